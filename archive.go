@@ -0,0 +1,76 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/bodgit/sevenzip"
+)
+
+// Reports whether a -location points at a dump archive rather than a folder.
+func isArchivePath(archivePath string) bool {
+	switch strings.ToLower(filepath.Ext(archivePath)) {
+	case ".zip", ".7z":
+		return true
+	}
+	return false
+}
+
+// Finds the folder holding TDATA inside an archive, either its root or a single top level folder such as "dump".
+func findDumpRoot(fsys fs.FS, archivePath string) (string, error) {
+	if info, err := fs.Stat(fsys, tdataFolder); err == nil && info.IsDir() {
+		return ".", nil
+	}
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if info, err := fs.Stat(fsys, path.Join(entry.Name(), tdataFolder)); err == nil && info.IsDir() {
+			return entry.Name(), nil
+		}
+	}
+	return "", fmt.Errorf("TDATA folder not found in %s", archivePath)
+}
+
+// Opens a ZIP or 7z archive of a dump so it can be scanned without extracting it. Files are hashed by streaming them out of the archive.
+func openArchiveSource(archivePath string) (*scanSource, func(), error) {
+	var archive fs.FS
+	var closeArchive func() error
+	if strings.EqualFold(filepath.Ext(archivePath), ".7z") {
+		reader, err := sevenzip.OpenReader(archivePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error opening archive: %v", err)
+		}
+		archive, closeArchive = reader, reader.Close
+	} else {
+		reader, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error opening archive: %v", err)
+		}
+		archive, closeArchive = reader, reader.Close
+	}
+
+	// Archives made on Linux keep whatever case the dump had there
+	archive = newFoldFS(archive)
+	root, err := findDumpRoot(archive, archivePath)
+	if err != nil {
+		closeArchive()
+		return nil, nil, err
+	}
+	sub, err := fs.Sub(archive, root)
+	if err != nil {
+		closeArchive()
+		return nil, nil, err
+	}
+	// Files in a solid 7z block can only be read by decompressing the block from its start
+	source := &scanSource{Display: filepath.Join(archivePath, filepath.FromSlash(root)), FS: sub, Serial: strings.EqualFold(filepath.Ext(archivePath), ".7z")}
+	return source, func() { closeArchive() }, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+// Problems found by artifactChecker.
+const (
+	artifactZeroByte  = "zero-byte"
+	artifactTemporary = "temporary"
+	artifactDuplicate = "duplicate"
+	// A name Windows can't create or open without the \\?\ prefix, which breaks copying the dump to a PC
+	artifactWindowsName = "windows-name"
+)
+
+// Extensions FTP clients and copy tools give files they haven't finished transferring.
+var temporaryExtensions = []string{".tmp", ".part", ".partial", ".filepart", ".crdownload", ".!ut"}
+
+// Markers copy tools add to a file name when a file already exists, as in "default (1).xbe" or "default - Copy.xbe".
+var copyMarker = regexp.MustCompile(`(?i)( \(\d+\)| - copy( \(\d+\))?|^copy of )`)
+
+// ArtifactReport is a file that is likely left over from an interrupted or repeated transfer.
+type ArtifactReport struct {
+	Path     string `json:"path" xml:"path"`
+	Source   string `json:"source,omitempty" xml:"source,attr,omitempty"`
+	Size     int64  `json:"size" xml:"size"`
+	Problem  string `json:"problem" xml:"problem,attr"`
+	Original string `json:"original,omitempty" xml:"original,omitempty"`
+}
+
+// artifactChecker flags transfer artifacts while walking TDATA. Duplicates are files in the same folder with the
+// same size whose names only differ by a copy marker.
+type artifactChecker struct {
+	source    *scanSource
+	directory string
+	seen      map[string]string
+}
+
+func newArtifactChecker(source *scanSource, directory string) *artifactChecker {
+	return &artifactChecker{source: source, directory: directory, seen: make(map[string]string)}
+}
+
+func (c *artifactChecker) check(filePath string, d fs.DirEntry) error {
+	info, err := d.Info()
+	if err != nil {
+		return err
+	}
+	name := d.Name()
+	artifact := ArtifactReport{Path: reportPath(c.directory, filePath), Source: c.source.reportName(), Size: info.Size()}
+
+	ext := path.Ext(name)
+	switch {
+	case info.Size() == 0:
+		artifact.Problem = artifactZeroByte
+		c.warn("Zero-byte file, likely an interrupted transfer: %s", c.source.displayPath(filePath))
+	case containsFold(temporaryExtensions, ext):
+		artifact.Problem = artifactTemporary
+		c.warn("Temporary transfer file, likely an interrupted transfer: %s", c.source.displayPath(filePath))
+	default:
+		key := fmt.Sprintf("%s/%s/%d", strings.ToLower(path.Dir(filePath)), strings.ToLower(copyMarker.ReplaceAllString(strings.TrimSuffix(name, ext), "")+ext), info.Size())
+		original, duplicate := c.seen[key]
+		if !duplicate {
+			c.seen[key] = filePath
+			return nil
+		}
+		// "default (1).xbe" sorts before "default.xbe", so the copy isn't always the second file walked
+		if !copyMarker.MatchString(name) && copyMarker.MatchString(path.Base(original)) {
+			c.seen[key] = filePath
+			filePath, original = original, filePath
+			artifact.Path = reportPath(c.directory, filePath)
+		}
+		artifact.Problem = artifactDuplicate
+		artifact.Original = reportPath(c.directory, original)
+		c.warn("Duplicate of %s with the same size: %s", c.source.displayPath(original), c.source.displayPath(filePath))
+	}
+	scanResults.Artifacts = append(scanResults.Artifacts, artifact)
+	return nil
+}
+
+// Flags a file or folder whose name breaks on Windows, such as AUX or a name ending with a dot. Pinecone reads and
+// exports them, but Explorer, FTP clients and most copy tools can't.
+func (c *artifactChecker) checkName(filePath string, d fs.DirEntry) {
+	problem := windowsNameProblem(d.Name())
+	if problem == "" {
+		return
+	}
+	artifact := ArtifactReport{Path: reportPath(c.directory, filePath), Source: c.source.reportName(), Problem: artifactWindowsName}
+	if info, err := d.Info(); err == nil && !d.IsDir() {
+		artifact.Size = info.Size()
+	}
+	c.warn("Name that will break on Windows, as %s: %s", problem, c.source.displayPath(filePath))
+	scanResults.Artifacts = append(scanResults.Artifacts, artifact)
+}
+
+func (c *artifactChecker) warn(format string, args ...any) {
+	if guiEnabled {
+		addText(theme.PrimaryColorNamed(theme.ColorYellow), format, args...)
+	}
+	defer itemOutput(onlyErrors)()
+	printInfo(fatihColor.FgYellow, format+"\n", args...)
+}
+
+// Reports whether slice holds val, ignoring case.
+func containsFold(slice []string, val string) bool {
+	for _, item := range slice {
+		if strings.EqualFold(item, val) {
+			return true
+		}
+	}
+	return false
+}
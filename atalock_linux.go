@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// SG_IO ATA PASS-THROUGH(16) constants.
+const (
+	sgIO                  = 0x2285
+	sgDxferToDev          = -2
+	sgDxferFromDev        = -3
+	ataPassThrough16      = 0x85
+	ataProtocolPIOIn      = 4
+	ataProtocolPIOOut     = 5
+	ataFlagsFromDev       = 0x0E // T_DIR, BYT_BLOK and T_LENGTH in the sector count
+	ataFlagsToDev         = 0x06
+	ataCommandIdentify    = 0xEC
+	ataCommandUnlock      = 0xF2
+	ataSecurityWord       = 128
+	ataSecurityLocked     = 1 << 2
+	ataSecurityEnabled    = 1 << 1
+	ataSerialWord         = 10
+	ataSerialWords        = 10
+	ataModelWord          = 27
+	ataModelWords         = 20
+	sgTimeoutMilliseconds = 10000
+)
+
+// sgIOHdr mirrors struct sg_io_hdr from <scsi/sg.h>.
+type sgIOHdr struct {
+	InterfaceID    int32
+	DxferDirection int32
+	CmdLen         uint8
+	MxSbLen        uint8
+	IovecCount     uint16
+	DxferLen       uint32
+	Dxferp         uintptr
+	Cmdp           uintptr
+	Sbp            uintptr
+	Timeout        uint32
+	Flags          uint32
+	PackID         int32
+	UsrPtr         uintptr
+	Status         uint8
+	MaskedStatus   uint8
+	MsgStatus      uint8
+	SbLenWr        uint8
+	HostStatus     uint16
+	DriverStatus   uint16
+	Resid          int32
+	Duration       uint32
+	Info           uint32
+}
+
+// Sends a single sector PIO ATA command to the drive through the SCSI generic layer.
+func ataCommand(device *os.File, command byte, data []byte, toDevice bool) error {
+	cdb := make([]byte, 16)
+	cdb[0] = ataPassThrough16
+	cdb[6] = 1 // sector count
+	cdb[14] = command
+	direction := int32(sgDxferFromDev)
+	if toDevice {
+		cdb[1] = ataProtocolPIOOut << 1
+		cdb[2] = ataFlagsToDev
+		direction = sgDxferToDev
+	} else {
+		cdb[1] = ataProtocolPIOIn << 1
+		cdb[2] = ataFlagsFromDev
+	}
+
+	sense := make([]byte, 32)
+	hdr := sgIOHdr{
+		InterfaceID:    'S',
+		DxferDirection: direction,
+		CmdLen:         uint8(len(cdb)),
+		MxSbLen:        uint8(len(sense)),
+		DxferLen:       uint32(len(data)),
+		Dxferp:         uintptr(unsafe.Pointer(&data[0])),
+		Cmdp:           uintptr(unsafe.Pointer(&cdb[0])),
+		Sbp:            uintptr(unsafe.Pointer(&sense[0])),
+		Timeout:        sgTimeoutMilliseconds,
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, device.Fd(), sgIO, uintptr(unsafe.Pointer(&hdr)))
+	runtime.KeepAlive(data)
+	runtime.KeepAlive(cdb)
+	runtime.KeepAlive(sense)
+	if errno != 0 {
+		return errno
+	}
+	if hdr.Status != 0 && hdr.Status != 2 || hdr.HostStatus != 0 {
+		return fmt.Errorf("ATA command 0x%02x failed (status 0x%02x, host status 0x%04x)", command, hdr.Status, hdr.HostStatus)
+	}
+	// A CHECK CONDITION carries the ATA status in the sense data; bit 0 of the status is ERR
+	if hdr.Status == 2 && hdr.SbLenWr > 21 && sense[21]&1 != 0 {
+		return fmt.Errorf("drive rejected ATA command 0x%02x", command)
+	}
+	return nil
+}
+
+// Decodes an IDENTIFY DEVICE string, which stores each pair of characters byte swapped.
+func ataString(identify []byte, word int, words int) string {
+	chars := make([]byte, words*2)
+	for i := 0; i < words; i++ {
+		chars[i*2] = identify[(word+i)*2+1]
+		chars[i*2+1] = identify[(word+i)*2]
+	}
+	return strings.TrimSpace(string(chars))
+}
+
+// Unlocks an attached drive locked by an Xbox, using the HDD key from its console's EEPROM.
+func unlockATADrive(devicePath string, hddKey []byte) error {
+	if err := checkReadOnly("unlock the drive, which opens it for writing"); err != nil {
+		return err
+	}
+	device, err := os.OpenFile(devicePath, os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("error opening drive: %v", err)
+	}
+	defer device.Close()
+
+	identify := make([]byte, 512)
+	if err := ataCommand(device, ataCommandIdentify, identify, false); err != nil {
+		return fmt.Errorf("error identifying drive: %v", err)
+	}
+	model := ataString(identify, ataModelWord, ataModelWords)
+	serial := ataString(identify, ataSerialWord, ataSerialWords)
+	security := uint16(identify[ataSecurityWord*2]) | uint16(identify[ataSecurityWord*2+1])<<8
+
+	password := hddPassword(hddKey, model, serial)
+	fmt.Printf("Drive %s (serial %s)\n", model, serial)
+	if security&ataSecurityEnabled == 0 || security&ataSecurityLocked == 0 {
+		fmt.Println("Drive is not locked")
+		return nil
+	}
+
+	// The unlock payload is a control word selecting the user password, followed by the 32 byte password field
+	payload := make([]byte, 512)
+	copy(payload[2:], password)
+	if err := ataCommand(device, ataCommandUnlock, payload, true); err != nil {
+		return fmt.Errorf("error unlocking drive: %v", err)
+	}
+	fmt.Println("Drive unlocked")
+	return nil
+}
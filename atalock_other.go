@@ -0,0 +1,10 @@
+//go:build !linux
+
+package main
+
+import "fmt"
+
+// Unlocking attached drives needs ATA pass-through, which is only implemented on Linux.
+func unlockATADrive(devicePath string, hddKey []byte) error {
+	return fmt.Errorf("unlocking drives is only supported on Linux, unlock %s with another tool first", devicePath)
+}
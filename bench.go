@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"runtime"
+	"sync"
+	"time"
+
+	fatihColor "github.com/fatih/color"
+)
+
+// Bytes read by each worker count's run of the hashing benchmark.
+const benchSampleSize = 128 << 20
+
+// Runs within this share of the fastest are considered as good, so the lowest such -j is recommended.
+const benchTolerance = 0.1
+
+// benchFile is a file picked to be hashed by the benchmark.
+type benchFile struct {
+	source *scanSource
+	path   string
+	size   int64
+}
+
+// Measures how fast the dump given by -location or -image can be walked and hashed, and with how many workers.
+func runBench(args []string) error {
+	flags := newCommandFlags("bench")
+	addSourceFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("usage: pinecone bench [flags]")
+	}
+	applyLocationFlags()
+	sources, closeSources, err := openScanSources(scanLocations())
+	if err != nil {
+		return err
+	}
+	defer closeSources()
+	ctx, release := newScanContext()
+	defer release()
+
+	printHeader("Walk")
+	var files []benchFile
+	var entries int
+	var totalSize int64
+	start := time.Now()
+	for _, source := range sources {
+		err := fs.WalkDir(source.FS, ".", func(filePath string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			entries++
+			if d.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			totalSize += info.Size()
+			if info.Size() > 0 {
+				files = append(files, benchFile{source: source, path: filePath, size: info.Size()})
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("error walking %s: %v", source.displayPath("."), scanError(ctx, err))
+		}
+	}
+	elapsed := time.Since(start)
+	printInfo(fatihColor.FgWhite, "%d files and folders (%.1f MB) walked in %s, %.0f entries/s\n", entries, float64(totalSize)/1e6, elapsed.Round(time.Millisecond), float64(entries)/elapsed.Seconds())
+	if len(files) == 0 {
+		return fmt.Errorf("no files to hash")
+	}
+
+	// Every run hashes files of its own, so later runs aren't sped up by the OS caching what earlier runs read
+	jobCounts := benchJobCounts()
+	samples := make([][]benchFile, len(jobCounts))
+	run := 0
+	var sampled int64
+	for _, file := range files {
+		samples[run] = append(samples[run], file)
+		sampled += file.size
+		if sampled >= benchSampleSize {
+			run++
+			sampled = 0
+			if run == len(jobCounts) {
+				break
+			}
+		}
+	}
+	filled := 0
+	for filled < len(samples) && len(samples[filled]) > 0 {
+		filled++
+	}
+	if filled < len(samples) {
+		printInfo(fatihColor.FgYellow, "The dump is too small for %d MB per run, so some runs reuse files and may be sped up by the OS cache\n", benchSampleSize>>20)
+		for i := filled; i < len(samples); i++ {
+			samples[i] = samples[i%filled]
+		}
+	}
+
+	printHeader("Hashing")
+	best := 0.0
+	speeds := make([]float64, len(jobCounts))
+	for i, jobs := range jobCounts {
+		size, elapsed, err := benchHash(ctx, samples[i], jobs)
+		if err != nil {
+			return scanError(ctx, err)
+		}
+		speeds[i] = float64(size) / 1e6 / elapsed.Seconds()
+		best = max(best, speeds[i])
+		printInfo(fatihColor.FgWhite, "-j=%d: %.1f MB hashed in %s, %.1f MB/s\n", jobs, float64(size)/1e6, elapsed.Round(time.Millisecond), speeds[i])
+	}
+	for i, jobs := range jobCounts {
+		if speeds[i] >= best*(1-benchTolerance) {
+			printInfo(fatihColor.FgGreen, "Recommended: -j=%d\n", jobs)
+			if jobs == 1 && len(jobCounts) > 1 {
+				printInfo(fatihColor.FgGreen, "This storage doesn't get faster with more workers, which is typical of spinning disks and network shares\n")
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// Returns the worker counts to try: powers of two up to the number of CPUs, and the number of CPUs itself.
+func benchJobCounts() []int {
+	var counts []int
+	for jobs := 1; jobs < runtime.NumCPU() && jobs < 16; jobs *= 2 {
+		counts = append(counts, jobs)
+	}
+	return append(counts, min(runtime.NumCPU(), 16))
+}
+
+// Hashes files on the given number of workers, returning the bytes hashed and how long it took.
+func benchHash(ctx context.Context, files []benchFile, jobs int) (int64, time.Duration, error) {
+	queue := make(chan benchFile)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	start := time.Now()
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range queue {
+				if _, err := getFileHashes(ctx, file.source.FS, file.path); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = fmt.Errorf("error hashing %s: %v", file.source.displayPath(file.path), err)
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	var size int64
+	for _, file := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		queue <- file
+		size += file.size
+	}
+	close(queue)
+	wg.Wait()
+	return size, time.Since(start), firstErr
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"time"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+// Name of the manifest inside a submission bundle.
+const bundleManifestName = "manifest.json"
+
+// Types of the items in a submission bundle.
+const (
+	bundleItemContent      = "content"
+	bundleItemUpdate       = "update"
+	bundleItemUnknownTitle = "unknown title"
+)
+
+// BundleManifest describes the content packed into a submission bundle, for the Pinecone team to check and add to the
+// database.
+type BundleManifest struct {
+	Scanner   string           `json:"scanner"`
+	Created   string           `json:"created"`
+	Databases []DatabaseReport `json:"databases,omitempty"`
+	Items     []BundleItem     `json:"items"`
+}
+
+// BundleItem is an unarchived content folder or title update, or the content and updates of an unknown title.
+type BundleItem struct {
+	Type      string `json:"type"`
+	TitleID   string `json:"titleID"`
+	TitleName string `json:"titleName,omitempty"`
+	ContentID string `json:"contentID,omitempty"`
+	// Name is the display name in the content's contentmeta.xbx, or the known name of an update
+	Name   string       `json:"name,omitempty"`
+	Known  bool         `json:"known"`
+	Source string       `json:"source,omitempty"`
+	Meta   *ContentMeta `json:"meta,omitempty"`
+	XBE    *XBEReport   `json:"xbe,omitempty"`
+	// Path is the item's folder or file under TDATA, in the bundle or in the dump
+	Path  string       `json:"path"`
+	Files []FileReport `json:"files"`
+}
+
+// Returns the unarchived content and updates of a report, and the unknown titles holding any, as bundle items with
+// the files and hashes the scan found.
+func bundleItems(report *ScanReport) []BundleItem {
+	var items []BundleItem
+	for _, title := range report.Titles {
+		if title.Unknown != nil {
+			// Unknown titles are only worth submitting for the content or updates in them
+			source := report.titleSource(&title)
+			if source == nil || len(unknownTitleFiles(source.FS, path.Join(tdataFolder, title.Path))) == 0 {
+				continue
+			}
+			item := BundleItem{Type: bundleItemUnknownTitle, TitleID: title.TitleID, TitleName: title.Unknown.ProbableName, Source: title.Source, Path: path.Join(tdataFolder, title.Path)}
+			for _, xbe := range title.Unknown.XBEs {
+				item.Files = append(item.Files, FileReport{Path: path.Join(tdataFolder, xbe.Path), SHA1: xbe.SHA1})
+			}
+			items = append(items, item)
+			continue
+		}
+		for _, content := range title.Content {
+			if content.Archived {
+				continue
+			}
+			item := BundleItem{Type: bundleItemContent, TitleID: title.TitleID, TitleName: title.TitleName, ContentID: content.ContentID, Known: content.Known, Source: title.Source, Meta: content.Meta, Path: path.Join(tdataFolder, content.Path)}
+			if content.Meta != nil {
+				item.Name = content.Meta.DisplayName
+			}
+			for _, file := range content.Files {
+				file.Path = path.Join(item.Path, file.Path)
+				item.Files = append(item.Files, file)
+			}
+			items = append(items, item)
+		}
+		for _, update := range title.Updates {
+			if update.Archived || update.Error != "" {
+				continue
+			}
+			item := BundleItem{Type: bundleItemUpdate, TitleID: title.TitleID, TitleName: title.TitleName, Name: update.Name, Known: update.Known, Source: title.Source, XBE: update.XBE, Path: path.Join(tdataFolder, update.Path)}
+			item.Files = []FileReport{{Path: item.Path, Size: update.Size, SHA1: update.SHA1, MD5: update.MD5, CRC32: update.CRC32, XXH64: update.XXH64}}
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Returns the files of an unknown title worth submitting: everything in its $c and $u folders.
+func unknownTitleFiles(fsys fs.FS, titleDir string) []string {
+	var files []string
+	for _, reserved := range []string{"$c", "$u"} {
+		fs.WalkDir(fsys, path.Join(titleDir, reserved), func(filePath string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				files = append(files, filePath)
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+// Returns the files of an item, as paths inside its source.
+func (item *BundleItem) sourceFiles(fsys fs.FS) ([]string, error) {
+	if item.Type == bundleItemUnknownTitle {
+		return unknownTitleFiles(fsys, item.Path), nil
+	}
+	var files []string
+	err := fs.WalkDir(fsys, item.Path, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, filePath)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// Copies a file of the dump into the zip, hashing it on the way.
+func addBundleFile(zw *zip.Writer, fsys fs.FS, filePath, zipPath string) (FileReport, error) {
+	in, err := fsys.Open(filePath)
+	if err != nil {
+		return FileReport{}, err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return FileReport{}, err
+	}
+	out, err := zw.CreateHeader(&zip.FileHeader{Name: zipPath, Method: zip.Deflate, Modified: info.ModTime()})
+	if err != nil {
+		return FileReport{}, err
+	}
+	return copyHashed(out, in, zipPath)
+}
+
+// Copies a file to out, returning its report, under reportPath, with the hashes of what was copied.
+func copyHashed(out io.Writer, in io.Reader, reportPath string) (FileReport, error) {
+	sha1Hash, md5Hash, crc32Hash, xxHash := sha1.New(), md5.New(), crc32.NewIEEE(), newXXHash64()
+	size, err := io.Copy(io.MultiWriter(out, sha1Hash, md5Hash, crc32Hash, xxHash), in)
+	if err != nil {
+		return FileReport{}, err
+	}
+	return newFileReport(reportPath, size, FileHashes{
+		SHA1:  fmt.Sprintf("%x", sha1Hash.Sum(nil)),
+		MD5:   fmt.Sprintf("%x", md5Hash.Sum(nil)),
+		CRC32: fmt.Sprintf("%08x", crc32Hash.Sum32()),
+		XXH64: fmt.Sprintf("%016x", xxHash.Sum64()),
+	}), nil
+}
+
+// Copies the files of items out of the sources of a report through add, which is given each file's path in its source
+// and in the copy, and returns the manifest of what was copied. Each source gets its own folder in the copy, so
+// content of the same title from several consoles doesn't clash.
+func copyBundleItems(report *ScanReport, items []BundleItem, created time.Time, add func(source *scanSource, filePath, outPath string) (FileReport, error)) (BundleManifest, error) {
+	manifest := BundleManifest{Scanner: "Pinecone v" + version, Created: created.UTC().Format(time.RFC3339), Databases: report.Databases}
+	for _, item := range items {
+		source := report.sourceByLabel(item.Source)
+		if source == nil {
+			continue
+		}
+		files, err := item.sourceFiles(source.FS)
+		if err != nil {
+			return manifest, fmt.Errorf("error reading %s: %v", source.displayPath(item.Path), err)
+		}
+		if len(files) == 0 {
+			continue
+		}
+		prefix := ""
+		if item.Source != "" {
+			prefix = unsafeFileNameChars.ReplaceAllString(item.Source, "_") + "/"
+		}
+		// The files are hashed again as they're copied, which also fills in hashes --fast-hash skipped
+		item.Path = prefix + item.Path
+		item.Files = nil
+		for _, filePath := range files {
+			fileReport, err := add(source, filePath, prefix+filePath)
+			if err != nil {
+				return manifest, fmt.Errorf("error copying %s: %v", source.displayPath(filePath), err)
+			}
+			item.Files = append(item.Files, fileReport)
+		}
+		manifest.Items = append(manifest.Items, item)
+	}
+	return manifest, nil
+}
+
+// Writes a zip of the unarchived and unknown content of a scan, laid out as in the dump, with a manifest of what
+// each item is, ready to attach to a GitHub issue.
+func writeBundle(bundlePath string, report *ScanReport) error {
+	items := bundleItems(report)
+	if len(items) == 0 {
+		if guiEnabled {
+			addText(theme.ForegroundColor(), "Nothing unarchived to bundle")
+		}
+		printInfo(fatihColor.FgWhite, "Nothing unarchived to bundle\n")
+		return nil
+	}
+	if err := checkWritable(bundlePath); err != nil {
+		return err
+	}
+	file, err := os.Create(bundlePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	zw := zip.NewWriter(file)
+
+	created := time.Now()
+	manifest, err := copyBundleItems(report, items, created, func(source *scanSource, filePath, outPath string) (FileReport, error) {
+		return addBundleFile(zw, source.FS, filePath, outPath)
+	})
+	if err != nil {
+		return err
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	out, err := zw.CreateHeader(&zip.FileHeader{Name: bundleManifestName, Method: zip.Deflate, Modified: created})
+	if err != nil {
+		return err
+	}
+	if _, err := out.Write(manifestData); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	if guiEnabled {
+		addText(theme.ForegroundColor(), "%d items bundled for submission to: %s", len(manifest.Items), bundlePath)
+	}
+	printInfo(fatihColor.FgWhite, "%d items bundled for submission to: %s\n", len(manifest.Items), bundlePath)
+	return nil
+}
+
+// Points out, after a scan that found unarchived or unknown content, that it can be bundled for submission.
+func offerBundle(report *ScanReport) {
+	items := bundleItems(report)
+	if len(items) == 0 {
+		return
+	}
+	if guiEnabled {
+		addText(theme.PrimaryColorNamed(theme.ColorYellow), "%d unarchived or unknown items found, scan again with --bundle=submission.zip to package them for the Pinecone team", len(items))
+	}
+	printInfo(fatihColor.FgYellow, "%d unarchived or unknown items found, scan again with --bundle=submission.zip to package them for the Pinecone team\n", len(items))
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+// CacheReport describes what a title left behind on an X/Y/Z cache partition.
+type CacheReport struct {
+	Partition string       `json:"partition" xml:"partition,attr"`
+	Files     int          `json:"files" xml:"files"`
+	Size      int64        `json:"size" xml:"size"`
+	Titles    []CacheTitle `json:"titles,omitempty" xml:"title,omitempty"`
+	Findings  []string     `json:"findings,omitempty" xml:"finding,omitempty"`
+}
+
+// CacheTitle is a title whose data was found in a cache partition, with the first path that pointed to it.
+type CacheTitle struct {
+	TitleID   string `json:"titleID" xml:"titleID,attr"`
+	TitleName string `json:"titleName,omitempty" xml:"titleName,omitempty"`
+	Known     bool   `json:"known" xml:"known,attr"`
+	Evidence  string `json:"evidence" xml:"evidence"`
+}
+
+// Reports whether s is a hexadecimal string of the given length.
+func isHexString(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, c := range strings.ToLower(s) {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the title whose database entry lists a content ID, if any.
+func contentIDTitle(contentID string) (string, bool) {
+	titleID, ok := databaseIndex.contentTitles[contentID]
+	return titleID, ok
+}
+
+// Walks a cache partition, mapping leftover data back to title IDs and flagging DLC staged in the cache.
+func scanCachePartition(ctx context.Context, source *scanSource) error {
+	cache := CacheReport{Partition: source.Label}
+	seen := make(map[string]bool)
+	addTitle := func(titleID string, evidence string) {
+		titleID = strings.ToLower(titleID)
+		if seen[titleID] {
+			return
+		}
+		seen[titleID] = true
+		titleData, _, ok := lookupTitle(titleID)
+		cache.Titles = append(cache.Titles, CacheTitle{
+			TitleID:   titleID,
+			TitleName: titleData.TitleName,
+			Known:     ok,
+			Evidence:  evidence,
+		})
+	}
+	addFinding := func(format string, args ...any) {
+		cache.Findings = append(cache.Findings, fmt.Sprintf(format, args...))
+	}
+
+	err := fs.WalkDir(source.FS, ".", func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		name := d.Name()
+		if d.IsDir() {
+			// Titles usually keep their cache in a folder named after their title ID
+			if isHexString(name, 8) {
+				if _, _, ok := lookupTitle(strings.ToLower(name)); ok {
+					addTitle(name, filePath)
+				}
+			}
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		cache.Files++
+		cache.Size += info.Size()
+
+		switch {
+		case strings.EqualFold(path.Ext(name), ".xbe"):
+			xbe, err := readXBEFile(source.FS, filePath)
+			if err != nil {
+				addFinding("Unreadable XBE at %s: %v", source.displayPath(filePath), err)
+				return nil
+			}
+			addTitle(xbe.TitleID, filePath)
+			addFinding("XBE for %s (%s) at %s", xbe.TitleName, xbe.TitleID, source.displayPath(filePath))
+		case strings.EqualFold(name, "contentmeta.xbx"):
+			contentDir := path.Dir(filePath)
+			contentID := strings.ToLower(path.Base(contentDir))
+			if titleID, ok := contentIDTitle(contentID); ok {
+				addTitle(titleID, filePath)
+				addFinding("Known DLC %s for %s staged at %s", contentID, titleID, source.displayPath(contentDir))
+			} else {
+				addFinding("Unknown DLC staged at %s", source.displayPath(contentDir))
+			}
+			if meta, err := readContentMeta(source.FS, filePath); err == nil && meta.TitleID != "" {
+				addTitle(meta.TitleID, filePath)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error reading cache partition %s: %v", source.Label, err)
+	}
+
+	if guiEnabled {
+		addText(theme.ForegroundColor(), "%d files (%.1f MB) in cache", cache.Files, float64(cache.Size)/1e6)
+	}
+	printInfo(fatihColor.FgWhite, "%d files (%.1f MB) in cache\n", cache.Files, float64(cache.Size)/1e6)
+	for _, title := range cache.Titles {
+		if title.Known {
+			if guiEnabled {
+				addText(theme.PrimaryColorNamed(theme.ColorGreen), "Cache data for %s (%s) at %s", title.TitleName, title.TitleID, source.displayPath(title.Evidence))
+			}
+			printInfo(fatihColor.FgGreen, "Cache data for %s (%s) at %s\n", title.TitleName, title.TitleID, source.displayPath(title.Evidence))
+		} else {
+			if guiEnabled {
+				addText(theme.PrimaryColorNamed(theme.ColorYellow), "Cache data for unknown title %s at %s", title.TitleID, source.displayPath(title.Evidence))
+			}
+			printInfo(fatihColor.FgYellow, "Cache data for unknown title %s at %s\n", title.TitleID, source.displayPath(title.Evidence))
+		}
+	}
+	for _, finding := range cache.Findings {
+		if guiEnabled {
+			addText(theme.PrimaryColorNamed(theme.ColorYellow), "%s", finding)
+		}
+		printInfo(fatihColor.FgYellow, "%s\n", finding)
+	}
+
+	scanResults.Caches = append(scanResults.Caches, cache)
+	return nil
+}
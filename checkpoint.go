@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// How often the progress of a scan is written to its checkpoint.
+const checkpointInterval = 30 * time.Second
+
+// scanCheckpoint records the progress of a scan, so one that crashed or was interrupted can pick up where it stopped
+// with --resume. Walking a dump again is quick, so only the titles walked and the hashes computed are kept; a resumed
+// scan walks everything again but only hashes the files it hadn't got to.
+type scanCheckpoint struct {
+	path     string
+	location string
+	hashes   *hashCache
+
+	mu     sync.Mutex
+	walked []string
+	saved  time.Time
+}
+
+// checkpointFile is how a checkpoint is stored on disk.
+type checkpointFile struct {
+	Location string                    `json:"location"`
+	Walked   []string                  `json:"walked"`
+	Hashes   map[string]hashCacheEntry `json:"hashes"`
+}
+
+// The checkpoint of the scan in progress, or nil.
+var currentCheckpoint *scanCheckpoint
+
+// Returns where checkpoints are kept.
+func checkpointPath() string {
+	return filepath.Join(dataPath, "checkpoint.json")
+}
+
+// Starts checkpointing a scan of location. With resume set, the scan continues from the checkpoint left by an
+// interrupted scan of the same location.
+func startCheckpoint(location string, resume bool) (*scanCheckpoint, error) {
+	checkpoint := &scanCheckpoint{
+		path:     checkpointPath(),
+		location: location,
+		hashes:   &hashCache{entries: make(map[string]hashCacheEntry)},
+		saved:    time.Now(),
+	}
+	if !resume {
+		return checkpoint, nil
+	}
+
+	data, err := os.ReadFile(checkpoint.path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no interrupted scan to resume")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading checkpoint: %v", err)
+	}
+	var saved checkpointFile
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("error parsing checkpoint: %v", err)
+	}
+	if saved.Location != location {
+		return nil, fmt.Errorf("the interrupted scan was of %s, not %s", saved.Location, location)
+	}
+	if saved.Hashes != nil {
+		checkpoint.hashes.entries = saved.Hashes
+	}
+	checkpoint.walked = saved.Walked
+	printLine("Resuming the scan of %s: %d titles walked and %d files hashed before it stopped", location, len(saved.Walked), len(saved.Hashes))
+	return checkpoint, nil
+}
+
+// Records that a title directory has been walked, saving the checkpoint if it hasn't been saved for a while.
+func (c *scanCheckpoint) titleWalked(titlePath string) error {
+	c.mu.Lock()
+	if !contains(c.walked, titlePath) {
+		c.walked = append(c.walked, titlePath)
+	}
+	due := time.Since(c.saved) >= checkpointInterval
+	c.mu.Unlock()
+	if !due {
+		return nil
+	}
+	return c.save()
+}
+
+// Writes the checkpoint to disk.
+func (c *scanCheckpoint) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hashes.mu.Lock()
+	data, err := json.Marshal(checkpointFile{Location: c.location, Walked: c.walked, Hashes: c.hashes.entries})
+	c.hashes.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := checkWritable(c.path); err != nil {
+		return err
+	}
+	// Written aside and renamed, so a crash while saving doesn't lose the previous checkpoint
+	if err := os.WriteFile(c.path+".tmp", data, 0o644); err != nil {
+		return fmt.Errorf("error writing checkpoint: %v", err)
+	}
+	if err := os.Rename(c.path+".tmp", c.path); err != nil {
+		return fmt.Errorf("error writing checkpoint: %v", err)
+	}
+	c.saved = time.Now()
+	return nil
+}
+
+// Removes the checkpoint once the scan has finished.
+func (c *scanCheckpoint) remove() {
+	os.Remove(c.path)
+}
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/fatih/color"
@@ -21,6 +24,11 @@ type CLIOptions struct {
 
 func printHeader(title string) {
 	title = strings.TrimSpace(title)
+	logMessage(color.FgCyan, title)
+	if !showOutput() {
+		return
+	}
+	defer pauseProgress()()
 	if len(title) > headerWidth-6 { // -6 to account for spaces and equals signs
 		title = title[:headerWidth-9] + "..."
 	}
@@ -30,32 +38,66 @@ func printHeader(title string) {
 }
 
 func printInfo(colorCode color.Attribute, format string, args ...interface{}) {
+	logMessage(colorCode, fmt.Sprintf(format, args...))
+	if !showOutput() {
+		return
+	}
+	defer pauseProgress()()
 	color.New(colorCode).Printf("    "+format, args...)
 }
 
-// Prints statistics for a specific title or for all titles if batch is true.
-func printStats(titleID string, batch bool) {
+// Turns colored output off for --no-color, and for the NO_COLOR environment variable (https://no-color.org), so
+// output piped to a file isn't filled with escape codes. --no-color=false leaves color as it is.
+func disableColor(value string) error {
+	disable, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	if disable {
+		color.NoColor = true
+	}
+	return nil
+}
+
+// Prints an uncolored line, logging it too.
+func printLine(format string, args ...interface{}) {
+	logMessage(color.FgWhite, fmt.Sprintf(format, args...))
+	if !showOutput() {
+		return
+	}
+	defer pauseProgress()()
+	fmt.Printf(format+"\n", args...)
+}
+
+// Prints statistics for the titles --titleid matches, or for all titles if batch is true.
+func printStats(batch bool) {
 	if batch {
 		printTotalStats()
 	} else {
-		data, ok := titles.Titles[titleID]
-		if !ok {
-			fmt.Printf("No data found for title ID %s\n", titleID)
+		titleIDs := selectedTitleIDs()
+		if len(titleIDs) == 0 {
+			printLine("No data found for title ID %s", titleIDFlag)
 			return
 		}
-		fmt.Printf("Statistics for title ID %s:\n", titleID)
-		printTitleStats(&data)
+		for _, titleID := range titleIDs {
+			data, _, _ := lookupTitle(titleID)
+			printLine("Statistics for title ID %s:", titleID)
+			printTitleStats(&data)
+		}
 	}
 }
 
 // Prints statistics for TitleData.
 func printTitleStats(data *TitleData) {
-	fmt.Println("Title:", data.TitleName)
-	fmt.Println("Total number of Content IDs:", len(data.ContentIDs))
-	fmt.Println("Total number of Title Updates:", len(data.TitleUpdates))
-	fmt.Println("Total number of Known Title Updates:", len(data.TitleUpdatesKnown))
-	fmt.Println("Total number of Archived items:", len(data.Archived))
-	fmt.Println()
+	printLine("Title: %s", data.TitleName)
+	printLine("Total number of Content IDs: %d", len(data.ContentIDs))
+	printLine("Total number of Title Updates: %d", len(data.TitleUpdates))
+	printLine("Total number of Known Title Updates: %d", len(data.TitleUpdatesKnown))
+	printLine("Total number of Archived items: %d", len(data.Archived))
+	if len(data.Insignia) > 0 {
+		printLine("Total number of Insignia downloads: %d", len(data.Insignia))
+	}
+	printLine("")
 }
 
 func printTotalStats() {
@@ -64,6 +106,9 @@ func printTotalStats() {
 	totalTitleUpdates := 0
 	totalKnownTitleUpdates := 0
 	totalArchivedItems := 0
+	totalInsigniaContent := 0
+	totalInsigniaReissues := 0
+	totalArchivedInsignia := 0
 
 	// Set to store unique hashes of known title updates and archived items
 	knownTitleUpdateHashes := make(map[string]struct{})
@@ -72,6 +117,13 @@ func printTotalStats() {
 	for _, data := range titles.Titles {
 		totalContentIDs += len(data.ContentIDs)
 		totalTitleUpdates += len(data.TitleUpdates)
+		for contentID := range data.Insignia {
+			if data.insigniaOnly(contentID) {
+				totalInsigniaContent++
+			} else {
+				totalInsigniaReissues++
+			}
+		}
 
 		// Count unique known title updates
 		for _, knownUpdate := range data.TitleUpdatesKnown {
@@ -81,8 +133,13 @@ func printTotalStats() {
 		}
 
 		// Count unique archived items
+		// Archived downloads of the Insignia revival are counted apart from original Xbox Live content
 		for _, archivedItem := range data.Archived {
 			for hash := range archivedItem {
+				if data.insigniaOnly(hash) {
+					totalArchivedInsignia++
+					continue
+				}
 				archivedItemHashes[hash] = struct{}{}
 			}
 		}
@@ -91,11 +148,20 @@ func printTotalStats() {
 	totalKnownTitleUpdates = len(knownTitleUpdateHashes)
 	totalArchivedItems = len(archivedItemHashes)
 
-	fmt.Println("Total Titles:", totalTitles)
-	fmt.Println("Total Content IDs:", totalContentIDs)
-	fmt.Println("Total Title Updates:", totalTitleUpdates)
-	fmt.Println("Total Known Title Updates:", totalKnownTitleUpdates)
-	fmt.Println("Total Archived Items:", totalArchivedItems)
+	printLine("Total Titles: %d", totalTitles)
+	printLine("Total Content IDs: %d", totalContentIDs)
+	printLine("Total Title Updates: %d", totalTitleUpdates)
+	printLine("Total Known Title Updates: %d", totalKnownTitleUpdates)
+	printLine("Total Archived Items: %d", totalArchivedItems)
+	if totalInsigniaContent+totalInsigniaReissues > 0 {
+		printLine("Total Insignia Downloads: %d", totalInsigniaContent)
+		printLine("Total Archived Insignia Downloads: %d", totalArchivedInsignia)
+		printLine("Total Insignia Re-issues: %d", totalInsigniaReissues)
+	}
+	printLine("Total Homebrew Titles: %d", len(titles.Homebrew))
+	printLine("Total Chihiro Titles: %d", len(titles.Chihiro))
+	printLine("Total Debug Titles: %d", len(titles.Debug))
+	printCoverage()
 }
 
 func cliPromptForDownload(url string) bool {
@@ -107,6 +173,290 @@ func cliPromptForDownload(url string) bool {
 	return strings.ToLower(response) == "yes"
 }
 
+// command is a subcommand of Pinecone, such as "pinecone scan".
+type command struct {
+	name string
+	// usage shows the command's flags and arguments
+	usage   string
+	summary string
+	// run is given the arguments after the command's name, which it parses with newCommandFlags
+	run func(args []string) error
+}
+
+// Returns the commands, in the order help lists them.
+func commandList() []command {
+	return []command{
+		{"scan", "[flags] [location...|-]", "Scan dumps, archives, HDD images, discs or consoles for content and title updates without the GUI, writing the reports asked for. With -, hashes the files listed on stdin and prints a verdict for each.", runScanCommand},
+		{"summarize", "[-titleid ID]", "Print summary statistics and archival coverage for all titles, or the details of one.", runSummarize},
+		{"update-db", "[flags]", "Download the latest databases.", runUpdateDatabase},
+		{"verify", "[-write] [flags] SHA1SUMS", "Re-check the dump against a SHA1SUMS manifest, reporting added, missing and changed files, or write one with -write.", runVerify},
+		{"export", "[-layout archive] [-hardlink] [flags] folder", "Scan the -location and copy the updates and content identified into a folder per title, adding only what's new.", runExport},
+		{"backup", "[-target s3://bucket/prefix|b2://bucket/prefix?region=...|webdavs://host/path] [flags]", "Scan the -location and copy its unarchived and unknown content, with a manifest, to off-site storage.", runBackup},
+		{"lookup", "<title ID, name or SHA1>", "Show what the database knows of a title, or which title update or file a hash belongs to.", func(args []string) error {
+			flags := newCommandFlags("lookup")
+			if err := flags.Parse(args); err != nil {
+				return err
+			}
+			if flags.NArg() == 0 {
+				return fmt.Errorf("usage: pinecone lookup <title ID, name or SHA1>")
+			}
+			return runLookup(strings.Join(flags.Args(), " "))
+		}},
+		{"hash", "<file or SHA1>...", "Hash files, or take SHA1s as given, and show which known title update or content each matches.", func(args []string) error {
+			flags := newCommandFlags("hash")
+			if err := flags.Parse(args); err != nil {
+				return err
+			}
+			if flags.NArg() == 0 {
+				return fmt.Errorf("usage: pinecone hash <file or SHA1>...")
+			}
+			return runHash(flags.Args())
+		}},
+		{"wanted", "[-title ID or name] [-publisher code] [-format text|md|csv]", "List the content and title updates the database knows of that nobody has archived yet.", runWanted},
+		{"serve", "[-addr 127.0.0.1:8080] [-token secret] [flags]", "Run an HTTP API to start scans, stream their progress, fetch reports and query the database.", runServe},
+		{"diff", "old.json new.json", "Show what changed between two reports written with -output.", func(args []string) error {
+			flags := newCommandFlags("diff")
+			if err := flags.Parse(args); err != nil {
+				return err
+			}
+			if flags.NArg() != 2 {
+				return fmt.Errorf("usage: pinecone diff old-report.json new-report.json")
+			}
+			return runDiff(flags.Arg(0), flags.Arg(1))
+		}},
+		{"bench", "[flags]", "Measure how fast the -location or -image can be walked and hashed, and recommend a -j setting.", runBench},
+		{"drives", "", "List attached drives that can be scanned with -image, marking Xbox drives. (Windows Only)", func(args []string) error {
+			flags := newCommandFlags("drives")
+			if err := flags.Parse(args); err != nil {
+				return err
+			}
+			return runDrives()
+		}},
+		{"torrent", "[-tracker URL]... [-webseed URL]... [-private] [-o file.torrent] bundle.zip|folder", "Make a .torrent to share a --bundle, --pull or export folder with the preservation group.", runTorrent},
+		{"db", "validate [id_database.json]", "Check the database, or a shard directory, for duplicate IDs, malformed hashes, empty names or unknown fields.", func(args []string) error {
+			flags := newCommandFlags("db")
+			if err := flags.Parse(args); err != nil {
+				return err
+			}
+			if flags.NArg() < 1 || flags.Arg(0) != "validate" || flags.NArg() > 2 {
+				return fmt.Errorf("usage: pinecone db validate [id_database.json]")
+			}
+			dbPath := filepath.Join(dataPath, "id_database.json")
+			if isShardedDatabase(dbPath) {
+				dbPath = shardDir(dbPath)
+			}
+			if flags.NArg() == 2 {
+				dbPath = flags.Arg(1)
+			}
+			return runValidate(dbPath)
+		}},
+		{"rollback", "", "Restore the database kept from before the last update, or undo the last rollback.", func(args []string) error {
+			flags := newCommandFlags("rollback")
+			if err := flags.Parse(args); err != nil {
+				return err
+			}
+			return runRollback()
+		}},
+		{"shard", "[id_database.json] id_database", "Split a database into one file per title, or index the shards of one already split.", func(args []string) error {
+			flags := newCommandFlags("shard")
+			if err := flags.Parse(args); err != nil {
+				return err
+			}
+			switch flags.NArg() {
+			case 1:
+				return runShard("", flags.Arg(0))
+			case 2:
+				return runShard(flags.Arg(0), flags.Arg(1))
+			}
+			return fmt.Errorf("usage: pinecone shard [id_database.json] id_database")
+		}},
+	}
+}
+
+// Returns a flag set for a command, whose -h prints the command's usage and flags.
+func newCommandFlags(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.BoolFunc("no-color", "Print without colors", disableColor)
+	flags.Usage = func() {
+		for _, cmd := range commandList() {
+			if cmd.name == name {
+				fmt.Fprintf(flags.Output(), "Usage: pinecone %s %s\n\n%s\n", cmd.name, cmd.usage, cmd.summary)
+			}
+		}
+		hasFlags := false
+		flags.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(flags.Output(), "\nFlags:")
+			flags.PrintDefaults()
+		}
+	}
+	return flags
+}
+
+// Prints the commands and what they do, for -help.
+func printCommands() {
+	fmt.Println("Commands:")
+	for _, cmd := range commandList() {
+		fmt.Printf("  %s: %s\n", strings.TrimSpace(cmd.name+" "+cmd.usage), cmd.summary)
+	}
+	fmt.Println("Run pinecone help <command> for the flags of a command.")
+}
+
+// Runs a command given as positional arguments, e.g. "pinecone diff old.json new.json". The flags given before the
+// command still apply, so "pinecone -l=dump export archive" works as well as "pinecone export -l=dump archive".
+func runCommand(args []string) error {
+	if args[0] == "help" {
+		if len(args) == 1 {
+			printHelp()
+			return nil
+		}
+		return runCommand([]string{args[1], "-h"})
+	}
+	for _, cmd := range commandList() {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:])
+		}
+	}
+	return fmt.Errorf("unknown command %q, see -help", args[0])
+}
+
+// Scans the locations given, or those of -location, from the command line, for pinecone scan.
+func runScanCommand(args []string) error {
+	flags := newCommandFlags("scan")
+	flags.BoolVar(&updateFlag, "update", updateFlag, "Update the databases before scanning")
+	flags.BoolVar(&updateFlag, "u", updateFlag, "Update the databases before scanning")
+	addSourceFlags(flags)
+	addScanFlags(flags)
+	addReportFlags(flags)
+	addDatabaseFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 1 && flags.Arg(0) == "-" {
+		guiEnabled = false
+		return runFileListScan()
+	}
+	for _, location := range flags.Args() {
+		locationsFlag.Set(location)
+	}
+	applyLocationFlags()
+	guiEnabled = false
+	if err := prepareScan(); err != nil {
+		return err
+	}
+	options, err := scanOptions()
+	if err != nil {
+		return err
+	}
+	startCLI(options)
+	return nil
+}
+
+// Prints the statistics of every title, or of the one given with -titleid, for pinecone summarize.
+func runSummarize(args []string) error {
+	flags := newCommandFlags("summarize")
+	flags.StringVar(&titleIDFlag, "titleid", titleIDFlag, "Only print the details of the titles with these comma-separated IDs or wildcards")
+	flags.StringVar(&titleIDFlag, "tID", titleIDFlag, "Only print the details of the titles with these comma-separated IDs or wildcards")
+	addDatabaseFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("usage: pinecone summarize [-titleid ID]")
+	}
+	if err := loadCLIDatabase(false); err != nil {
+		return err
+	}
+	if err := validateTitleFilter(); err != nil {
+		return err
+	}
+	printStats(titleIDFlag == "")
+	return nil
+}
+
+// Downloads the databases again, for pinecone update-db.
+func runUpdateDatabase(args []string) error {
+	flags := newCommandFlags("update-db")
+	addDatabaseFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("usage: pinecone update-db [flags]")
+	}
+	return loadCLIDatabase(true)
+}
+
+// Checks the dump against a SHA1SUMS manifest, or writes one, for pinecone verify.
+func runVerify(args []string) error {
+	flags := newCommandFlags("verify")
+	write := flags.Bool("write", false, "Write the manifest instead of checking the dump against it")
+	addSourceFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: pinecone verify [-write] [flags] SHA1SUMS")
+	}
+	applyLocationFlags()
+	guiEnabled = false
+	if *write {
+		hashManifestFlag = flags.Arg(0)
+	} else {
+		verifyManifestFlag = flags.Arg(0)
+	}
+	if err := prepareScan(); err != nil {
+		return err
+	}
+	sources, closeSources, err := openScanSources(scanLocations())
+	if err != nil {
+		return err
+	}
+	defer closeSources()
+	ctx, release := newScanContext()
+	defer release()
+	if *write {
+		return scanError(ctx, writeManifest(ctx, sources, hashManifestFlag))
+	}
+	return scanError(ctx, verifyManifest(ctx, sources, verifyManifestFlag))
+}
+
+// Loads the databases as a scan from the command line does, downloading them when asked to or when there's no local
+// copy yet.
+func loadCLIDatabase(update bool) error {
+	guiEnabled = false
+	options, err := scanOptions()
+	if err != nil {
+		return err
+	}
+	if err := checkDataFolder(options.DataFolder); err != nil {
+		return err
+	}
+	return checkDatabaseFile(options.JSONFilePath, options.JSONUrl, update)
+}
+
+// Scans what the flags before a command point at, for commands such as backup that work on the scan's results once
+// exportReports gets to them.
+func runCommandScan() error {
+	guiEnabled = false
+	if err := prepareScan(); err != nil {
+		return err
+	}
+	if err := loadCommandDatabase(); err != nil {
+		return err
+	}
+	if ftpFlag == "" && imageFlag == "" {
+		for _, location := range scanLocations() {
+			if err := checkDumpFolder(location); err != nil {
+				return err
+			}
+		}
+	}
+	ctx, release := newScanContext()
+	defer release()
+	return checkParsingSettings(ctx, scanLocations())
+}
+
 func startCLI(options CLIOptions) {
 	err := checkDataFolder(options.DataFolder)
 	if err != nil {
@@ -118,15 +468,25 @@ func startCLI(options CLIOptions) {
 		log.Fatalln(err)
 	}
 
-	err = checkDumpFolder(dumpLocation)
-	if err != nil {
-		log.Fatalln(err)
+	if ftpFlag == "" {
+		for _, location := range scanLocations() {
+			err = checkDumpFolder(location)
+			if err != nil {
+				log.Fatalln(err)
+			}
+		}
 	}
 
 	fmt.Printf("Pinecone v%s\n", version)
 	fmt.Println("Please share output of this program with the Pinecone team if you find anything interesting!")
 
-	err = checkParsingSettings()
+	if everyFlag > 0 {
+		runDaemon(options)
+		return
+	}
+	ctx, release := newScanContext()
+	defer release()
+	err = checkParsingSettings(ctx, scanLocations())
 	if err != nil {
 		log.Fatalln(err)
 	}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Content classifications.
+const (
+	contentClassLive         = "live"
+	contentClassDisc         = "disc"
+	contentClassDemo         = "demo"
+	contentClassUnclassified = "unclassified"
+)
+
+var contentClassNames = map[string]string{
+	contentClassLive:         "Xbox Live DLC",
+	contentClassDisc:         "disc-installed bonus content",
+	contentClassDemo:         "demo content",
+	contentClassUnclassified: "unclassified content",
+}
+
+// Words in content names and offers that mark demos and trials.
+var demoKeywords = []string{"demo", "trial", "preview"}
+
+// Reports whether contentID falls in a "first-last" range of content IDs, compared as hex numbers.
+func inContentRange(contentID string, contentRange string) bool {
+	first, last, ok := strings.Cut(contentRange, "-")
+	if !ok {
+		return false
+	}
+	id, err := strconv.ParseUint(contentID, 16, 64)
+	if err != nil {
+		return false
+	}
+	low, err := strconv.ParseUint(strings.TrimSpace(first), 16, 64)
+	if err != nil {
+		return false
+	}
+	high, err := strconv.ParseUint(strings.TrimSpace(last), 16, 64)
+	if err != nil {
+		return false
+	}
+	return id >= low && id <= high
+}
+
+// Classifies a $c item as Xbox Live DLC, disc-installed content or a demo. The database's Content Types, keyed by
+// content ID or ID range, take priority; otherwise contentmeta.xbx is used: demos name themselves as such, and only
+// marketplace downloads carry an offer description.
+func classifyContent(titleData TitleData, contentID string, meta *ContentMeta) string {
+	if class, ok := titleData.ContentTypes[contentID]; ok {
+		return class
+	}
+	ranges := make([]string, 0, len(titleData.ContentTypes))
+	for contentRange := range titleData.ContentTypes {
+		ranges = append(ranges, contentRange)
+	}
+	sort.Strings(ranges)
+	for _, contentRange := range ranges {
+		if inContentRange(contentID, contentRange) {
+			return titleData.ContentTypes[contentRange]
+		}
+	}
+	if meta == nil {
+		return contentClassUnclassified
+	}
+	text := strings.ToLower(meta.DisplayName + " " + meta.OfferString)
+	for _, keyword := range demoKeywords {
+		if strings.Contains(text, keyword) {
+			return contentClassDemo
+		}
+	}
+	if meta.OfferString != "" {
+		return contentClassLive
+	}
+	if meta.DisplayName != "" {
+		return contentClassDisc
+	}
+	return contentClassUnclassified
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+// Hashes every file in a content folder, with paths relative to it. Quick hashing only computes XXH64.
+func hashContentFiles(ctx context.Context, source *scanSource, contentDir string, quick bool) ([]FileReport, error) {
+	var files []FileReport
+	err := fs.WalkDir(source.FS, contentDir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if isIgnored(filePath) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		hash := source.hash
+		if quick {
+			hash = source.quickHash
+		}
+		hashes, err := hash(ctx, filePath)
+		if err != nil {
+			return err
+		}
+		files = append(files, newFileReport(reportPath(contentDir, filePath), info.Size(), hashes))
+		return nil
+	})
+	return files, err
+}
+
+// Reports whether content needs its SHA1s with --fast-hash, which is when the database can verify them or tell an
+// Insignia re-issue by them.
+func needsFullHashes(titleData TitleData, contentID string) bool {
+	_, ok := titleData.ContentFiles[contentID]
+	return !fastHashFlag || ok || len(titleData.Insignia[contentID].Files) > 0
+}
+
+// Reports whether content was downloaded from the Insignia revival: one of its new downloads, or a re-issue whose
+// files match Insignia's copy. Re-issues that can't be told apart count as original Xbox Live downloads.
+func insigniaCopy(titleData TitleData, contentID string, files []FileReport) bool {
+	insignia, ok := titleData.Insignia[contentID]
+	if !ok {
+		return false
+	}
+	if !insignia.Reissue {
+		return true
+	}
+	return len(insignia.Files) > 0 && len(compareContentFiles(insignia.Files, files)) == 0
+}
+
+// Compares hashed content files against the database's hashes for the archived copy. FATX ignores case, so paths
+// are compared case-insensitively.
+func compareContentFiles(expected map[string]string, files []FileReport) []string {
+	want := make(map[string]string, len(expected))
+	for name, hash := range expected {
+		want[strings.ToLower(name)] = strings.ToLower(hash)
+	}
+
+	var mismatches []string
+	for _, file := range files {
+		name := strings.ToLower(file.Path)
+		hash, ok := want[name]
+		switch {
+		case !ok:
+			mismatches = append(mismatches, fmt.Sprintf("%s is not in the archived copy", file.Path))
+		case hash != file.SHA1:
+			mismatches = append(mismatches, fmt.Sprintf("%s differs from the archived copy", file.Path))
+		}
+		delete(want, name)
+	}
+	var missing []string
+	for name := range want {
+		missing = append(missing, name)
+	}
+	sort.Strings(missing)
+	for _, name := range missing {
+		mismatches = append(mismatches, fmt.Sprintf("%s is missing", name))
+	}
+	return mismatches
+}
+
+// Checks archived content file by file when the database has per-file hashes for it.
+func verifyContentFiles(titleData TitleData, contentReport *ContentReport) {
+	expected, ok := titleData.ContentFiles[contentReport.ContentID]
+	if !ok {
+		return
+	}
+	contentReport.Mismatches = compareContentFiles(expected, contentReport.Files)
+	contentReport.Verified = len(contentReport.Mismatches) == 0
+	if contentReport.Verified {
+		if guiEnabled {
+			addText(theme.PrimaryColorNamed(theme.ColorGreen), "All %d files verified identical to the archived copy", len(contentReport.Files))
+		}
+		printInfo(fatihColor.FgGreen, "All %d files verified identical to the archived copy\n", len(contentReport.Files))
+		return
+	}
+	for _, mismatch := range contentReport.Mismatches {
+		if guiEnabled {
+			addText(theme.PrimaryColorNamed(theme.ColorYellow), "Mismatch: %s", mismatch)
+		}
+		printInfo(fatihColor.FgYellow, "Mismatch: %s\n", mismatch)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// Binary contentmeta.xbx header offsets. The header follows a 20 byte signature.
+const (
+	contentMetaMagic            = "XCMT"
+	contentMetaMagicOffset      = 0x14
+	contentMetaHeaderSizeOffset = 0x18
+	contentMetaTitleIDOffset    = 0x24
+	contentMetaOfferingIDOffset = 0x28
+	contentMetaStringsOffset    = 0x30
+	contentMetaMinStringLength  = 3
+)
+
+// ContentMeta holds what Pinecone can recover from a contentmeta.xbx file.
+type ContentMeta struct {
+	TitleID     string      `json:"titleID,omitempty" xml:"titleID,omitempty"`
+	OfferingID  string      `json:"offeringID,omitempty" xml:"offeringID,omitempty"`
+	DisplayName string      `json:"displayName,omitempty" xml:"displayName,omitempty"`
+	OfferString string      `json:"offerString,omitempty" xml:"offerString,omitempty"`
+	Fields      []MetaField `json:"fields,omitempty" xml:"field,omitempty"`
+}
+
+// MetaField is a single key/value pair found in a metadata file.
+type MetaField struct {
+	Key   string `json:"key" xml:"key,attr"`
+	Value string `json:"value" xml:",chardata"`
+}
+
+// Decodes UTF-16LE data, with or without a byte order mark.
+func decodeUTF16Text(data []byte) string {
+	data = bytes.TrimPrefix(data, []byte{0xFF, 0xFE})
+	chars := make([]uint16, len(data)/2)
+	for i := range chars {
+		chars[i] = binary.LittleEndian.Uint16(data[i*2:])
+	}
+	return string(utf16.Decode(chars))
+}
+
+// Extracts runs of printable UTF-16LE characters from binary data.
+func extractUTF16Strings(data []byte) []string {
+	var found []string
+	var current []uint16
+	flush := func() {
+		if len(current) >= contentMetaMinStringLength {
+			s := strings.TrimSpace(string(utf16.Decode(current)))
+			if s != "" {
+				found = append(found, s)
+			}
+		}
+		current = current[:0]
+	}
+	for i := 0; i+1 < len(data); i += 2 {
+		c := binary.LittleEndian.Uint16(data[i:])
+		if c != 0 && c < 0xD800 && unicode.IsPrint(rune(c)) {
+			current = append(current, c)
+		} else {
+			flush()
+		}
+	}
+	flush()
+	return found
+}
+
+// Parses "Key=Value" lines, the text format used by titlemeta.xbx and savemeta.xbx.
+func parseMetaFields(text string) []MetaField {
+	var fields []MetaField
+	for _, line := range strings.Split(text, "\n") {
+		key, value, found := strings.Cut(strings.TrimSpace(line), "=")
+		if !found || key == "" || strings.HasPrefix(key, "[") {
+			continue
+		}
+		fields = append(fields, MetaField{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value)})
+	}
+	return fields
+}
+
+func metaField(fields []MetaField, keys ...string) string {
+	for _, key := range keys {
+		for _, field := range fields {
+			if strings.EqualFold(field.Key, key) {
+				return field.Value
+			}
+		}
+	}
+	return ""
+}
+
+func parseContentMeta(data []byte) (*ContentMeta, error) {
+	meta := &ContentMeta{}
+
+	if len(data) >= contentMetaStringsOffset && string(data[contentMetaMagicOffset:contentMetaMagicOffset+4]) == contentMetaMagic {
+		meta.TitleID = fmt.Sprintf("%08x", binary.LittleEndian.Uint32(data[contentMetaTitleIDOffset:]))
+		meta.OfferingID = fmt.Sprintf("%016x", binary.LittleEndian.Uint64(data[contentMetaOfferingIDOffset:]))
+
+		headerSize := int(binary.LittleEndian.Uint32(data[contentMetaHeaderSizeOffset:]))
+		if headerSize <= contentMetaStringsOffset || headerSize > len(data) {
+			headerSize = len(data)
+		}
+		strs := extractUTF16Strings(data[contentMetaStringsOffset:headerSize])
+		for i, s := range strs {
+			meta.Fields = append(meta.Fields, MetaField{Key: fmt.Sprintf("String%d", i+1), Value: s})
+		}
+		if len(strs) > 0 {
+			meta.DisplayName = strs[0]
+		}
+		if len(strs) > 1 {
+			meta.OfferString = strs[1]
+		}
+		return meta, nil
+	}
+
+	// Otherwise assume the UTF-16 text format
+	if len(data) < 2 || len(data)%2 != 0 {
+		return nil, fmt.Errorf("unrecognized contentmeta format")
+	}
+	meta.Fields = parseMetaFields(decodeUTF16Text(data))
+	if len(meta.Fields) == 0 {
+		return nil, fmt.Errorf("unrecognized contentmeta format")
+	}
+	meta.DisplayName = metaField(meta.Fields, "Name", "DisplayName", "OfferingName", "TitleName")
+	meta.OfferString = metaField(meta.Fields, "OfferString", "Offer", "Description")
+	return meta, nil
+}
+
+func readContentMeta(fsys fs.FS, filePath string) (*ContentMeta, error) {
+	data, err := fs.ReadFile(fsys, filePath)
+	if err != nil {
+		return nil, err
+	}
+	return parseContentMeta(data)
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Name of the file in the data folder recording the coverage of each database revision summarized.
+const coverageHistoryName = "coverage_history.json"
+
+// Database revisions shown in the coverage trend.
+const coverageTrendRevisions = 5
+
+// Region known updates are tagged with when they run on any console.
+const regionFree = "RF"
+
+// CoverageStats are the archival coverage numbers of the retail titles of a database revision.
+type CoverageStats struct {
+	Recorded  string           `json:"recorded"`
+	Databases []DatabaseReport `json:"databases"`
+	Titles    int              `json:"titles"`
+	// Content IDs listed by titles, and how many of them are archived
+	ContentIDs         int `json:"contentIDs"`
+	ArchivedContentIDs int `json:"archivedContentIDs"`
+	// Downloads of the Insignia revival that aren't original Xbox Live content, which the counts above leave out
+	InsigniaContentIDs         int `json:"insigniaContentIDs,omitempty"`
+	ArchivedInsigniaContentIDs int `json:"archivedInsigniaContentIDs,omitempty"`
+	// Update IDs listed by titles or named by their known updates, and how many have an archived hash
+	Updates         int `json:"updates"`
+	ArchivedUpdates int `json:"archivedUpdates"`
+	// Titles listing updates without any of them archived
+	TitlesWithoutUpdates int              `json:"titlesWithoutUpdates"`
+	Regions              []RegionCoverage `json:"regions"`
+}
+
+// RegionCoverage counts the archived updates of a region, from the region their names give.
+type RegionCoverage struct {
+	Region  string `json:"region"`
+	Updates int    `json:"updates"`
+	Titles  int    `json:"titles"`
+	// Titles with updates archived for other regions but none for this one or region-free
+	Gaps int `json:"gaps"`
+}
+
+func percentage(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) * 100 / float64(total)
+}
+
+// Returns the regions a known update's name gives, as in "0000000100000201:NTSC+PAL 0201".
+func updateNameRegions(name string) []string {
+	_, rest, ok := strings.Cut(name, ":")
+	if !ok {
+		return nil
+	}
+	field, _, _ := strings.Cut(strings.TrimSpace(rest), " ")
+	var regions []string
+	for _, region := range strings.Split(field, "+") {
+		if region = strings.TrimSpace(region); region != "" {
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
+
+// Returns the update IDs a title lists or names known updates after, and whether each has an archived hash.
+func titleUpdateIDs(data TitleData) map[string]bool {
+	updates := make(map[string]bool)
+	for _, updateID := range data.TitleUpdates {
+		updates[strings.ToLower(updateID)] = false
+	}
+	// Known update names start with their update ID, as in "0000000300000103:RF English Update 1"
+	for _, known := range data.TitleUpdatesKnown {
+		for _, name := range known {
+			updateID, _, _ := strings.Cut(name, ":")
+			updates[strings.ToLower(updateID)] = true
+		}
+	}
+	return updates
+}
+
+// Computes the coverage of the loaded database.
+func computeCoverage() CoverageStats {
+	stats := CoverageStats{Recorded: time.Now().UTC().Format(time.RFC3339), Databases: loadedDatabases, Titles: len(titles.Titles)}
+	type regionCount struct {
+		updates int
+		titles  map[string]bool
+	}
+	regions := make(map[string]*regionCount)
+	titleRegions := make(map[string]map[string]bool)
+
+	for titleID, data := range titles.Titles {
+		for _, contentID := range data.ContentIDs {
+			stats.ContentIDs++
+			if databaseIndex.archivedName(titleID, contentID) != "" {
+				stats.ArchivedContentIDs++
+			}
+		}
+		for contentID := range data.Insignia {
+			if !data.insigniaOnly(contentID) {
+				continue
+			}
+			stats.InsigniaContentIDs++
+			if databaseIndex.archivedName(titleID, contentID) != "" {
+				stats.ArchivedInsigniaContentIDs++
+			}
+		}
+
+		updates := titleUpdateIDs(data)
+		for _, known := range data.TitleUpdatesKnown {
+			for _, name := range known {
+				for _, region := range updateNameRegions(name) {
+					count, ok := regions[region]
+					if !ok {
+						count = &regionCount{titles: make(map[string]bool)}
+						regions[region] = count
+					}
+					count.updates++
+					count.titles[titleID] = true
+					if region == "unknown" {
+						continue
+					}
+					if titleRegions[titleID] == nil {
+						titleRegions[titleID] = make(map[string]bool)
+					}
+					titleRegions[titleID][region] = true
+				}
+			}
+		}
+		archived := 0
+		for _, isArchived := range updates {
+			if isArchived {
+				archived++
+			}
+		}
+		stats.Updates += len(updates)
+		stats.ArchivedUpdates += archived
+		if len(updates) > 0 && archived == 0 {
+			stats.TitlesWithoutUpdates++
+		}
+	}
+
+	for region, count := range regions {
+		coverage := RegionCoverage{Region: region, Updates: count.updates, Titles: len(count.titles)}
+		if region != regionFree && region != "unknown" {
+			for _, found := range titleRegions {
+				if !found[region] && !found[regionFree] {
+					coverage.Gaps++
+				}
+			}
+		}
+		stats.Regions = append(stats.Regions, coverage)
+	}
+	sort.Slice(stats.Regions, func(i, j int) bool {
+		if stats.Regions[i].Updates != stats.Regions[j].Updates {
+			return stats.Regions[i].Updates > stats.Regions[j].Updates
+		}
+		return stats.Regions[i].Region < stats.Regions[j].Region
+	})
+	return stats
+}
+
+// Reports whether two coverage records are of the same database revisions.
+func sameRevisions(a, b []DatabaseReport) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Source != b[i].Source || a[i].Revision != b[i].Revision {
+			return false
+		}
+	}
+	return true
+}
+
+// Adds stats to the coverage history in the data folder, unless its revisions are already the last recorded, and
+// returns the history.
+func recordCoverage(stats CoverageStats) ([]CoverageStats, error) {
+	historyPath := filepath.Join(dataPath, coverageHistoryName)
+	var history []CoverageStats
+	data, err := os.ReadFile(historyPath)
+	if err == nil {
+		if err := json.Unmarshal(data, &history); err != nil {
+			return nil, fmt.Errorf("error decoding %s: %v", historyPath, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(history) > 0 && sameRevisions(history[len(history)-1].Databases, stats.Databases) {
+		return history, nil
+	}
+	history = append(history, stats)
+	if err := checkWritable(historyPath); err != nil {
+		return history, err
+	}
+	data, err = json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return history, err
+	}
+	return history, os.WriteFile(historyPath, data, 0o644)
+}
+
+// Returns the revision of the first database of a coverage record, shortened.
+func coverageRevision(stats CoverageStats) string {
+	if len(stats.Databases) == 0 {
+		return "unknown"
+	}
+	database := stats.Databases[0]
+	return strings.TrimSuffix(database.String(), " from "+database.Source)
+}
+
+// Prints the coverage of the loaded database and how it changed over the revisions summarized before.
+func printCoverage() {
+	stats := computeCoverage()
+	printLine("")
+	printLine("Coverage:")
+	printLine("Archived Content IDs: %d of %d (%.1f%%)", stats.ArchivedContentIDs, stats.ContentIDs, percentage(stats.ArchivedContentIDs, stats.ContentIDs))
+	if stats.InsigniaContentIDs > 0 {
+		printLine("Archived Insignia downloads: %d of %d (%.1f%%)", stats.ArchivedInsigniaContentIDs, stats.InsigniaContentIDs, percentage(stats.ArchivedInsigniaContentIDs, stats.InsigniaContentIDs))
+	}
+	printLine("Archived Title Updates: %d of %d (%.1f%%)", stats.ArchivedUpdates, stats.Updates, percentage(stats.ArchivedUpdates, stats.Updates))
+	printLine("Titles with no archived Title Updates: %d", stats.TitlesWithoutUpdates)
+	for _, region := range stats.Regions {
+		line := fmt.Sprintf("Region %s: %d archived updates for %d titles", region.Region, region.Updates, region.Titles)
+		if region.Gaps > 0 {
+			line += fmt.Sprintf(", %d titles archived for other regions only", region.Gaps)
+		}
+		printLine("%s", line)
+	}
+
+	history, err := recordCoverage(stats)
+	if err != nil {
+		printLine("Coverage history not saved: %v", err)
+	}
+	if len(history) < 2 {
+		return
+	}
+	printLine("")
+	printLine("Coverage by database revision:")
+	for _, past := range history[max(0, len(history)-coverageTrendRevisions):] {
+		recorded, _, _ := strings.Cut(past.Recorded, "T")
+		printLine("%s %s: %.1f%% of content IDs, %.1f%% of title updates", recorded, coverageRevision(past),
+			percentage(past.ArchivedContentIDs, past.ContentIDs), percentage(past.ArchivedUpdates, past.Updates))
+	}
+	previous := history[len(history)-2]
+	printLine("Since the previous revision: %+d archived content IDs, %+d archived title updates, %+d titles",
+		stats.ArchivedContentIDs-previous.ArchivedContentIDs, stats.ArchivedUpdates-previous.ArchivedUpdates, stats.Titles-previous.Titles)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+)
+
+var csvHeader = []string{"Title ID", "Title Name", "Content ID", "Path", "SHA1", "MD5", "CRC32", "Archived", "Type", "Source"}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// Builds one CSV row per discovered item in the report.
+func csvRows(report *ScanReport) [][]string {
+	rows := [][]string{csvHeader}
+	for _, item := range reportItems(report) {
+		rows = append(rows, []string{item.TitleID, item.TitleName, item.ContentID, item.Path, item.SHA1, item.MD5, item.CRC32, yesNo(item.Archived), item.Type, item.Source})
+	}
+	return rows
+}
+
+func writeCSVReport(outputPath string, report *ScanReport) error {
+	if err := checkWritable(outputPath); err != nil {
+		return err
+	}
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	if err := writer.WriteAll(csvRows(report)); err != nil {
+		return err
+	}
+	return file.Close()
+}
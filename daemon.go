@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	fatihColor "github.com/fatih/color"
+)
+
+// Most items listed in a notification, and the longest text sent, which Discord caps at 2000 characters.
+const (
+	maxNotifiedItems      = 20
+	maxNotificationLength = 1900
+)
+
+// historyItem is content or a title update recorded in a run of the history database.
+type historyItem struct {
+	Type      string `json:"type"`
+	TitleID   string `json:"titleID"`
+	TitleName string `json:"titleName,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Path      string `json:"path"`
+	Archived  bool   `json:"archived"`
+}
+
+// notification is the JSON POSTed to a --notify webhook. Text is what Slack shows and Content what Discord shows.
+type notification struct {
+	Text     string        `json:"text"`
+	Content  string        `json:"content"`
+	Location string        `json:"location"`
+	Items    []historyItem `json:"items"`
+}
+
+// Returns the id of the most recent run of location, or 0 if there is none.
+func latestHistoryRun(db *sql.DB, location string) (int64, error) {
+	var runID int64
+	err := db.QueryRow("SELECT id FROM scan_runs WHERE location = ? ORDER BY id DESC LIMIT 1", location).Scan(&runID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return runID, err
+}
+
+// Returns the content and title updates of a run that weren't in the previous run of the same location. Without a
+// previous run everything would be new, so only the unarchived items are returned.
+func newHistoryItems(db *sql.DB, runID, previousID int64) ([]historyItem, error) {
+	titleName := "COALESCE((SELECT title_name FROM titles t WHERE t.run_id = i.run_id AND t.title_id = i.title_id LIMIT 1), '')"
+	contentQuery := "SELECT 'content', i.title_id, " + titleName + ", i.name, i.path, i.archived FROM content i WHERE i.run_id = ?"
+	updateQuery := "SELECT 'update', i.title_id, " + titleName + ", i.name, i.path, i.archived FROM updates i WHERE i.run_id = ?"
+	if previousID == 0 {
+		contentQuery += " AND i.archived = 0"
+		updateQuery += " AND i.archived = 0"
+	} else {
+		contentQuery += " AND NOT EXISTS (SELECT 1 FROM content p WHERE p.run_id = ? AND p.title_id = i.title_id AND p.content_id = i.content_id AND p.path = i.path)"
+		updateQuery += " AND NOT EXISTS (SELECT 1 FROM updates p WHERE p.run_id = ? AND p.title_id = i.title_id AND p.path = i.path AND COALESCE(p.sha1, '') = COALESCE(i.sha1, ''))"
+	}
+
+	var items []historyItem
+	for _, query := range []string{contentQuery, updateQuery} {
+		args := []interface{}{runID}
+		if previousID != 0 {
+			args = append(args, previousID)
+		}
+		rows, err := db.Query(query+" ORDER BY i.title_id, i.path", args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var item historyItem
+			if err := rows.Scan(&item.Type, &item.TitleID, &item.TitleName, &item.Name, &item.Path, &item.Archived); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// Returns the line an item is listed with in notifications.
+func (item historyItem) String() string {
+	name := item.Name
+	if name == "" {
+		name = item.Path
+	}
+	title := item.TitleID
+	if item.TitleName != "" {
+		title = fmt.Sprintf("%s (%s)", item.TitleName, item.TitleID)
+	}
+	status := "archived"
+	if !item.Archived {
+		status = "not archived"
+	}
+	return fmt.Sprintf("%s %s: %s, %s", title, item.Type, name, status)
+}
+
+// Returns the text of a notification about items found in location, listing as many of them as fit.
+func notificationText(location string, items []historyItem) string {
+	unarchived := 0
+	for _, item := range items {
+		if !item.Archived {
+			unarchived++
+		}
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Pinecone found %d new items in %s, %d of them not archived yet", len(items), location, unarchived)
+	for i, item := range items {
+		line := "\n- " + item.String()
+		if i == maxNotifiedItems || sb.Len()+len(line) > maxNotificationLength {
+			fmt.Fprintf(&sb, "\n%d more", len(items)-i)
+			break
+		}
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
+
+// POSTs a notification to the --notify webhook.
+func postNotification(endpoint string, message notification) error {
+	client, err := httpClient()
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Pinecone/"+version)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		text, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s refused the notification: %s %s", endpoint, resp.Status, strings.TrimSpace(string(text)))
+	}
+	return nil
+}
+
+// Compares the run just recorded for location in the history database with the previous one, and notifies what's
+// new, if anything: on the console, and to the --notify webhook if one is given.
+func notifyHistoryChanges(dbPath, location string) error {
+	db, err := openHistory(dbPath)
+	if err != nil {
+		return fmt.Errorf("error opening history database: %v", err)
+	}
+	defer db.Close()
+
+	runID, err := latestHistoryRun(db, location)
+	if err != nil || runID == 0 {
+		return err
+	}
+	previousID, err := previousHistoryRun(db, runID, location)
+	if err != nil {
+		return err
+	}
+	items, err := newHistoryItems(db, runID, previousID)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		printInfo(fatihColor.FgWhite, "Nothing new since the last scan\n")
+		return nil
+	}
+
+	text := notificationText(location, items)
+	for _, line := range strings.Split(text, "\n") {
+		printInfo(fatihColor.FgGreen, "%s\n", line)
+	}
+	if notifyFlag == "" {
+		return nil
+	}
+	if err := postNotification(notifyFlag, notification{Text: text, Content: text, Location: location, Items: items}); err != nil {
+		return fmt.Errorf("error sending notification: %v", err)
+	}
+	printInfo(fatihColor.FgWhite, "Notification sent to %s\n", notifyFlag)
+	return nil
+}
+
+// Runs one scheduled scan, recording it into the history database and notifying what it found that's new.
+func runScheduledScan() error {
+	ctx, release := newScanContext()
+	defer release()
+	if err := checkParsingSettings(ctx, scanLocations()); err != nil {
+		return err
+	}
+	return notifyHistoryChanges(historyFlag, scanResults.Location)
+}
+
+// Scans the locations given every --every interval until interrupted, as a daemon for a NAS or home server. With -u
+// the database is updated before every scan after the first. A scan that fails, such as of a share that's offline,
+// is reported and tried again at the next run rather than stopping the daemon.
+func runDaemon(options CLIOptions) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	for next := time.Now(); ; {
+		printHeader("Scheduled scan " + time.Now().Format(time.DateTime))
+		if err := runScheduledScan(); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			printInfo(fatihColor.FgRed, "Scheduled scan failed: %v\n", err)
+		}
+		// Runs keep to the schedule, skipping those missed while a scan took longer than the interval
+		for !next.After(time.Now()) {
+			next = next.Add(everyFlag)
+		}
+		printInfo(fatihColor.FgWhite, "Next scan at %s, press Ctrl+C to stop\n", next.Format(time.DateTime))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+		if updateFlag {
+			if err := loadDatabases(options.JSONFilePath, true); err != nil {
+				printInfo(fatihColor.FgYellow, "Error updating data, scanning with the database already loaded: %v\n", err)
+			}
+		}
+	}
+}
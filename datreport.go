@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path"
+)
+
+const datDoctype = `<!DOCTYPE datafile PUBLIC "-//Logiqx//DTD ROM Management Datafile//EN" "http://www.logiqx.com/Dats/datafile.dtd">`
+
+// datFile is a clrmamepro/Logiqx XML datafile.
+type datFile struct {
+	XMLName xml.Name  `xml:"datafile"`
+	Header  datHeader `xml:"header"`
+	Games   []datGame `xml:"game"`
+}
+
+type datHeader struct {
+	Name        string `xml:"name"`
+	Description string `xml:"description"`
+	Version     string `xml:"version"`
+	Author      string `xml:"author"`
+}
+
+type datGame struct {
+	Name        string   `xml:"name,attr"`
+	Description string   `xml:"description"`
+	Roms        []datRom `xml:"rom"`
+}
+
+type datRom struct {
+	Name string `xml:"name,attr"`
+	Size int64  `xml:"size,attr"`
+	CRC  string `xml:"crc,attr,omitempty"`
+	MD5  string `xml:"md5,attr,omitempty"`
+	SHA1 string `xml:"sha1,attr,omitempty"`
+}
+
+// Lists every file inside a DLC content folder as roms named relative to the folder, hashing them unless the scan already did.
+// Files only hashed by --fast-hash are hashed again, since DAT tools need their CRC32s and SHA1s.
+func datContentRoms(source *scanSource, contentDir string, files []FileReport) ([]datRom, error) {
+	if files == nil || (len(files) > 0 && files[0].SHA1 == "") {
+		var err error
+		files, err = hashContentFiles(context.Background(), source, contentDir, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+	roms := make([]datRom, 0, len(files))
+	for _, file := range files {
+		roms = append(roms, datRom{Name: file.Path, Size: file.Size, CRC: file.CRC32, MD5: file.MD5, SHA1: file.SHA1})
+	}
+	return roms, nil
+}
+
+// Builds a DAT with one game per title's updates and one game per DLC item.
+func buildDAT(report *ScanReport) (*datFile, error) {
+	dat := &datFile{
+		Header: datHeader{
+			Name:        "Pinecone",
+			Description: "Original Xbox title updates and DLC found by Pinecone",
+			Version:     report.Version,
+			Author:      "Pinecone",
+		},
+	}
+
+	for _, title := range report.Titles {
+		name := fmt.Sprintf("%s (%s)", titleDisplayName(title), title.TitleID)
+		source := report.titleSource(&title)
+
+		updates := datGame{Name: name + " - Title Updates", Description: name + " - Title Updates"}
+		for _, update := range title.Updates {
+			if update.Error != "" {
+				continue
+			}
+			updates.Roms = append(updates.Roms, datRom{Name: path.Base(update.Path), Size: update.Size, CRC: update.CRC32, MD5: update.MD5, SHA1: update.SHA1})
+		}
+		if len(updates.Roms) > 0 {
+			dat.Games = append(dat.Games, updates)
+		}
+
+		for _, content := range title.Content {
+			contentName := content.Name
+			if contentName == "" {
+				contentName = "Unknown Content"
+			}
+			game := datGame{
+				Name:        fmt.Sprintf("%s - %s (%s)", name, contentName, content.ContentID),
+				Description: fmt.Sprintf("%s - %s", name, contentName),
+			}
+			if source != nil {
+				roms, err := datContentRoms(source, path.Join(tdataFolder, content.Path), content.Files)
+				if err != nil {
+					return nil, err
+				}
+				game.Roms = roms
+			}
+			dat.Games = append(dat.Games, game)
+		}
+	}
+
+	for _, disc := range report.Discs {
+		name := fmt.Sprintf("%s (%s)", disc.TitleName, disc.TitleID)
+		game := datGame{Name: name + " - Disc", Description: name + " - Disc"}
+		for _, file := range disc.Files {
+			game.Roms = append(game.Roms, datRom{Name: file.Path, Size: file.Size, CRC: file.CRC32, MD5: file.MD5, SHA1: file.SHA1})
+		}
+		dat.Games = append(dat.Games, game)
+	}
+	return dat, nil
+}
+
+func writeDATReport(outputPath string, report *ScanReport) error {
+	if err := checkWritable(outputPath); err != nil {
+		return err
+	}
+	dat, err := buildDAT(report)
+	if err != nil {
+		return err
+	}
+	data, err := xml.MarshalIndent(dat, "", "\t")
+	if err != nil {
+		return err
+	}
+	output := xml.Header + datDoctype + "\n" + string(data) + "\n"
+	return os.WriteFile(outputPath, []byte(output), 0o644)
+}
@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2/theme"
+)
+
+// A database can be split into a directory of shards next to where its single file would be, e.g. data/id_database/
+// for data/id_database.json, so a contribution only touches the files of the titles it changes. Every shard is a
+// database file of its own holding part of the sections, usually a single title, and the shards are merged on load.
+// The index lists the SHA1 of every shard, so an update only downloads the shards that changed.
+const shardIndexName = "index.json"
+
+// shardIndex lists the shards of a database by their path inside the shard directory.
+type shardIndex struct {
+	Shards map[string]string `json:"Shards"`
+}
+
+// Returns the shard directory of a database file.
+func shardDir(jsonFilePath string) string {
+	return strings.TrimSuffix(jsonFilePath, ".json")
+}
+
+// Reports whether the database at jsonFilePath has been split into shards.
+func isShardedDatabase(jsonFilePath string) bool {
+	info, err := os.Stat(shardDir(jsonFilePath))
+	return err == nil && info.IsDir()
+}
+
+// Reports whether there is a database to load at jsonFilePath, single file or sharded.
+func databaseExists(jsonFilePath string) bool {
+	if _, err := os.Stat(jsonFilePath); err == nil {
+		return true
+	}
+	return isShardedDatabase(jsonFilePath)
+}
+
+// Lists the shards in a shard directory, as slash separated paths inside it.
+func listShards(dir string) ([]string, error) {
+	var shards []string
+	err := filepath.WalkDir(dir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(filePath), ".json") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		if rel = filepath.ToSlash(rel); rel != shardIndexName {
+			shards = append(shards, rel)
+		}
+		return nil
+	})
+	return shards, err
+}
+
+// Loads every shard in dir into list.
+func loadShardedDatabase(dir string, list *TitleList) error {
+	shards, err := listShards(dir)
+	if err != nil {
+		return fmt.Errorf("error listing database shards: %v", err)
+	}
+	*list = TitleList{}
+	owners := make(map[string]string)
+	strs := make(stringInterner)
+	for _, shard := range shards {
+		shardFile, err := os.Open(filepath.Join(dir, filepath.FromSlash(shard)))
+		if err != nil {
+			return err
+		}
+		var part TitleList
+		err = decodeTitleListWith(shardFile, &part, strs)
+		shardFile.Close()
+		if err != nil {
+			return fmt.Errorf("error decoding database shard %s: %v", shard, err)
+		}
+		if err := mergeTitleList(list, &part, shard, owners); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Adds the entries of a shard to list. owners records which shard each entry came from, so an entry found in two
+// shards is reported instead of one silently replacing the other.
+func mergeTitleList(list, part *TitleList, shard string, owners map[string]string) error {
+	if part.Version != "" {
+		list.Version = part.Version
+	}
+	for _, err := range []error{
+		mergeSection(&list.Titles, part.Titles, "Titles", shard, owners),
+		mergeSection(&list.Chihiro, part.Chihiro, "Chihiro", shard, owners),
+		mergeSection(&list.Debug, part.Debug, "Debug", shard, owners),
+		mergeSection(&list.Software, part.Software, "Software", shard, owners),
+		mergeSection(&list.Homebrew, part.Homebrew, "Homebrew", shard, owners),
+		mergeSection(&list.KnownBad, part.KnownBad, "Known Bad", shard, owners),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mergeSection[V any](section *map[string]V, part map[string]V, name, shard string, owners map[string]string) error {
+	if len(part) > 0 && *section == nil {
+		*section = make(map[string]V, len(part))
+	}
+	for key, value := range part {
+		owner := name + "/" + key
+		if previous, ok := owners[owner]; ok {
+			return fmt.Errorf("%s %s is in both database shards %s and %s", name, key, previous, shard)
+		}
+		owners[owner] = shard
+		(*section)[key] = value
+	}
+	return nil
+}
+
+// Returns the URL of a file published alongside the database at dbURL, given where its path is from the database's.
+// The query is kept, so files of a pinned revision come from the same revision.
+func siblingURL(dbURL string, sibling func(string) string) string {
+	parsed, err := url.Parse(dbURL)
+	if err != nil {
+		return sibling(dbURL)
+	}
+	parsed.Path = sibling(parsed.Path)
+	parsed.RawPath = ""
+	return parsed.String()
+}
+
+// Returns the URL of a shard of the database at dbURL.
+func shardURL(dbURL, shard string) string {
+	return siblingURL(dbURL, func(p string) string { return shardDir(p) + "/" + shard })
+}
+
+// Brings the shard directory dir up to date with the sharded database at dbURL, downloading only the shards whose
+// SHA1 differs from the local copy and removing those no longer listed. Reports false if the database isn't sharded
+// upstream. With verify set, the index must carry a valid signature.
+func updateShardedDatabase(dir, dbURL string, verify bool) (bool, error) {
+	indexPath := filepath.Join(dir, shardIndexName)
+	indexData, state, err := downloadJSONData(shardURL(dbURL, shardIndexName), readDownloadState(indexPath))
+	if errors.Is(err, errDownloadNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if indexData == nil {
+		// The index hasn't changed, but the local shards are still checked against it
+		if indexData, err = os.ReadFile(indexPath); err != nil {
+			return false, err
+		}
+	} else if verify {
+		// The shards are checked against the SHA1s of the index, so signing the index covers them all
+		if err := verifyDownloadSignature(shardURL(dbURL, shardIndexName), indexData); err != nil {
+			return false, err
+		}
+	}
+	var index shardIndex
+	if err := json.Unmarshal(indexData, &index); err != nil {
+		return false, fmt.Errorf("error parsing database index: %v", err)
+	}
+
+	var stale []string
+	for shard, hash := range index.Shards {
+		if !fs.ValidPath(shard) || !strings.EqualFold(path.Ext(shard), ".json") || shard == shardIndexName {
+			return false, fmt.Errorf("invalid database shard %q in index", shard)
+		}
+		existingData, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(shard)))
+		if err != nil || fmt.Sprintf("%x", sha1.Sum(existingData)) != strings.ToLower(hash) {
+			stale = append(stale, shard)
+		}
+	}
+	sort.Strings(stale)
+
+	// Shards that were removed or renamed upstream
+	local, err := listShards(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	var removed []string
+	for _, shard := range local {
+		if _, ok := index.Shards[shard]; !ok {
+			removed = append(removed, shard)
+		}
+	}
+
+	if len(stale) > 0 || len(removed) > 0 {
+		if err := keepPreviousDatabase(dir); err != nil {
+			return false, err
+		}
+	}
+	if len(stale) == 0 {
+		printDatabaseStatus("%s is up to date", dir)
+	} else {
+		printDatabaseStatus("Updating %d of %d database shards in %s...", len(stale), len(index.Shards), dir)
+	}
+	for _, shard := range stale {
+		shardData, _, err := downloadJSONData(shardURL(dbURL, shard), downloadState{})
+		if err != nil {
+			return false, err
+		}
+		if hash := fmt.Sprintf("%x", sha1.Sum(shardData)); hash != strings.ToLower(index.Shards[shard]) {
+			return false, fmt.Errorf("database shard %s has SHA1 %s, the index lists %s", shard, hash, index.Shards[shard])
+		}
+		if err := writeShard(dir, shard, shardData); err != nil {
+			return false, err
+		}
+	}
+
+	for _, shard := range removed {
+		shardPath := filepath.Join(dir, filepath.FromSlash(shard))
+		if err := checkWritable(shardPath); err != nil {
+			return false, err
+		}
+		if err := os.Remove(shardPath); err != nil {
+			return false, err
+		}
+	}
+
+	if err := writeShard(dir, shardIndexName, indexData); err != nil {
+		return false, err
+	}
+	if state.SHA1 != "" {
+		if err := writeDownloadState(indexPath, state); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// Writes a file into a shard directory, creating the folders it goes in.
+func writeShard(dir, shard string, data []byte) error {
+	shardPath := filepath.Join(dir, filepath.FromSlash(shard))
+	if err := checkWritable(shardPath); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(shardPath), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(shardPath, data, 0o644)
+}
+
+// Prints the progress of a database update.
+func printDatabaseStatus(format string, args ...interface{}) {
+	if guiEnabled {
+		addText(theme.ForegroundColor(), format, args...)
+	}
+	printLine(format, args...)
+}
+
+// Splits the database file src into one shard per title in dir and writes its index, or with no src, writes the index
+// of the shards already in dir after they've been edited.
+func runShard(src, dir string) error {
+	if src != "" {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		var list TitleList
+		if err := decodeTitleList(bytes.NewReader(data), &list); err != nil {
+			return fmt.Errorf("error decoding %s: %v", src, err)
+		}
+		for _, section := range []struct {
+			name   string
+			titles map[string]TitleData
+		}{{"Titles", list.Titles}, {"Chihiro", list.Chihiro}, {"Debug", list.Debug}} {
+			for titleID, title := range section.titles {
+				if !fs.ValidPath(titleID) || strings.Contains(titleID, "/") {
+					return fmt.Errorf("title ID %q can't be used as a file name", titleID)
+				}
+				shard := map[string]map[string]TitleData{section.name: {titleID: title}}
+				if err := writeShardJSON(dir, section.name+"/"+titleID+".json", shard); err != nil {
+					return err
+				}
+			}
+		}
+		// The other sections are small and keyed by hash, so they stay in one shard
+		rest := make(map[string]interface{})
+		if len(list.Software) > 0 {
+			rest["Software"] = list.Software
+		}
+		if len(list.Homebrew) > 0 {
+			rest["Homebrew"] = list.Homebrew
+		}
+		if len(list.KnownBad) > 0 {
+			rest["Known Bad"] = list.KnownBad
+		}
+		if len(rest) > 0 {
+			if err := writeShardJSON(dir, "software.json", rest); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Checks the shards load together, so an index is never written for a database that won't
+	var list TitleList
+	if err := loadShardedDatabase(dir, &list); err != nil {
+		return err
+	}
+	shards, err := listShards(dir)
+	if err != nil {
+		return err
+	}
+	index := shardIndex{Shards: make(map[string]string, len(shards))}
+	for _, shard := range shards {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(shard)))
+		if err != nil {
+			return err
+		}
+		index.Shards[shard] = fmt.Sprintf("%x", sha1.Sum(data))
+	}
+	if err := writeShardJSON(dir, shardIndexName, index); err != nil {
+		return err
+	}
+	printLine("Indexed %d shards with %d titles in %s", len(shards), len(list.Titles)+len(list.Chihiro)+len(list.Debug), dir)
+	return nil
+}
+
+// Writes v as indented JSON into a shard directory.
+func writeShardJSON(dir, shard string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeShard(dir, shard, append(data, '\n'))
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Name of the file in the data folder holding the user's own additions to the database, merged over it on load.
+const localOverridesName = "local_overrides.json"
+
+// Stands for the official database in --db-url and the databases setting.
+const officialDatabase = "official"
+
+// officialDatabaseURL is where the official database is published.
+var officialDatabaseURL = githubContentsURL("Xbox-Preservation-Project", "Pinecone", "data/id_database.json")
+
+// databaseSource is a database to load, kept locally at path and downloaded from the first of its URLs that answers.
+type databaseSource struct {
+	urls []string
+	path string
+	// Whether downloads must be signed with the official database's key
+	verify bool
+}
+
+// urlList collects every --db-url given. They aren't split on commas, since URLs may hold them.
+type urlList []string
+
+func (urls *urlList) String() string {
+	return strings.Join(*urls, " ")
+}
+
+func (urls *urlList) Set(value string) error {
+	if value = strings.TrimSpace(value); value != "" {
+		*urls = append(*urls, value)
+	}
+	return nil
+}
+
+// Returns the databases to load in the order they're merged: those given with --db-url, or else those in the
+// databases setting, or else the official database alone. Each is a URL optionally followed by "|" and the URLs of its
+// mirrors. The first database is kept at jsonFilePath and the others under data/databases.
+func databaseSources(jsonFilePath string) ([]databaseSource, error) {
+	entries := []string(dbURLsFlag)
+	if len(entries) == 0 {
+		if settings, err := loadSettings(); err == nil {
+			entries = settings.Databases
+		}
+	}
+	if len(entries) == 0 {
+		entries = []string{officialDatabase}
+	}
+
+	var sources []databaseSource
+	for i, entry := range entries {
+		var source databaseSource
+		for _, u := range strings.Split(entry, "|") {
+			u = strings.TrimSpace(u)
+			if u == officialDatabase {
+				u = officialDatabaseURL
+			}
+			if u == "" {
+				continue
+			}
+			if parsed, err := url.Parse(u); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+				return nil, fmt.Errorf("invalid database URL %q", u)
+			}
+			// Mirrors of the official database are held to its signature too
+			source.verify = source.verify || u == officialDatabaseURL
+			source.urls = append(source.urls, u)
+		}
+		if len(source.urls) == 0 {
+			return nil, fmt.Errorf("invalid database source %q", entry)
+		}
+		source.path = jsonFilePath
+		if i == 0 && dbVersionFlag != "" {
+			for j, u := range source.urls {
+				pinned, err := pinnedURL(u, dbVersionFlag)
+				if err != nil {
+					return nil, err
+				}
+				source.urls[j] = pinned
+			}
+		}
+		if i > 0 {
+			source.path = filepath.Join(dataPath, "databases", databaseFileName(source.urls[0]))
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// Returns the local file name of the database at u, from the name it's published under and a hash of the URL that
+// tells apart databases published under the same name.
+func databaseFileName(u string) string {
+	name := "database"
+	if parsed, err := url.Parse(u); err == nil {
+		if base := unsafeFileNameChars.ReplaceAllString(strings.TrimSuffix(path.Base(parsed.Path), ".json"), "_"); strings.Trim(base, "_") != "" {
+			name = base
+		}
+	}
+	hash := sha1.Sum([]byte(u))
+	return fmt.Sprintf("%s-%x.json", name, hash[:4])
+}
+
+// Loads every configured database into titles, merging each into the ones before it and the local overrides over
+// them all. Databases other than the first are downloaded as soon as they're configured, since their URLs were given
+// on purpose.
+func loadDatabases(jsonFilePath string, updateFlag bool) error {
+	sources, err := databaseSources(jsonFilePath)
+	if err != nil {
+		return err
+	}
+	var merged TitleList
+	var loaded []DatabaseReport
+	for i, source := range sources {
+		// A pinned revision is downloaded unless it's the one already there
+		update := updateFlag || (i == 0 && dbVersionFlag != "")
+		if i > 0 && !databaseExists(source.path) {
+			if err := checkWritable(source.path); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(source.path), 0o755); err != nil {
+				return err
+			}
+			update = true
+		}
+		var list TitleList
+		if err := loadJSONData(source, &list, update); err != nil {
+			if len(sources) > 1 {
+				return fmt.Errorf("%s: %v", source.urls[0], err)
+			}
+			return err
+		}
+		if i == 0 {
+			merged = list
+		} else {
+			mergeDatabase(&merged, &list)
+		}
+		database := DatabaseReport{Source: source.urls[0], Revision: databaseRevision(source.path), Version: list.Version}
+		if i == 0 {
+			database.Pinned = dbVersionFlag
+		}
+		loaded = append(loaded, database)
+	}
+
+	// Local overrides go over everything downloaded, and -u never touches them
+	overridesPath := filepath.Join(dataPath, localOverridesName)
+	if overrides, err := os.Open(overridesPath); err == nil {
+		var list TitleList
+		err = decodeTitleList(overrides, &list)
+		overrides.Close()
+		if err != nil {
+			return fmt.Errorf("error decoding %s: %v", overridesPath, err)
+		}
+		mergeDatabase(&merged, &list)
+		loaded = append(loaded, DatabaseReport{Source: overridesPath, Revision: databaseRevision(overridesPath), Version: list.Version})
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	titles = merged
+	databaseIndex = buildTitleIndex(&titles)
+	loadedDatabases = loaded
+	return nil
+}
+
+// Merges the database part into list. Titles found in both keep the name from part, if it has one, and gain the
+// content, updates and hashes part adds; software, homebrew and known-bad entries from part replace those in list.
+func mergeDatabase(list, part *TitleList) {
+	mergeTitles(&list.Titles, part.Titles)
+	mergeTitles(&list.Chihiro, part.Chihiro)
+	mergeTitles(&list.Debug, part.Debug)
+	replaceEntries(&list.Software, part.Software)
+	replaceEntries(&list.Homebrew, part.Homebrew)
+	replaceEntries(&list.KnownBad, part.KnownBad)
+}
+
+func mergeTitles(section *map[string]TitleData, part map[string]TitleData) {
+	if len(part) > 0 && *section == nil {
+		*section = make(map[string]TitleData, len(part))
+	}
+	for titleID, added := range part {
+		title, ok := (*section)[titleID]
+		if !ok {
+			(*section)[titleID] = added
+			continue
+		}
+		if added.TitleName != "" {
+			title.TitleName = added.TitleName
+		}
+		title.ContentIDs = appendMissing(title.ContentIDs, added.ContentIDs...)
+		title.TitleUpdates = appendMissing(title.TitleUpdates, added.TitleUpdates...)
+		title.TitleUpdatesKnown = appendMissingMaps(title.TitleUpdatesKnown, added.TitleUpdatesKnown)
+		title.Archived = appendMissingMaps(title.Archived, added.Archived)
+		title.ContentTypes = mergeStrings(title.ContentTypes, added.ContentTypes)
+		if len(added.ContentFiles) > 0 && title.ContentFiles == nil {
+			title.ContentFiles = make(map[string]map[string]string, len(added.ContentFiles))
+		}
+		for contentID, files := range added.ContentFiles {
+			title.ContentFiles[contentID] = mergeStrings(title.ContentFiles[contentID], files)
+		}
+		if len(added.Insignia) > 0 && title.Insignia == nil {
+			title.Insignia = make(map[string]InsigniaData, len(added.Insignia))
+		}
+		for contentID, insignia := range added.Insignia {
+			title.Insignia[contentID] = insignia
+		}
+		(*section)[titleID] = title
+	}
+}
+
+func replaceEntries[V any](section *map[string]V, part map[string]V) {
+	if len(part) > 0 && *section == nil {
+		*section = make(map[string]V, len(part))
+	}
+	for key, value := range part {
+		(*section)[key] = value
+	}
+}
+
+// Appends the values not already in list.
+func appendMissing(list []string, values ...string) []string {
+	for _, value := range values {
+		if !contains(list, value) {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+func appendMissingMaps(list, values []map[string]string) []map[string]string {
+	for _, value := range values {
+		found := false
+		for _, existing := range list {
+			if reflect.DeepEqual(existing, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// Returns a copy of m with the entries of added set, so maps shared with the database being merged aren't changed.
+func mergeStrings(m, added map[string]string) map[string]string {
+	if len(added) == 0 {
+		return m
+	}
+	merged := make(map[string]string, len(m)+len(added))
+	for key, value := range m {
+		merged[key] = value
+	}
+	for key, value := range added {
+		merged[key] = value
+	}
+	return merged
+}
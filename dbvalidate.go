@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// dbValidator collects the problems found in a database, which may be spread over several shards.
+type dbValidator struct {
+	problems []string
+	// The file each entry was found in, by section and key, to catch entries given twice
+	entries map[string]string
+	// The title each content ID belongs to
+	contentIDs map[string]string
+	titles     int
+}
+
+func (v *dbValidator) report(file, format string, args ...interface{}) {
+	v.problems = append(v.problems, file+": "+fmt.Sprintf(format, args...))
+}
+
+// Reports whether s is n lowercase hex digits. Title IDs, content IDs and hashes found while scanning are always
+// lowercase, so uppercase ones in the database would never match.
+func isLowerHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// Checks the database at dbPath, a single file or a shard directory, for duplicate entries, malformed IDs and
+// hashes, empty names and fields the schema doesn't have.
+func runValidate(dbPath string) error {
+	v := &dbValidator{entries: make(map[string]string), contentIDs: make(map[string]string)}
+	files := []string{dbPath}
+	if info, err := os.Stat(dbPath); err != nil {
+		return err
+	} else if info.IsDir() {
+		shards, err := listShards(dbPath)
+		if err != nil {
+			return err
+		}
+		files = files[:0]
+		for _, shard := range shards {
+			files = append(files, filepath.Join(dbPath, filepath.FromSlash(shard)))
+		}
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		v.validateFile(file, data)
+	}
+
+	sort.Strings(v.problems)
+	for _, problem := range v.problems {
+		fmt.Println(problem)
+	}
+	if len(v.problems) > 0 {
+		return fmt.Errorf("%d problems found in %s", len(v.problems), dbPath)
+	}
+	fmt.Printf("No problems found in %d titles of %s\n", v.titles, dbPath)
+	return nil
+}
+
+// Checks one database file, section by section.
+func (v *dbValidator) validateFile(file string, data []byte) {
+	dec := json.NewDecoder(newCommentReader(bytes.NewReader(data)))
+	if err := expectDelim(dec, '{'); err != nil {
+		v.report(file, "%v", err)
+		return
+	}
+	sections := make(map[string]bool)
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			v.report(file, "%v", err)
+			return
+		}
+		section, _ := token.(string)
+		if sections[section] {
+			v.report(file, "section %q is given twice", section)
+		}
+		sections[section] = true
+
+		switch section {
+		case "Titles", "Chihiro", "Debug":
+			err = v.validateEntries(dec, file, section, func(key string, raw json.RawMessage) {
+				var title TitleData
+				if v.decodeStrict(raw, &title, file, section, key) {
+					v.validateTitle(file, section, key, title)
+				}
+			})
+		case "Software":
+			err = v.validateEntries(dec, file, section, func(key string, raw json.RawMessage) {
+				var software SoftwareData
+				if !v.decodeStrict(raw, &software, file, section, key) {
+					return
+				}
+				v.checkHash(file, section+" "+key, key)
+				if strings.TrimSpace(software.Name) == "" {
+					v.report(file, "%s %s has no name", section, key)
+				}
+			})
+		case "Homebrew":
+			err = v.validateEntries(dec, file, section, func(key string, raw json.RawMessage) {
+				var homebrew HomebrewData
+				if !v.decodeStrict(raw, &homebrew, file, section, key) {
+					return
+				}
+				if strings.TrimSpace(homebrew.TitleName) == "" {
+					v.report(file, "%s %s has no title name", section, key)
+				}
+				for _, xbes := range homebrew.XBEs {
+					for hash := range xbes {
+						v.checkHash(file, section+" "+key+" XBE", hash)
+					}
+				}
+			})
+		case "Known Bad":
+			err = v.validateEntries(dec, file, section, func(key string, raw json.RawMessage) {
+				var bad KnownBadData
+				if !v.decodeStrict(raw, &bad, file, section, key) {
+					return
+				}
+				v.checkHash(file, section+" entry", key)
+				if !isLowerHex(bad.TitleID, 8) {
+					v.report(file, "%s %s has malformed title ID %q", section, key, bad.TitleID)
+				}
+				if strings.TrimSpace(bad.Reason) == "" {
+					v.report(file, "%s %s gives no reason", section, key)
+				}
+			})
+		case "Version":
+			var version string
+			if err = dec.Decode(&version); err != nil {
+				v.report(file, "Version must be a string")
+				err = nil
+			}
+		default:
+			v.report(file, "unknown section %q", section)
+			var skipped json.RawMessage
+			err = dec.Decode(&skipped)
+		}
+		if err != nil {
+			v.report(file, "%v", err)
+			return
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		v.report(file, "%v", err)
+	}
+}
+
+// Walks the entries of a section one at a time, catching keys given twice in it, whether in the same file or in
+// another shard.
+func (v *dbValidator) validateEntries(dec *json.Decoder, file, section string, validate func(key string, raw json.RawMessage)) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return fmt.Errorf("section %s: %v", section, err)
+	}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, _ := token.(string)
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		if previous, ok := v.entries[section+"/"+key]; ok {
+			if previous == file {
+				v.report(file, "%s %s is given twice", section, key)
+			} else {
+				v.report(file, "%s %s is also given in %s", section, key, previous)
+			}
+			continue
+		}
+		v.entries[section+"/"+key] = file
+		validate(key, raw)
+	}
+	return expectDelim(dec, '}')
+}
+
+// Decodes an entry, reporting fields of the wrong type or that the schema doesn't have.
+func (v *dbValidator) decodeStrict(raw json.RawMessage, entry interface{}, file, section, key string) bool {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(entry); err != nil && err != io.EOF {
+		v.report(file, "%s %s: %v", section, key, err)
+		return false
+	}
+	return true
+}
+
+func (v *dbValidator) checkHash(file, what, hash string) {
+	if !isLowerHex(hash, 40) {
+		v.report(file, "%s has malformed SHA1 %q", what, hash)
+	}
+}
+
+func (v *dbValidator) validateTitle(file, section, titleID string, title TitleData) {
+	v.titles++
+	where := section + " " + titleID
+	if !isLowerHex(titleID, 8) {
+		v.report(file, "%s: malformed title ID", where)
+	}
+	if strings.TrimSpace(title.TitleName) == "" {
+		v.report(file, "%s has no title name", where)
+	}
+
+	seen := make(map[string]bool)
+	for _, contentID := range title.ContentIDs {
+		if !isLowerHex(contentID, 16) {
+			v.report(file, "%s has malformed content ID %q", where, contentID)
+		}
+		if seen[contentID] {
+			v.report(file, "%s lists content ID %s twice", where, contentID)
+			continue
+		}
+		seen[contentID] = true
+		if owner, ok := v.contentIDs[contentID]; ok {
+			v.report(file, "%s has content ID %s, which also belongs to %s", where, contentID, owner)
+		} else {
+			v.contentIDs[contentID] = where
+		}
+	}
+	// Update IDs are only read for the version they start with, so their case doesn't matter
+	for _, updateID := range title.TitleUpdates {
+		if !isLowerHex(strings.ToLower(updateID), 16) {
+			v.report(file, "%s has malformed title update ID %q", where, updateID)
+		}
+	}
+	for _, known := range title.TitleUpdatesKnown {
+		for hash, name := range known {
+			v.checkHash(file, where+" known update", hash)
+			if strings.TrimSpace(name) == "" {
+				v.report(file, "%s known update %s has no name", where, hash)
+			}
+		}
+	}
+	for contentID, insignia := range title.Insignia {
+		if !isLowerHex(contentID, 16) {
+			v.report(file, "%s has malformed Insignia content ID %q", where, contentID)
+		}
+		if strings.TrimSpace(insignia.Name) == "" {
+			v.report(file, "%s Insignia content %s has no name", where, contentID)
+		}
+		if insignia.Reissue && !seen[contentID] {
+			v.report(file, "%s has Insignia re-issue %s, which isn't one of its content IDs", where, contentID)
+		} else if !insignia.Reissue && seen[contentID] {
+			v.report(file, "%s lists Insignia content %s as a content ID, which is kept for original Xbox Live content", where, contentID)
+		}
+	}
+	for _, archived := range title.Archived {
+		for id, name := range archived {
+			if !isLowerHex(id, 16) {
+				v.report(file, "%s has malformed archived ID %q", where, id)
+			}
+			if strings.TrimSpace(name) == "" {
+				v.report(file, "%s archived item %s has no name", where, id)
+			}
+		}
+	}
+
+	classes := make([]string, 0, len(contentClassNames))
+	for class := range contentClassNames {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	for id, class := range title.ContentTypes {
+		first, last, isRange := strings.Cut(id, "-")
+		if !isLowerHex(strings.TrimSpace(first), 16) || (isRange && !isLowerHex(strings.TrimSpace(last), 16)) {
+			v.report(file, "%s has malformed content type ID or range %q", where, id)
+		}
+		if _, ok := contentClassNames[class]; !ok {
+			v.report(file, "%s gives %s unknown content type %q, expected one of %s", where, id, class, strings.Join(classes, ", "))
+		}
+	}
+	for contentID, files := range title.ContentFiles {
+		if !isLowerHex(contentID, 16) {
+			v.report(file, "%s has content files for malformed content ID %q", where, contentID)
+		}
+		for name, hash := range files {
+			v.checkHash(file, where+" content file "+name, hash)
+		}
+	}
+}
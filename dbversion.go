@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Suffix of the copy of the database kept from before the last update, which the rollback command restores.
+const previousDatabaseExt = ".previous"
+
+// The revisions of the databases loaded, recorded in the reports of every scan.
+var loadedDatabases []DatabaseReport
+
+// Returns the SHA1 git gives the contents of a file, so a revision can be found in the database's history.
+func gitBlobSHA1(data []byte) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", len(data))
+	h.Write(data)
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// Returns the revision of the local database at jsonFilePath, sharded or not.
+func databaseRevision(jsonFilePath string) string {
+	revisionPath := jsonFilePath
+	if isShardedDatabase(jsonFilePath) {
+		revisionPath = filepath.Join(shardDir(jsonFilePath), shardIndexName)
+	}
+	data, err := os.ReadFile(revisionPath)
+	if err != nil {
+		return ""
+	}
+	return gitBlobSHA1(data)
+}
+
+// Returns u asking for revision ref of a database on GitHub, which can be a commit, branch or tag.
+func pinnedURL(u, ref string) (string, error) {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Host != "api.github.com" || !strings.Contains(parsed.Path, "/contents/") {
+		return "", fmt.Errorf("--db-version can only pin databases published on GitHub, not %s", u)
+	}
+	query := parsed.Query()
+	query.Set("ref", ref)
+	parsed.RawQuery = query.Encode()
+	return parsed.String(), nil
+}
+
+// Keeps a copy of the local database at current, a file or a shard directory, before an update replaces it.
+func keepPreviousDatabase(current string) error {
+	previous := current + previousDatabaseExt
+	if err := checkWritable(previous); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(previous); err != nil {
+		return err
+	}
+	info, err := os.Stat(current)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return copyFromFS(os.DirFS(filepath.Dir(current)), filepath.Base(current), previous)
+	}
+	fsys := os.DirFS(current)
+	return fs.WalkDir(fsys, ".", func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(previous, filepath.FromSlash(filePath))
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		return copyFromFS(fsys, filePath, target)
+	})
+}
+
+// Swaps the database with the one kept from before the last update, so a rollback can itself be undone by running
+// it again.
+func runRollback() error {
+	jsonFilePath := filepath.Join(dataPath, "id_database.json")
+	rolledBack := false
+	for _, current := range []string{jsonFilePath, shardDir(jsonFilePath)} {
+		previous := current + previousDatabaseExt
+		if _, err := os.Stat(previous); err != nil {
+			continue
+		}
+		if err := checkWritable(current); err != nil {
+			return err
+		}
+		swap := current + ".swap"
+		if err := os.Rename(current, swap); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error rolling back %s: %v", current, err)
+		}
+		if err := os.Rename(previous, current); err != nil {
+			return fmt.Errorf("error rolling back %s: %v", current, err)
+		}
+		if err := os.Rename(swap, previous); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("error rolling back %s: %v", current, err)
+		}
+		rolledBack = true
+	}
+	if !rolledBack {
+		return fmt.Errorf("no previous database to roll back to, one is kept each time -u replaces the database")
+	}
+	fmt.Printf("Rolled back to database revision %s, run rollback again to undo\n", databaseRevision(jsonFilePath))
+	return nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	fatihColor "github.com/fatih/color"
+)
+
+func readJSONReport(reportPath string) (*ScanReport, error) {
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		return nil, err
+	}
+	report := &ScanReport{}
+	if err := json.Unmarshal(data, report); err != nil {
+		return nil, fmt.Errorf("error reading report %s: %v", reportPath, err)
+	}
+	return report, nil
+}
+
+// ReportDiff lists the differences between two scans of the same dump.
+type ReportDiff struct {
+	Added   []ReportItem
+	Removed []ReportItem
+	Changed [][2]ReportItem
+}
+
+func reportItemsByPath(report *ScanReport) map[string]ReportItem {
+	items := make(map[string]ReportItem)
+	for _, item := range reportItems(report) {
+		items[item.Type+":"+item.Path] = item
+	}
+	return items
+}
+
+func sortedItemKeys(items map[string]ReportItem) []string {
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func diffReports(oldReport, newReport *ScanReport) ReportDiff {
+	var diff ReportDiff
+	oldItems := reportItemsByPath(oldReport)
+	newItems := reportItemsByPath(newReport)
+
+	for _, key := range sortedItemKeys(newItems) {
+		oldItem, ok := oldItems[key]
+		newItem := newItems[key]
+		if !ok {
+			diff.Added = append(diff.Added, newItem)
+		} else if oldItem.SHA1 != newItem.SHA1 {
+			diff.Changed = append(diff.Changed, [2]ReportItem{oldItem, newItem})
+		}
+	}
+	for _, key := range sortedItemKeys(oldItems) {
+		if _, ok := newItems[key]; !ok {
+			diff.Removed = append(diff.Removed, oldItems[key])
+		}
+	}
+	return diff
+}
+
+func describeItem(item ReportItem) string {
+	description := fmt.Sprintf("%s %s (%s)", item.Type, item.Path, item.Status())
+	if item.Name != "" {
+		description += " " + item.Name
+	}
+	return description
+}
+
+// Prints what changed between two JSON reports written with --output.
+func runDiff(oldPath, newPath string) error {
+	oldReport, err := readJSONReport(oldPath)
+	if err != nil {
+		return err
+	}
+	newReport, err := readJSONReport(newPath)
+	if err != nil {
+		return err
+	}
+
+	diff := diffReports(oldReport, newReport)
+
+	printHeader("Added")
+	for _, item := range diff.Added {
+		printInfo(fatihColor.FgGreen, "+ %s\n", describeItem(item))
+	}
+	printHeader("Removed")
+	for _, item := range diff.Removed {
+		printInfo(fatihColor.FgRed, "- %s\n", describeItem(item))
+	}
+	printHeader("Changed")
+	for _, items := range diff.Changed {
+		printInfo(fatihColor.FgYellow, "~ %s\n", describeItem(items[1]))
+		printInfo(fatihColor.FgYellow, "  SHA1: %s -> %s\n", items[0].SHA1, items[1].SHA1)
+	}
+	fmt.Printf("%d added, %d removed, %d changed\n", len(diff.Added), len(diff.Removed), len(diff.Changed))
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+// DiscReport describes a game disc image, identified by its default.xbe.
+type DiscReport struct {
+	Path      string       `json:"path" xml:"path"`
+	TitleID   string       `json:"titleID,omitempty" xml:"titleID,attr,omitempty"`
+	TitleName string       `json:"titleName,omitempty" xml:"titleName,omitempty"`
+	Known     bool         `json:"known" xml:"known,attr"`
+	Platform  string       `json:"platform,omitempty" xml:"platform,attr,omitempty"`
+	XBE       *XBEReport   `json:"xbe,omitempty" xml:"xbe,omitempty"`
+	Files     []FileReport `json:"files,omitempty" xml:"file,omitempty"`
+	Warnings  []string     `json:"warnings,omitempty" xml:"warning,omitempty"`
+}
+
+// Identifies an XISO or full disc image against the database and hashes every file on it, adding it to scanResults.
+func scanDiscImage(ctx context.Context, isoPath string) error {
+	file, err := os.Open(isoPath)
+	if err != nil {
+		return fmt.Errorf("error opening disc image: %v", err)
+	}
+	defer file.Close()
+
+	xiso, err := openXISO(file)
+	if err != nil {
+		return fmt.Errorf("error opening disc image: %v", err)
+	}
+
+	disc := DiscReport{Path: isoPath}
+	if guiEnabled {
+		addHeader("Disc Image")
+	}
+	printHeader("Disc Image")
+
+	xbe, err := readXBEFile(xiso, "default.xbe")
+	if err != nil {
+		disc.Warnings = append(disc.Warnings, fmt.Sprintf("unable to read default.xbe: %v", err))
+	} else {
+		disc.XBE = newXBEReport(xbe)
+		disc.TitleID = xbe.TitleID
+		disc.TitleName = xbe.TitleName
+		if titleData, platform, ok := lookupTitle(xbe.TitleID); ok {
+			disc.Platform = platform
+			disc.Known = true
+			disc.TitleName = titleData.TitleName
+			if guiEnabled {
+				addText(theme.PrimaryColorNamed(theme.ColorGreen), "Disc identified as %s (%s)", titleData.TitleName, xbe.TitleID)
+				addText(theme.ForegroundColor(), "Database lists %d title updates and %d DLC for this title", len(titleData.TitleUpdates), len(titleData.ContentIDs))
+			}
+			printInfo(fatihColor.FgGreen, "Disc identified as %s (%s)\n", titleData.TitleName, xbe.TitleID)
+			printInfo(fatihColor.FgWhite, "Database lists %d title updates and %d DLC for this title\n", len(titleData.TitleUpdates), len(titleData.ContentIDs))
+		} else {
+			if guiEnabled {
+				addText(theme.PrimaryColorNamed(theme.ColorYellow), "%s (%s) is not in the database", xbe.TitleName, xbe.TitleID)
+			}
+			printInfo(fatihColor.FgYellow, "%s (%s) is not in the database\n", xbe.TitleName, xbe.TitleID)
+		}
+		if guiEnabled {
+			addText(theme.ForegroundColor(), "XBE version %d", xbe.Version)
+		}
+		printInfo(fatihColor.FgWhite, "XBE version %d\n", xbe.Version)
+		printXBEFlags(disc.XBE)
+	}
+
+	var totalSize int64
+	err = fs.WalkDir(xiso, ".", func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		hashes, err := getFileHashes(ctx, xiso, filePath)
+		if err != nil {
+			return err
+		}
+		disc.Files = append(disc.Files, newFileReport(filePath, info.Size(), hashes))
+		totalSize += info.Size()
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error reading disc image: %v", err)
+	}
+
+	for _, warning := range disc.Warnings {
+		if guiEnabled {
+			addText(theme.PrimaryColorNamed(theme.ColorYellow), "Warning: %s", warning)
+		}
+		printInfo(fatihColor.FgYellow, "Warning: %s\n", warning)
+	}
+	if guiEnabled {
+		addText(theme.ForegroundColor(), "Hashed %d files (%.1f MB)", len(disc.Files), float64(totalSize)/1e6)
+	}
+	printInfo(fatihColor.FgWhite, "Hashed %d files (%.1f MB)\n", len(disc.Files), float64(totalSize)/1e6)
+
+	scanResults.Discs = append(scanResults.Discs, disc)
+	return nil
+}
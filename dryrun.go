@@ -0,0 +1,40 @@
+package main
+
+import (
+	"io/fs"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+var dryRunFlag = false
+
+// dryRun totals the files a --dry-run would have copied or uploaded.
+type dryRun struct {
+	files int
+	size  int64
+}
+
+// Prints a file the operation would copy to destination, as verb says, and adds it to the totals. Only its size is
+// read, so the report returned has no hashes.
+func (run *dryRun) add(verb string, source *scanSource, filePath, outPath, destination string) (FileReport, error) {
+	info, err := fs.Stat(source.FS, filePath)
+	if err != nil {
+		return FileReport{}, err
+	}
+	run.files++
+	run.size += info.Size()
+	if guiEnabled {
+		addText(theme.ForegroundColor(), "Would %s %s (%s) to %s", verb, source.displayPath(filePath), formatBytes(info.Size()), destination)
+	}
+	printInfo(fatihColor.FgWhite, "Would %s %s (%s) to %s\n", verb, source.displayPath(filePath), formatBytes(info.Size()), destination)
+	return FileReport{Path: outPath, Size: info.Size()}, nil
+}
+
+// Prints the totals of the dry run, where done says what would have happened to the files.
+func (run *dryRun) summary(done, destination string) {
+	if guiEnabled {
+		addText(theme.PrimaryColorNamed(theme.ColorGreen), "Dry run: %d files (%s) would be %s %s, nothing was written", run.files, formatBytes(run.size), done, destination)
+	}
+	printInfo(fatihColor.FgGreen, "Dry run: %d files (%s) would be %s %s, nothing was written\n", run.files, formatBytes(run.size), done, destination)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+// DuplicateUpdateReport is an update found under more than one title, or under a title it doesn't belong to.
+type DuplicateUpdateReport struct {
+	SHA1 string `json:"sha1" xml:"sha1,attr"`
+	// Owner is the title the update belongs to, from the database or the XBE certificate
+	Owner string   `json:"owner,omitempty" xml:"owner,omitempty"`
+	Paths []string `json:"paths" xml:"path"`
+	// Misplaced are the paths of copies outside the owner's $u folder
+	Misplaced []string `json:"misplaced,omitempty" xml:"misplaced,omitempty"`
+}
+
+// Returns the title whose database entry lists an update hash, if any.
+func updateHashTitle(hash string) (string, bool) {
+	titleID, ok := databaseIndex.updateTitles[hash]
+	return titleID, ok
+}
+
+// Looks for the same update under several titles, and for updates sitting in the wrong title's $u folder, once
+// every source has been scanned.
+func checkDuplicateUpdates() {
+	type copyOfUpdate struct {
+		titleID string
+		path    string
+	}
+	copies := make(map[string][]copyOfUpdate)
+	certificates := make(map[string]string)
+	var hashes []string
+	for _, title := range scanResults.Titles {
+		for _, update := range title.Updates {
+			if update.SHA1 == "" {
+				continue
+			}
+			if _, ok := copies[update.SHA1]; !ok {
+				hashes = append(hashes, update.SHA1)
+			}
+			itemPath := update.Path
+			if title.Source != "" {
+				itemPath = title.Source + "/" + itemPath
+			}
+			copies[update.SHA1] = append(copies[update.SHA1], copyOfUpdate{titleID: title.TitleID, path: itemPath})
+			if update.XBE != nil {
+				certificates[update.SHA1] = strings.ToLower(update.XBE.TitleID)
+			}
+		}
+	}
+	sort.Strings(hashes)
+
+	printed := false
+	for _, hash := range hashes {
+		owner, ok := updateHashTitle(hash)
+		if !ok {
+			owner = certificates[hash]
+		}
+		duplicate := DuplicateUpdateReport{SHA1: hash, Owner: owner}
+		titleIDs := make(map[string]bool)
+		for _, found := range copies[hash] {
+			duplicate.Paths = append(duplicate.Paths, found.path)
+			titleIDs[found.titleID] = true
+			if owner != "" && found.titleID != owner {
+				duplicate.Misplaced = append(duplicate.Misplaced, found.path)
+			}
+		}
+		if len(titleIDs) < 2 && len(duplicate.Misplaced) == 0 {
+			continue
+		}
+
+		if !printed {
+			if guiEnabled {
+				addHeader("Duplicate Updates")
+			}
+			printHeader("Duplicate Updates")
+			printed = true
+		}
+		ownerName := owner
+		if titleData, _, ok := lookupTitle(owner); ok {
+			ownerName = fmt.Sprintf("%s (%s)", titleData.TitleName, owner)
+		}
+		if len(titleIDs) > 1 {
+			if guiEnabled {
+				addText(theme.PrimaryColorNamed(theme.ColorYellow), "Update %s found under %d titles: %s", hash, len(titleIDs), strings.Join(duplicate.Paths, ", "))
+			}
+			printInfo(fatihColor.FgYellow, "Update %s found under %d titles: %s\n", hash, len(titleIDs), strings.Join(duplicate.Paths, ", "))
+		}
+		for _, misplaced := range duplicate.Misplaced {
+			if guiEnabled {
+				addText(theme.PrimaryColorNamed(theme.ColorYellow), "Probably misplaced, belongs to %s: %s", ownerName, misplaced)
+			}
+			printInfo(fatihColor.FgYellow, "Probably misplaced, belongs to %s: %s\n", ownerName, misplaced)
+		}
+		scanResults.DuplicateUpdates = append(scanResults.DuplicateUpdates, duplicate)
+	}
+}
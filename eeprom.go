@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rc4"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Layout of the encrypted section at the start of an Xbox EEPROM.
+const (
+	eepromSize            = 256
+	eepromChecksumLength  = 20
+	eepromEncryptedLength = 28
+	eepromHDDKeyOffset    = 8
+	eepromHDDKeyLength    = 16
+	eepromKeyLength       = 16
+)
+
+// XboxEEPROM holds the decrypted security section of an EEPROM dump.
+type XboxEEPROM struct {
+	HDDKey     []byte
+	GameRegion uint32
+}
+
+// Decrypts the security section of an EEPROM with the kernel's EEPROM key, checking the result against its HMAC.
+func decryptEEPROM(data []byte, eepromKey []byte) (*XboxEEPROM, error) {
+	if len(data) < eepromSize {
+		return nil, fmt.Errorf("EEPROM dump should be %d bytes, got %d", eepromSize, len(data))
+	}
+	if len(eepromKey) != eepromKeyLength {
+		return nil, fmt.Errorf("EEPROM key should be %d bytes, got %d", eepromKeyLength, len(eepromKey))
+	}
+
+	checksum := data[:eepromChecksumLength]
+	mac := hmac.New(sha1.New, eepromKey)
+	mac.Write(checksum)
+	cipher, err := rc4.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	decrypted := make([]byte, eepromEncryptedLength)
+	cipher.XORKeyStream(decrypted, data[eepromChecksumLength:eepromChecksumLength+eepromEncryptedLength])
+
+	mac = hmac.New(sha1.New, eepromKey)
+	mac.Write(decrypted)
+	if !hmac.Equal(mac.Sum(nil), checksum) {
+		return nil, fmt.Errorf("EEPROM checksum mismatch, the dump or EEPROM key is wrong")
+	}
+
+	hddKey := decrypted[eepromHDDKeyOffset : eepromHDDKeyOffset+eepromHDDKeyLength]
+	region := decrypted[eepromHDDKeyOffset+eepromHDDKeyLength:]
+	return &XboxEEPROM{
+		HDDKey:     bytes.Clone(hddKey),
+		GameRegion: binary.LittleEndian.Uint32(region),
+	}, nil
+}
+
+// Reads and decrypts the EEPROM dump named by the -eeprom flag.
+func loadEEPROM(eepromPath string, eepromKeyHex string) (*XboxEEPROM, error) {
+	if eepromKeyHex == "" {
+		return nil, fmt.Errorf("-eeprom requires -eeprom-key, the 16 byte EEPROM key of your kernel")
+	}
+	eepromKey, err := hex.DecodeString(strings.TrimSpace(eepromKeyHex))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing EEPROM key: %v", err)
+	}
+	data, err := os.ReadFile(eepromPath)
+	if err != nil {
+		return nil, fmt.Errorf("error reading EEPROM: %v", err)
+	}
+	return decryptEEPROM(data, eepromKey)
+}
+
+// Returns the ATA password an Xbox uses to lock a drive, derived from the HDD key and the drive's identity.
+func hddPassword(hddKey []byte, model string, serial string) []byte {
+	mac := hmac.New(sha1.New, hddKey)
+	mac.Write([]byte(strings.TrimSpace(model)))
+	mac.Write([]byte(strings.TrimSpace(serial)))
+	return mac.Sum(nil)
+}
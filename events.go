@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/fatih/color"
+)
+
+// ScanEvent is a single line of --porcelain output.
+type ScanEvent struct {
+	Event     string `json:"event"`
+	Type      string `json:"type,omitempty"`
+	TitleID   string `json:"titleID,omitempty"`
+	TitleName string `json:"titleName,omitempty"`
+	ContentID string `json:"contentID,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Path      string `json:"path,omitempty"`
+	SHA1      string `json:"sha1,omitempty"`
+	Archived  bool   `json:"archived,omitempty"`
+	// Bytes and Total are the progress of a progress event
+	Bytes int64  `json:"bytes,omitempty"`
+	Total int64  `json:"total,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+const (
+	eventTitle    = "title"
+	eventContent  = "content"
+	eventUpdate   = "update"
+	eventHash     = "hash"
+	eventProgress = "progress"
+	eventUnknown  = "unknown"
+	eventError    = "error"
+	// Sent by pinecone serve when a scan it runs starts and ends
+	eventStarted  = "started"
+	eventFinished = "finished"
+)
+
+var (
+	porcelainEncoder *json.Encoder
+	porcelainMu      sync.Mutex
+	// eventSubscribers receive every event too, for the event stream of pinecone serve
+	eventSubscribers = make(map[chan ScanEvent]bool)
+)
+
+// Returns a channel receiving every event from now on, and the function that stops it. Events a slow subscriber
+// hasn't taken yet are dropped rather than holding up the scan.
+func subscribeEvents() (chan ScanEvent, func()) {
+	events := make(chan ScanEvent, 256)
+	porcelainMu.Lock()
+	eventSubscribers[events] = true
+	porcelainMu.Unlock()
+	return events, func() {
+		porcelainMu.Lock()
+		delete(eventSubscribers, events)
+		porcelainMu.Unlock()
+	}
+}
+
+// Sends all human readable output to stderr so stdout only carries events.
+func enablePorcelain() {
+	porcelainEncoder = json.NewEncoder(os.Stdout)
+	os.Stdout = os.Stderr
+	color.Output = os.Stderr
+	color.NoColor = true
+	hashProgress = emitHashProgress
+}
+
+func emitEvent(event ScanEvent) {
+	porcelainMu.Lock()
+	defer porcelainMu.Unlock()
+	if porcelainEncoder != nil {
+		porcelainEncoder.Encode(event)
+	}
+	for events := range eventSubscribers {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// Emits a progress event for every 5% of a large file hashed.
+func emitHashProgress(filePath string, done int64, total int64) error {
+	step := total / 20
+	if done/step != (done-int64(hashReadSize()))/step || done == total {
+		emitEvent(ScanEvent{Event: eventProgress, Path: filePath, Bytes: done, Total: total})
+	}
+	return nil
+}
+
+// Emits the item event for a title, or an unknown event if it isn't in the database.
+func emitTitleEvent(title *TitleReport) {
+	if !title.Known {
+		emitEvent(ScanEvent{Event: eventUnknown, Type: itemTypeTitle, TitleID: title.TitleID, Path: title.Path})
+		return
+	}
+	emitEvent(ScanEvent{Event: eventTitle, TitleID: title.TitleID, TitleName: title.TitleName, Path: title.Path})
+}
+
+func emitContentEvent(title *TitleReport, content *ContentReport) {
+	event := ScanEvent{
+		Event:     eventContent,
+		TitleID:   title.TitleID,
+		TitleName: title.TitleName,
+		ContentID: content.ContentID,
+		Name:      content.Name,
+		Path:      content.Path,
+		Archived:  content.Archived,
+	}
+	if !content.Known {
+		event.Event = eventUnknown
+		event.Type = itemTypeContent
+	}
+	emitEvent(event)
+}
+
+func emitUpdateEvent(title *TitleReport, update *UpdateReport) {
+	event := ScanEvent{
+		Event:     eventUpdate,
+		TitleID:   title.TitleID,
+		TitleName: title.TitleName,
+		Name:      update.Name,
+		Path:      update.Path,
+		SHA1:      update.SHA1,
+		Archived:  update.Archived,
+	}
+	if update.Error != "" {
+		event.Event = eventError
+		event.Error = update.Error
+	} else if !update.Known {
+		event.Event = eventUnknown
+		event.Type = itemTypeUpdate
+	}
+	emitEvent(event)
+}
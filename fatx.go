@@ -0,0 +1,332 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+)
+
+// FATX layout constants.
+const (
+	fatxMagic          = "FATX"
+	fatxHeaderSize     = 0x1000
+	fatxSectorSize     = 512
+	fatxDirentSize     = 64
+	fatxMaxNameLength  = 42
+	fatxDirentDeleted  = 0xE5
+	fatxDirentEnd      = 0xFF
+	fatxAttrDirectory  = 0x10
+	fatx16MaxClusters  = 0xFFF0
+	fatx16ChainEnd     = 0xFFF8
+	fatx32ChainEnd     = 0xFFFFFFF8
+	fatxFATAlignment   = 0x1000
+	fatxFirstCluster   = 1
+	fatxMaxChainLength = 1 << 24
+)
+
+var errFATXCorrupt = errors.New("corrupt FATX filesystem")
+
+// FATXPartition is a read-only FATX filesystem, implementing fs.FS.
+type FATXPartition struct {
+	r           io.ReaderAt
+	offset      int64
+	size        int64
+	clusterSize int64
+	rootCluster uint32
+	fat32       bool
+	fatOffset   int64
+	dataOffset  int64
+	clusters    uint32
+}
+
+// Opens the FATX filesystem stored at offset in r.
+func openFATXPartition(r io.ReaderAt, offset int64, size int64) (*FATXPartition, error) {
+	header := make([]byte, 18)
+	if _, err := r.ReadAt(header, offset); err != nil {
+		return nil, err
+	}
+	if string(header[:4]) != fatxMagic {
+		return nil, fmt.Errorf("no FATX filesystem at offset 0x%x", offset)
+	}
+
+	sectorsPerCluster := binary.LittleEndian.Uint32(header[8:])
+	if sectorsPerCluster == 0 || sectorsPerCluster > 1024 {
+		return nil, fmt.Errorf("%w: invalid cluster size", errFATXCorrupt)
+	}
+	p := &FATXPartition{
+		r:           r,
+		offset:      offset,
+		size:        size,
+		clusterSize: int64(sectorsPerCluster) * fatxSectorSize,
+		rootCluster: binary.LittleEndian.Uint32(header[12:]),
+		fatOffset:   offset + fatxHeaderSize,
+	}
+
+	// The FAT has an entry per cluster plus the reserved first entry, and is padded to a page boundary
+	p.clusters = uint32(size / p.clusterSize)
+	entrySize := int64(2)
+	if p.clusters >= fatx16MaxClusters {
+		p.fat32 = true
+		entrySize = 4
+	}
+	fatSize := (int64(p.clusters) + 1) * entrySize
+	fatSize = (fatSize + fatxFATAlignment - 1) &^ (fatxFATAlignment - 1)
+	p.dataOffset = p.fatOffset + fatSize
+	return p, nil
+}
+
+// Returns the FAT entry for cluster.
+func (p *FATXPartition) nextCluster(cluster uint32) (uint32, error) {
+	if p.fat32 {
+		buf := make([]byte, 4)
+		if _, err := p.r.ReadAt(buf, p.fatOffset+int64(cluster)*4); err != nil {
+			return 0, err
+		}
+		return binary.LittleEndian.Uint32(buf), nil
+	}
+	buf := make([]byte, 2)
+	if _, err := p.r.ReadAt(buf, p.fatOffset+int64(cluster)*2); err != nil {
+		return 0, err
+	}
+	next := uint32(binary.LittleEndian.Uint16(buf))
+	if next >= fatx16ChainEnd {
+		next = fatx32ChainEnd
+	}
+	return next, nil
+}
+
+// Follows the FAT to list every cluster of a file or directory.
+func (p *FATXPartition) chain(first uint32) ([]uint32, error) {
+	var clusters []uint32
+	for cluster := first; cluster < fatx32ChainEnd; {
+		if cluster < fatxFirstCluster || cluster > p.clusters || len(clusters) > fatxMaxChainLength {
+			return nil, fmt.Errorf("%w: cluster chain out of range", errFATXCorrupt)
+		}
+		clusters = append(clusters, cluster)
+		next, err := p.nextCluster(cluster)
+		if err != nil {
+			return nil, err
+		}
+		if next == 0 {
+			return nil, fmt.Errorf("%w: cluster chain points to a free cluster", errFATXCorrupt)
+		}
+		cluster = next
+	}
+	return clusters, nil
+}
+
+func (p *FATXPartition) clusterOffset(cluster uint32) int64 {
+	return p.dataOffset + int64(cluster-fatxFirstCluster)*p.clusterSize
+}
+
+// fatxDirent is a single 64 byte directory entry.
+type fatxDirent struct {
+	name         string
+	attributes   uint8
+	firstCluster uint32
+	size         uint32
+	modified     time.Time
+}
+
+// Decodes a FAT style date and time, which FATX counts from the year 2000.
+func fatxTime(data []byte) time.Time {
+	t := binary.LittleEndian.Uint16(data[0:])
+	d := binary.LittleEndian.Uint16(data[2:])
+	return time.Date(2000+int(d>>9), time.Month(d>>5&0xF), int(d&0x1F), int(t>>11), int(t>>5&0x3F), int(t&0x1F)*2, 0, time.UTC)
+}
+
+func (p *FATXPartition) readDir(cluster uint32) ([]fatxDirent, error) {
+	clusters, err := p.chain(cluster)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []fatxDirent
+	buf := make([]byte, p.clusterSize)
+	for _, c := range clusters {
+		if _, err := p.r.ReadAt(buf, p.clusterOffset(c)); err != nil {
+			return nil, err
+		}
+		for offset := 0; offset+fatxDirentSize <= len(buf); offset += fatxDirentSize {
+			raw := buf[offset : offset+fatxDirentSize]
+			nameLength := raw[0]
+			if nameLength == 0 || nameLength == fatxDirentEnd {
+				return entries, nil
+			}
+			if nameLength == fatxDirentDeleted || nameLength > fatxMaxNameLength {
+				continue
+			}
+			entries = append(entries, fatxDirent{
+				name:         string(raw[2 : 2+nameLength]),
+				attributes:   raw[1],
+				firstCluster: binary.LittleEndian.Uint32(raw[0x2C:]),
+				size:         binary.LittleEndian.Uint32(raw[0x30:]),
+				modified:     fatxTime(raw[0x38:]),
+			})
+		}
+	}
+	return entries, nil
+}
+
+// Resolves a slash separated path, matching names case-insensitively like the Xbox does.
+func (p *FATXPartition) lookup(name string) (fatxDirent, error) {
+	entry := fatxDirent{name: ".", attributes: fatxAttrDirectory, firstCluster: p.rootCluster}
+	if name == "." {
+		return entry, nil
+	}
+	for _, element := range strings.Split(name, "/") {
+		if entry.attributes&fatxAttrDirectory == 0 {
+			return fatxDirent{}, fs.ErrNotExist
+		}
+		entries, err := p.readDir(entry.firstCluster)
+		if err != nil {
+			return fatxDirent{}, err
+		}
+		found := false
+		for _, child := range entries {
+			if strings.EqualFold(child.name, element) {
+				entry, found = child, true
+				break
+			}
+		}
+		if !found {
+			return fatxDirent{}, fs.ErrNotExist
+		}
+	}
+	return entry, nil
+}
+
+// Open implements fs.FS.
+func (p *FATXPartition) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	entry, err := p.lookup(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	if entry.attributes&fatxAttrDirectory != 0 {
+		entries, err := p.readDir(entry.firstCluster)
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		return &fatxDir{info: fatxFileInfo{entry}, entries: entries}, nil
+	}
+
+	var clusters []uint32
+	if entry.size > 0 {
+		clusters, err = p.chain(entry.firstCluster)
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		if int64(len(clusters))*p.clusterSize < int64(entry.size) {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: fmt.Errorf("%w: file is larger than its cluster chain", errFATXCorrupt)}
+		}
+	}
+	file := &fatxFile{partition: p, info: fatxFileInfo{entry}, clusters: clusters}
+	file.reader = io.NewSectionReader(file, 0, int64(entry.size))
+	return file, nil
+}
+
+// fatxFileInfo implements fs.FileInfo and fs.DirEntry for a directory entry.
+type fatxFileInfo struct {
+	entry fatxDirent
+}
+
+func (fi fatxFileInfo) Name() string       { return path.Base(fi.entry.name) }
+func (fi fatxFileInfo) Size() int64        { return int64(fi.entry.size) }
+func (fi fatxFileInfo) ModTime() time.Time { return fi.entry.modified }
+func (fi fatxFileInfo) IsDir() bool        { return fi.entry.attributes&fatxAttrDirectory != 0 }
+func (fi fatxFileInfo) Sys() any           { return nil }
+
+func (fi fatxFileInfo) Mode() fs.FileMode {
+	if fi.IsDir() {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+func (fi fatxFileInfo) Type() fs.FileMode          { return fi.Mode().Type() }
+func (fi fatxFileInfo) Info() (fs.FileInfo, error) { return fi, nil }
+
+type fatxFile struct {
+	partition *FATXPartition
+	info      fatxFileInfo
+	clusters  []uint32
+	reader    *io.SectionReader
+}
+
+func (f *fatxFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *fatxFile) Read(b []byte) (int, error) { return f.reader.Read(b) }
+func (f *fatxFile) Close() error               { return nil }
+
+func (f *fatxFile) Seek(offset int64, whence int) (int64, error) {
+	return f.reader.Seek(offset, whence)
+}
+
+// ReadAt maps file offsets onto the cluster chain.
+func (f *fatxFile) ReadAt(b []byte, off int64) (int, error) {
+	size := f.info.Size()
+	if off >= size {
+		return 0, io.EOF
+	}
+	if remaining := size - off; int64(len(b)) > remaining {
+		b = b[:remaining]
+	}
+
+	read := 0
+	clusterSize := f.partition.clusterSize
+	for read < len(b) {
+		index := (off + int64(read)) / clusterSize
+		within := (off + int64(read)) % clusterSize
+		chunk := b[read:]
+		if int64(len(chunk)) > clusterSize-within {
+			chunk = chunk[:clusterSize-within]
+		}
+		n, err := f.partition.r.ReadAt(chunk, f.partition.clusterOffset(f.clusters[index])+within)
+		read += n
+		if err != nil {
+			return read, err
+		}
+	}
+	if off+int64(read) >= size {
+		return read, io.EOF
+	}
+	return read, nil
+}
+
+type fatxDir struct {
+	info    fatxFileInfo
+	entries []fatxDirent
+	offset  int
+}
+
+func (d *fatxDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *fatxDir) Close() error               { return nil }
+
+func (d *fatxDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.Name(), Err: errors.New("is a directory")}
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *fatxDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if count > 0 && len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if count > 0 && count < len(remaining) {
+		remaining = remaining[:count]
+	}
+	d.offset += len(remaining)
+
+	result := make([]fs.DirEntry, len(remaining))
+	for i, entry := range remaining {
+		result[i] = fatxFileInfo{entry}
+	}
+	return result, nil
+}
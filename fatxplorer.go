@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// fatXplorerDrive is an Xbox partition FatXplorer has mounted as a drive letter.
+type fatXplorerDrive struct {
+	// Root is the root of the drive, such as X:\
+	Root string
+	// Label is the volume label FatXplorer gave the partition
+	Label string
+}
+
+// Returns the Xbox partition a drive holds, such as E, when its volume label names one, as in "E" or "Data (E)".
+func (drive fatXplorerDrive) partition() string {
+	words := strings.FieldsFunc(strings.ToUpper(drive.Label), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i := len(words) - 1; i >= 0; i-- {
+		if contains(xboxContentPartitions, words[i]) || contains(xboxCachePartitions, words[i]) {
+			return words[i]
+		}
+	}
+	return ""
+}
+
+// Returns the drives mounted by FatXplorer. Older versions of Pinecone assumed the E partition was mounted as X:,
+// which is still used when no FATX drive can be found, such as when FatXplorer's driver reports another file system
+// name.
+func fatXplorerDrives() ([]fatXplorerDrive, error) {
+	drives, err := listFatXplorerDrives()
+	if err != nil {
+		return nil, err
+	}
+	if len(drives) == 0 {
+		if _, err := os.Stat(filepath.Join(`X:\`, tdataFolder)); err == nil {
+			return []fatXplorerDrive{{Root: `X:\`, Label: "E"}}, nil
+		}
+		return nil, fmt.Errorf("no drives mounted by FatXplorer found, mount the Xbox drive's partitions in FatXplorer first")
+	}
+	return drives, nil
+}
+
+// Opens every Xbox partition FatXplorer mounted as a source, labelled with the partition its volume label names, or
+// else its drive letter, so the results of each can be told apart. As with -image, the X/Y/Z cache partitions are only
+// scanned with --cache, and other partitions are only scanned when they hold TDATA or UDATA, or might hold software.
+func openFatXplorerSources() ([]*scanSource, func(), error) {
+	drives, err := fatXplorerDrives()
+	if err != nil {
+		return nil, nil, err
+	}
+	var sources []*scanSource
+	var closers []func()
+	for _, drive := range drives {
+		partition := drive.partition()
+		cache := contains(xboxCachePartitions, partition)
+		if cache && !cacheFlag {
+			continue
+		}
+		_, tdataErr := os.Stat(filepath.Join(drive.Root, tdataFolder))
+		_, udataErr := os.Stat(filepath.Join(drive.Root, "UDATA"))
+		if !cache && tdataErr != nil && udataErr != nil && partition != "C" && !(softwareFlag && contains(softwarePartitions, partition)) {
+			continue
+		}
+		source, closeSource := openDirSource(drive.Root)
+		source.Label = partition
+		if source.Label == "" {
+			source.Label = strings.TrimSuffix(drive.Root, `\`)
+		}
+		source.Cache = cache
+		sources = append(sources, source)
+		closers = append(closers, closeSource)
+	}
+	closeSources := func() {
+		for _, closeSource := range closers {
+			closeSource()
+		}
+	}
+	if len(sources) == 0 {
+		var roots []string
+		for _, drive := range drives {
+			roots = append(roots, drive.Root)
+		}
+		return nil, nil, fmt.Errorf("none of the drives mounted by FatXplorer (%s) hold a TDATA or UDATA folder", strings.Join(roots, ", "))
+	}
+	return sources, closeSources, nil
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package main
+
+import "fmt"
+
+// FatXplorer only runs on Windows; elsewhere an Xbox drive or image can be read directly with -image.
+func listFatXplorerDrives() ([]fatXplorerDrive, error) {
+	return nil, fmt.Errorf("FatXplorer mode is only available on Windows, pass the drive or an image of it to -image instead")
+}
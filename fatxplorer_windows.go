@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32                  = syscall.NewLazyDLL("kernel32.dll")
+	procGetLogicalDrives      = kernel32.NewProc("GetLogicalDrives")
+	procGetVolumeInformationW = kernel32.NewProc("GetVolumeInformationW")
+)
+
+// Returns the file system name and label of the volume mounted at root, such as C:\.
+func volumeInformation(root string) (fileSystem, label string, err error) {
+	rootPtr, err := syscall.UTF16PtrFromString(root)
+	if err != nil {
+		return "", "", err
+	}
+	var labelBuf, fileSystemBuf [syscall.MAX_PATH + 1]uint16
+	ok, _, callErr := procGetVolumeInformationW.Call(uintptr(unsafe.Pointer(rootPtr)),
+		uintptr(unsafe.Pointer(&labelBuf[0])), uintptr(len(labelBuf)), 0, 0, 0,
+		uintptr(unsafe.Pointer(&fileSystemBuf[0])), uintptr(len(fileSystemBuf)))
+	if ok == 0 {
+		return "", "", callErr
+	}
+	return syscall.UTF16ToString(fileSystemBuf[:]), syscall.UTF16ToString(labelBuf[:]), nil
+}
+
+// Lists the drive letters FatXplorer has mounted Xbox partitions on, which report FATX as their file system.
+func listFatXplorerDrives() ([]fatXplorerDrive, error) {
+	mask, _, callErr := procGetLogicalDrives.Call()
+	if mask == 0 {
+		return nil, callErr
+	}
+	var drives []fatXplorerDrive
+	for i := 0; i < 26; i++ {
+		if mask&(1<<i) == 0 {
+			continue
+		}
+		root := string(rune('A'+i)) + `:\`
+		fileSystem, label, err := volumeInformation(root)
+		if err != nil || !strings.HasPrefix(strings.ToUpper(fileSystem), "FATX") {
+			continue
+		}
+		drives = append(drives, fatXplorerDrive{Root: root, Label: label})
+	}
+	return drives, nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Verdicts printed for each file of a list scanned with pinecone scan -.
+const (
+	verdictKnown      = "known"
+	verdictUnarchived = "unarchived"
+	verdictUnknown    = "unknown"
+	verdictBad        = "bad"
+	verdictError      = "error"
+)
+
+// fileListSplitter splits a file list at newlines, or at the NULs find -print0 separates paths with. Once a list
+// turns out to have NULs it's split at them alone, since the paths of a NUL separated list may hold newlines.
+type fileListSplitter struct {
+	nulSeparated bool
+}
+
+func (s *fileListSplitter) split(data []byte, atEOF bool) (int, []byte, error) {
+	if !s.nulSeparated && bytes.IndexByte(data, 0) >= 0 {
+		s.nulSeparated = true
+	}
+	if s.nulSeparated {
+		if i := bytes.IndexByte(data, 0); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+	} else if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, bytes.TrimSuffix(data[:i], []byte("\r")), nil
+	}
+	if atEOF && len(data) > 0 {
+		if !s.nulSeparated {
+			return len(data), bytes.TrimSuffix(data, []byte("\r")), nil
+		}
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// Returns the title and content IDs of a file inside a TDATA/<title ID>/$c/<content ID> folder.
+func contentOfPath(filePath string) (string, string, bool) {
+	parts := strings.Split(filepath.ToSlash(filePath), "/")
+	for i := 1; i+2 < len(parts); i++ {
+		if strings.EqualFold(parts[i], "$c") && isHexString(parts[i-1], 8) && isHexString(parts[i+1], 16) {
+			return strings.ToLower(parts[i-1]), strings.ToLower(parts[i+1]), true
+		}
+	}
+	return "", "", false
+}
+
+// Returns what the database makes of a file with the given SHA1 at filePath, and a description of what it is.
+// A file of a content item the database lists but nobody archived yet can only be told apart by its path.
+func fileVerdict(hash, filePath string) (string, string) {
+	if bad, ok := titles.KnownBad[hash]; ok {
+		return verdictBad, fmt.Sprintf("known bad dump of %s for %s: %s", bad.Name, bad.TitleID, bad.Reason)
+	}
+	if refs := databaseIndex.hashes[hash]; len(refs) > 0 {
+		ref := refs[0]
+		if ref.contentID == "" {
+			return verdictKnown, fmt.Sprintf("title update of %s (%s): %s", ref.titleName, ref.titleID, ref.name)
+		}
+		return verdictKnown, fmt.Sprintf("file %s of content %s of %s (%s)", ref.name, ref.contentID, ref.titleName, ref.titleID)
+	}
+	if software, ok := titles.Software[hash]; ok {
+		return verdictKnown, fmt.Sprintf("software: %s %s (%s)", software.Name, software.Version, software.Type)
+	}
+	if xbe, ok := databaseIndex.homebrewXBEs[hash]; ok {
+		return verdictKnown, fmt.Sprintf("homebrew: %s (%s) version %s", titles.Homebrew[xbe.titleID].TitleName, xbe.titleID, xbe.id)
+	}
+	if titleID, contentID, ok := contentOfPath(filePath); ok {
+		if titleData, _, known := lookupTitle(titleID); known && contains(titleData.ContentIDs, contentID) {
+			if databaseIndex.archivedName(titleID, contentID) == "" {
+				return verdictUnarchived, fmt.Sprintf("content %s of %s (%s), not archived by anyone yet", contentID, titleData.TitleName, titleID)
+			}
+			return verdictUnknown, fmt.Sprintf("doesn't match the archived copy of content %s of %s (%s)", contentID, titleData.TitleName, titleID)
+		}
+	}
+	return verdictUnknown, ""
+}
+
+// Hashes each file listed in list, one path per line or NUL separated, and prints a tab separated line per file to
+// out: its verdict, SHA1, path and what it is. Directories are skipped, so the output of find can be piped in as is.
+// Everything else Pinecone prints goes to stderr, so out only carries verdicts.
+func scanFileList(list io.Reader, out io.Writer) error {
+	ctx, release := newScanContext()
+	defer release()
+
+	failed := 0
+	scanner := bufio.NewScanner(list)
+	scanner.Split((&fileListSplitter{}).split)
+	for scanner.Scan() {
+		filePath := scanner.Text()
+		if filePath == "" {
+			continue
+		}
+		info, err := os.Stat(filePath)
+		if err == nil && info.IsDir() {
+			continue
+		}
+		var hashes FileHashes
+		if err == nil {
+			hashes, err = getFileHashes(ctx, dirFS(filepath.Dir(filePath)), filepath.Base(filePath))
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return errScanCancelled
+			}
+			failed++
+			fmt.Fprintf(out, "%s\t\t%s\t%v\n", verdictError, filePath, err)
+			continue
+		}
+		verdict, description := fileVerdict(hashes.SHA1, filePath)
+		fmt.Fprintf(out, "%s\t%s\t%s\t%s\n", verdict, hashes.SHA1, filePath, description)
+		logMessage(color.FgWhite, fmt.Sprintf("%s %s: %s", verdict, filePath, description))
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading the file list: %v", err)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d files couldn't be hashed", failed)
+	}
+	return nil
+}
+
+// Scans the file list piped to pinecone scan -, once the database is loaded.
+func runFileListScan() error {
+	verdicts := os.Stdout
+	os.Stdout = os.Stderr
+	color.Output = os.Stderr
+	if err := openLogFile(); err != nil {
+		return err
+	}
+	if err := loadCLIDatabase(updateFlag); err != nil {
+		return err
+	}
+	return scanFileList(os.Stdin, verdicts)
+}
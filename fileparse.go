@@ -1,12 +1,14 @@
 package main
 
 import (
+	"context"
+	"crypto/md5"
 	"crypto/sha1"
-	"encoding/json"
 	"fmt"
+	"hash/crc32"
 	"image/color"
 	"io"
-	"os"
+	"io/fs"
 	"path/filepath"
 	"strings"
 
@@ -14,34 +16,126 @@ import (
 	fatihColor "github.com/fatih/color"
 )
 
-func getSHA1Hash(filePath string) (string, error) {
-	file, err := os.Open(filePath)
+// Size of the reads files are hashed in. Network shares answer every read after a round trip, so --network reads in
+// larger chunks.
+const (
+	hashChunkSize        = 1 << 20
+	networkHashChunkSize = 8 << 20
+)
+
+// Returns the size of the reads files are hashed in.
+func hashReadSize() int {
+	if networkFlag {
+		return networkHashChunkSize
+	}
+	return hashChunkSize
+}
+
+// Files at least this big report their progress while being hashed.
+const hashProgressMinSize = 64 << 20
+
+// hashProgress, when set, is called after each chunk of a large file is hashed, with the number of bytes hashed so
+// far. Returning an error stops hashing the file. Files are hashed on several workers, so it must be safe to call
+// concurrently.
+var hashProgress func(filePath string, done int64, total int64) error
+
+// Feeds a file to hash in hashReadSize reads, reporting progress for large files and keeping to --io-limit. Hashing
+// stops between reads once ctx is cancelled.
+func hashChunked(ctx context.Context, hash io.Writer, file fs.File, filePath string) error {
+	var total int64
+	if info, err := file.Stat(); err == nil {
+		total = info.Size()
+	}
+	report := hashProgress != nil && total >= hashProgressMinSize
+
+	buf := make([]byte, hashReadSize())
+	var done int64
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n, err := file.Read(buf)
+		if n > 0 {
+			hash.Write(buf[:n])
+			done += int64(n)
+			progressHashed(int64(n))
+			if readThrottle != nil {
+				if err := readThrottle.wait(ctx, n); err != nil {
+					return err
+				}
+			}
+			if report {
+				if err := hashProgress(filePath, done, total); err != nil {
+					return err
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func getSHA1Hash(ctx context.Context, fsys fs.FS, filePath string) (string, error) {
+	file, err := fsys.Open(filePath)
 	if err != nil {
 		return "", err
 	}
 	defer file.Close()
 
 	hash := sha1.New()
-	if _, err := io.Copy(hash, file); err != nil {
+	if err := hashChunked(ctx, hash, file, filePath); err != nil {
 		return "", err
 	}
 
 	return fmt.Sprintf("%x", hash.Sum(nil)), nil
 }
 
-func loadIgnoreList(filepath string) ([]string, error) {
-	var ignoreList []string
+// FileHashes holds the checksums of a file. Preservation databases key on different algorithms, so all of them are
+// computed in the same read. Only XXH64 is set for files hashed by --fast-hash.
+type FileHashes struct {
+	SHA1  string
+	MD5   string
+	CRC32 string
+	XXH64 string
+}
 
-	data, err := os.ReadFile(filepath)
+func getFileHashes(ctx context.Context, fsys fs.FS, filePath string) (FileHashes, error) {
+	file, err := fsys.Open(filePath)
 	if err != nil {
-		return nil, err
+		return FileHashes{}, err
+	}
+	defer file.Close()
+
+	sha1Hash, md5Hash, crc32Hash, xxHash := sha1.New(), md5.New(), crc32.NewIEEE(), newXXHash64()
+	if err := hashChunked(ctx, io.MultiWriter(sha1Hash, md5Hash, crc32Hash, xxHash), file, filePath); err != nil {
+		return FileHashes{}, err
 	}
 
-	if err := json.Unmarshal(data, &ignoreList); err != nil {
-		return nil, err
+	return FileHashes{
+		SHA1:  fmt.Sprintf("%x", sha1Hash.Sum(nil)),
+		MD5:   fmt.Sprintf("%x", md5Hash.Sum(nil)),
+		CRC32: fmt.Sprintf("%08x", crc32Hash.Sum32()),
+		XXH64: fmt.Sprintf("%016x", xxHash.Sum64()),
+	}, nil
+}
+
+// Computes only the xxHash64 of a file, which is much faster than the cryptographic hashes.
+func getQuickHash(ctx context.Context, fsys fs.FS, filePath string) (FileHashes, error) {
+	file, err := fsys.Open(filePath)
+	if err != nil {
+		return FileHashes{}, err
 	}
+	defer file.Close()
 
-	return ignoreList, nil
+	xxHash := newXXHash64()
+	if err := hashChunked(ctx, xxHash, file, filePath); err != nil {
+		return FileHashes{}, err
+	}
+	return FileHashes{XXH64: fmt.Sprintf("%016x", xxHash.Sum64())}, nil
 }
 
 func contains(slice []string, val string) bool {
@@ -54,11 +148,22 @@ func contains(slice []string, val string) bool {
 	return false
 }
 
-func checkForContent(directory string) error {
-	if _, err := os.Stat(directory); os.IsNotExist(err) {
-		printInfo(fatihColor.FgYellow, "%s directory not found\n", directory)
-		return fmt.Errorf("%s directory not found", directory)
+// Scans the TDATA folder of source, adding what it finds to scanResults.
+func checkForContent(ctx context.Context, source *scanSource) error {
+	fsys := source.FS
+	directory := tdataFolder
+	if _, err := fs.Stat(fsys, directory); err != nil {
+		printInfo(fatihColor.FgYellow, "%s directory not found\n", source.displayPath(directory))
+		return fmt.Errorf("%s directory not found", source.displayPath(directory))
 	}
+	scanResults.sources = append(scanResults.sources, source)
+	return walkContent(ctx, source, directory)
+}
+
+// Walks root, the TDATA folder of source or a title folder inside it, adding the titles found to scanResults.
+func walkContent(ctx context.Context, source *scanSource, root string) error {
+	fsys := source.FS
+	directory := tdataFolder
 
 	logOutput := func(s string) {
 		if !guiEnabled {
@@ -68,55 +173,139 @@ func checkForContent(directory string) error {
 		}
 	}
 
-	err := filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
+	artifacts := newArtifactChecker(source, directory)
+	defer endTitleOutput()
+
+	if jobsFlag > 1 && !source.Serial && source.hashes == nil {
+		defer source.startHashing(ctx, jobsFlag)()
+	}
+
+	err := fs.WalkDir(fsys, root, func(path string, info fs.DirEntry, err error) error {
 		if err != nil {
+			// The folder is skipped, and the walk carries on with the next
+			return skipScanError(ctx, source, path, err)
+		}
+		if err := ctx.Err(); err != nil {
 			return err
 		}
+		if isIgnored(path) {
+			if info.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		artifacts.checkName(path, info)
+		if !info.IsDir() {
+			return artifacts.check(path, info)
+		}
 
-		// Check directories that are exactly 8 characters long, potential titleID
-		if info.IsDir() && len(info.Name()) == 8 {
+		// Check directories right under TDATA that are exactly 8 characters long, potential titleID. Folders of the same
+		// length inside a title, such as settings, are only its files
+		if info.IsDir() && len(info.Name()) == 8 && strings.TrimSuffix(path, "/"+info.Name()) == directory {
 			titleID := strings.ToLower(info.Name())
-			titleData, ok := titles.Titles[titleID]
+			if !titleSelected(titleID) {
+				return fs.SkipDir
+			}
+			if titleID == dashboardTitleID {
+				return fs.SkipDir // The dashboard's own data, such as soundtracks, is checked separately
+			}
+			titleData, platform, ok := lookupTitle(titleID)
+			if _, homebrew := titles.Homebrew[titleID]; !ok && homebrew {
+				recordHomebrew(source, titleID, reportPath(directory, path), path)
+				return fs.SkipDir
+			}
 			if ok {
 				// Process known titles as before
 				if guiEnabled {
 					addHeader(titleData.TitleName)
 				}
-				printHeader(titleData.TitleName)
+				beginTitleOutput(titleData.TitleName, "")
+				if platform != "" {
+					if guiEnabled {
+						addText(theme.ForegroundColor(), "%s", platformNames[platform])
+					}
+					printInfo(fatihColor.FgWhite, "%s\n", platformNames[platform])
+				}
+			}
+			if !ok {
+				beginTitleOutput("", onlyUnknown)
+			}
+			titleReport := TitleReport{
+				TitleID:   titleID,
+				TitleName: titleData.TitleName,
+				Known:     ok,
+				Platform:  platform,
+				Path:      reportPath(directory, path),
+				Source:    source.reportName(),
+			}
+			if ok {
+				emitTitleEvent(&titleReport)
 			}
 
 			// Check and potentially process $c subdirectory
-			subDirDLC := filepath.Join(path, "$c")
-			subInfoDLC, err := os.Stat(subDirDLC)
+			subDirDLC := path + "/$c"
+			subInfoDLC, err := fs.Stat(fsys, subDirDLC)
 			if err == nil && subInfoDLC.IsDir() {
 				if ok { // Process content if titleID is known
-					err = processDLCContent(subDirDLC, titleData, titleID, directory)
-					if err != nil {
+					err = processDLCContent(ctx, source, subDirDLC, titleData, titleID, directory, &titleReport)
+					if err := skipScanError(ctx, source, subDirDLC, err); err != nil {
 						return err
 					}
 				} else {
-					logOutput(fmt.Sprintf("DLC content found in unrecognized directory: %s\n", subDirDLC))
+					logOutput(fmt.Sprintf("DLC content found in unrecognized directory: %s\n", source.displayPath(subDirDLC)))
 				}
 			}
 
 			// Check and potentially process $u subdirectory
-			subDirUpdates := filepath.Join(path, "$u")
-			subInfoUpdates, err := os.Stat(subDirUpdates)
+			subDirUpdates := path + "/$u"
+			subInfoUpdates, err := fs.Stat(fsys, subDirUpdates)
 			if err == nil && subInfoUpdates.IsDir() {
 				if ok { // Process updates if titleID is known
-					err = processUpdates(subDirUpdates, titleData, titleID, directory)
-					if err != nil {
+					err = processUpdates(ctx, source, subDirUpdates, titleData, titleID, directory, &titleReport)
+					if err := skipScanError(ctx, source, subDirUpdates, err); err != nil {
 						return err
 					}
 				} else {
 					if guiEnabled {
 					}
-					logOutput(fmt.Sprintf("Updates found in unrecognized directory: %s\n", subDirUpdates))
+					logOutput(fmt.Sprintf("Updates found in unrecognized directory: %s\n", source.displayPath(subDirUpdates)))
+				}
+			}
+
+			if ok {
+				err = processReservedFolders(ctx, source, path, directory, &titleReport)
+				if err := skipScanError(ctx, source, path, err); err != nil {
+					return err
+				}
+				if deepFlag {
+					err = inventoryTitleFiles(ctx, source, path, &titleReport)
+					if err := skipScanError(ctx, source, path, err); err != nil {
+						return err
+					}
+				}
+				reportUpdateVersions(titleData, &titleReport)
+			}
+
+			if !ok {
+				// Unrecognized directories are recorded with what they hold, so they can be submitted to the database
+				unknown, err := inspectUnknownTitle(ctx, source, directory, path)
+				if err := skipScanError(ctx, source, path, err); err != nil {
+					return err
+				}
+				titleReport.Unknown = unknown
+				emitTitleEvent(&titleReport)
+			}
+			scanResults.Titles = append(scanResults.Titles, titleReport)
+			endTitleOutput()
+			progressTitleWalked()
+			if currentCheckpoint != nil {
+				if err := currentCheckpoint.titleWalked(source.displayPath(path)); err != nil {
+					return err
 				}
 			}
 
 			if !ok {
-				return filepath.SkipDir // Skip further processing in unrecognized directories
+				return fs.SkipDir // Skip further processing in unrecognized directories
 			}
 		}
 		return nil
@@ -125,27 +314,32 @@ func checkForContent(directory string) error {
 	return err
 }
 
-func processDLCContent(subDirDLC string, titleData TitleData, titleID string, directory string) error {
-	subContents, err := os.ReadDir(subDirDLC)
+func processDLCContent(ctx context.Context, source *scanSource, subDirDLC string, titleData TitleData, titleID string, directory string, titleReport *TitleReport) error {
+	subContents, err := fs.ReadDir(source.FS, subDirDLC)
 	if err != nil {
 		return err
 	}
 
 	for _, subContent := range subContents {
-		subContentPath := filepath.Join(subDirDLC, subContent.Name())
-		if !subContent.IsDir() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		subContentPath := subDirDLC + "/" + subContent.Name()
+		if !subContent.IsDir() || isIgnored(subContentPath) {
 			continue
 		}
 
-		subDirContents, err := os.ReadDir(subContentPath)
+		subDirContents, err := fs.ReadDir(source.FS, subContentPath)
 		if err != nil {
 			return err
 		}
 
 		hasContentMetaXbx := false
+		contentMetaPath := ""
 		for _, dlcFiles := range subDirContents {
 			if strings.Contains(strings.ToLower(dlcFiles.Name()), "contentmeta.xbx") && !dlcFiles.IsDir() {
 				hasContentMetaXbx = true
+				contentMetaPath = subContentPath + "/" + dlcFiles.Name()
 				break
 			}
 		}
@@ -155,25 +349,46 @@ func processDLCContent(subDirDLC string, titleData TitleData, titleID string, di
 		}
 
 		contentID := strings.ToLower(subContent.Name())
-		if !contains(titleData.ContentIDs, contentID) {
+		contentReport := ContentReport{
+			ContentID: contentID,
+			Path:      reportPath(directory, subContentPath),
+		}
+		meta, err := readContentMeta(source.FS, contentMetaPath)
+		if err != nil {
+			contentReport.Warnings = append(contentReport.Warnings, fmt.Sprintf("unable to parse contentmeta.xbx: %v", err))
+		} else {
+			contentReport.Meta = meta
+		}
+		contentReport.Class = classifyContent(titleData, contentID, contentReport.Meta)
+		contentReport.Files, err = hashContentFiles(ctx, source, subContentPath, !needsFullHashes(titleData, contentID))
+		if err != nil {
+			return err
+		}
+
+		contentReport.Insignia = insigniaCopy(titleData, contentID, contentReport.Files)
+		if !contains(titleData.ContentIDs, contentID) && !titleData.insigniaOnly(contentID) {
 			if guiEnabled {
-				addText(theme.ErrorColor(), "Unknown content found at: %s", subContentPath)
+				addText(theme.ErrorColor(), "Unknown content found at: %s", source.displayPath(subContentPath))
 			}
-			printInfo(fatihColor.FgRed, "Unknown content found at: %s\n", subContentPath)
+			restoreOutput := itemOutput(onlyUnknown)
+			printInfo(fatihColor.FgRed, "Unknown content found at: %s\n", source.displayPath(subContentPath))
+			printContentMeta(&contentReport)
+			restoreOutput()
+			emitContentEvent(titleReport, &contentReport)
+			titleReport.Content = append(titleReport.Content, contentReport)
 			continue
 		}
-
-		archivedName := ""
-		for _, archived := range titleData.Archived {
-			for archivedID, name := range archived {
-				if archivedID == contentID {
-					archivedName = name
-					break
-				}
-			}
-			if archivedName != "" {
-				break
+		contentReport.Known = true
+		archivedName := databaseIndex.archivedName(titleID, contentID)
+		restoreOutput := itemOutput(onlyUnarchived)
+		if archivedName != "" {
+			restoreOutput = itemOutput(onlyArchived)
+		}
+		if contentReport.Insignia {
+			if guiEnabled {
+				addText(theme.PrimaryColorNamed(theme.ColorBlue), "Downloaded from Insignia: %s", titleData.Insignia[contentID].Name)
 			}
+			printInfo(fatihColor.FgCyan, "Downloaded from Insignia: %s\n", titleData.Insignia[contentID].Name)
 		}
 
 		subContentPath = strings.TrimPrefix(subContentPath, directory+"/")
@@ -182,85 +397,205 @@ func processDLCContent(subDirDLC string, titleData TitleData, titleID string, di
 				addText(theme.PrimaryColorNamed(theme.ColorGreen), "Content is known and archived %s", archivedName)
 			}
 			printInfo(fatihColor.FgGreen, "Content is known and archived %s\n", archivedName)
-
+			// The archived copy is the original download, which a re-issue isn't expected to match
+			if !contentReport.Insignia {
+				verifyContentFiles(titleData, &contentReport)
+			}
 		} else {
 			if guiEnabled {
 				addText(theme.ErrorColor(), "%s has unarchived content found at: %s", titleData.TitleName, subContentPath)
 			}
 			printInfo(fatihColor.FgYellow, "%s has unarchived content found at: %s\n", titleData.TitleName, subContentPath)
-
+			printContentMeta(&contentReport)
 		}
+		restoreOutput()
+		contentReport.Name = archivedName
+		if archivedName == "" && contentReport.Insignia {
+			contentReport.Name = titleData.Insignia[contentID].Name
+		}
+		contentReport.Archived = archivedName != ""
+		emitContentEvent(titleReport, &contentReport)
+		titleReport.Content = append(titleReport.Content, contentReport)
 	}
 
 	return nil
 }
 
-func processUpdates(subDirUpdates string, titleData TitleData, titleID string, directory string) error {
-	files, err := os.ReadDir(subDirUpdates)
+func processUpdates(ctx context.Context, source *scanSource, subDirUpdates string, titleData TitleData, titleID string, directory string, titleReport *TitleReport) error {
+	files, err := fs.ReadDir(source.FS, subDirUpdates)
 	if err != nil {
 		return err
 	}
 
-	knownUpdateFound := false
 	for _, f := range files {
-		if filepath.Ext(f.Name()) != ".xbe" {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !strings.EqualFold(filepath.Ext(f.Name()), ".xbe") || isIgnored(subDirUpdates+"/"+f.Name()) {
 			continue
 		}
 
-		filePath := filepath.Join(subDirUpdates, f.Name())
-		fileHash, err := getSHA1Hash(filePath)
+		knownUpdateFound := false
+		filePath := subDirUpdates + "/" + f.Name()
+		updateReport := UpdateReport{Path: reportPath(directory, filePath)}
+		if fileInfo, err := f.Info(); err == nil {
+			updateReport.Size = fileInfo.Size()
+		}
+		hashes, err := source.hash(ctx, filePath)
+		fileHash := hashes.SHA1
 		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err() // A cancelled scan isn't a hashing error
+			}
 			if guiEnabled {
 				addText(theme.ErrorColor(), "Error calculating hash for file: %s, error: %s", f.Name(), err.Error())
 			}
+			restoreOutput := itemOutput(onlyErrors)
 			printInfo(fatihColor.FgRed, "Error calculating hash for file: %s, error: %s\n", f.Name(), err.Error())
+			restoreOutput()
+			updateReport.Error = err.Error()
+			emitUpdateEvent(titleReport, &updateReport)
+			titleReport.Updates = append(titleReport.Updates, updateReport)
 
 			continue
 		}
+		emitEvent(ScanEvent{Event: eventHash, TitleID: titleID, Path: updateReport.Path, SHA1: fileHash})
+		inspectUpdateXBE(source.FS, filePath, titleID, &updateReport)
 
-		for _, knownUpdate := range titleData.TitleUpdatesKnown {
-			for knownHash, name := range knownUpdate {
-				if knownHash == fileHash {
-					if guiEnabled {
-						addHeader("File Info")
-						addText(theme.PrimaryColorNamed(theme.ColorGreen), "Known and Archived Title update found for %s (%s) (%s)", titleData.TitleName, titleID, name)
-						filePath = strings.TrimPrefix(filePath, directory+"/")
-						addText(theme.PrimaryColorNamed(theme.ColorGreen), "Path: %s", filePath)
-						addText(theme.PrimaryColorNamed(theme.ColorGreen), "SHA1: %s", fileHash)
-						addText(color.Transparent, separator)
-					}
-					printHeader("File Info")
-					printInfo(fatihColor.FgGreen, "Known and Archive Title update found for %s (%s) (%s)\n", titleData.TitleName, titleID, name)
-					filePath = strings.TrimPrefix(filePath, directory+"/")
-					printInfo(fatihColor.FgGreen, "Path: %s\n", filePath)
-					printInfo(fatihColor.FgGreen, "SHA1: %s\n", fileHash)
-					fmt.Println(separator)
-
-					knownUpdateFound = true
-					break
-				}
+		if name, ok := databaseIndex.knownUpdate(titleID, fileHash); ok {
+			if guiEnabled {
+				addHeader("File Info")
+				addText(theme.PrimaryColorNamed(theme.ColorGreen), "Known and Archived Title update found for %s (%s) (%s)", titleData.TitleName, titleID, name)
+				filePath = strings.TrimPrefix(filePath, directory+"/")
+				addText(theme.PrimaryColorNamed(theme.ColorGreen), "Path: %s", filePath)
+				addText(theme.PrimaryColorNamed(theme.ColorGreen), "SHA1: %s", fileHash)
 			}
-			if knownUpdateFound {
-				break
+			restoreOutput := itemOutput(onlyArchived)
+			printHeader("File Info")
+			printInfo(fatihColor.FgGreen, "Known and Archive Title update found for %s (%s) (%s)\n", titleData.TitleName, titleID, name)
+			filePath = strings.TrimPrefix(filePath, directory+"/")
+			printInfo(fatihColor.FgGreen, "Path: %s\n", filePath)
+			printInfo(fatihColor.FgGreen, "SHA1: %s\n", fileHash)
+			printXBEInfo(&updateReport)
+			if guiEnabled {
+				addText(color.Transparent, separator)
 			}
+			printLine(separator)
+			restoreOutput()
+
+			updateReport.Name = name
+			knownUpdateFound = true
+		}
+
+		if bad, ok := titles.KnownBad[fileHash]; ok && !knownUpdateFound {
+			updateReport.KnownBad = bad.Reason
+			updateReport.Warnings = append(updateReport.Warnings, fmt.Sprintf("this update matches a known-bad dump of %s: %s", bad.Name, bad.Reason))
 		}
 
 		if !knownUpdateFound {
+			// A damaged copy of a known update won't match its hash, so say why rather than calling it unknown
+			description, category := "Unknown", onlyUnknown
+			switch {
+			case updateReport.KnownBad != "":
+				description, category = "Known-bad", onlyErrors
+			case updateReport.Integrity == xbeTruncated:
+				description, category = "Truncated", onlyErrors
+			case updateReport.Integrity == xbeCorrupted:
+				description, category = "Corrupted", onlyErrors
+			}
 			if guiEnabled {
 				addHeader("File Info")
-				addText(theme.ErrorColor(), "Unknown Title Update found for %s (%s)", titleData.TitleName, titleID)
+				addText(theme.ErrorColor(), "%s Title Update found for %s (%s)", description, titleData.TitleName, titleID)
 				filePath = strings.TrimPrefix(filePath, directory+"/")
 				addText(theme.ErrorColor(), "Path: %s", filePath)
 				addText(theme.ErrorColor(), "SHA1: %s", fileHash)
 			}
+			restoreOutput := itemOutput(category)
 			printHeader("File Info")
-			printInfo(fatihColor.FgRed, "Unknown Title Update found for %s (%s)\n", titleData.TitleName, titleID)
+			printInfo(fatihColor.FgRed, "%s Title Update found for %s (%s)\n", description, titleData.TitleName, titleID)
 			filePath = strings.TrimPrefix(filePath, directory+"/")
 			printInfo(fatihColor.FgRed, "Path: %s\n", filePath)
 			printInfo(fatihColor.FgRed, "SHA1: %s\n", fileHash)
-
+			printXBEInfo(&updateReport)
+			restoreOutput()
 		}
+
+		updateReport.SHA1 = fileHash
+		updateReport.MD5 = hashes.MD5
+		updateReport.CRC32 = hashes.CRC32
+		updateReport.XXH64 = hashes.XXH64
+		updateReport.Known = knownUpdateFound
+		updateReport.Archived = knownUpdateFound
+		emitUpdateEvent(titleReport, &updateReport)
+		titleReport.Updates = append(titleReport.Updates, updateReport)
 	}
 
 	return nil
 }
+
+// Prints the self-described name of a content item, along with any problems reading it.
+func printContentMeta(contentReport *ContentReport) {
+	if meta := contentReport.Meta; meta != nil && meta.DisplayName != "" {
+		if guiEnabled {
+			addText(theme.ForegroundColor(), "Content name: %s", meta.DisplayName)
+		}
+		printInfo(fatihColor.FgWhite, "Content name: %s\n", meta.DisplayName)
+		if meta.OfferString != "" {
+			if guiEnabled {
+				addText(theme.ForegroundColor(), "Offer: %s", meta.OfferString)
+			}
+			printInfo(fatihColor.FgWhite, "Offer: %s\n", meta.OfferString)
+		}
+	}
+	if contentReport.Class != "" && contentReport.Class != contentClassUnclassified {
+		if guiEnabled {
+			addText(theme.ForegroundColor(), "Classified as %s", contentClassNames[contentReport.Class])
+		}
+		printInfo(fatihColor.FgWhite, "Classified as %s\n", contentClassNames[contentReport.Class])
+	}
+	for _, warning := range contentReport.Warnings {
+		if guiEnabled {
+			addText(theme.PrimaryColorNamed(theme.ColorYellow), "Warning: %s", warning)
+		}
+		printInfo(fatihColor.FgYellow, "Warning: %s\n", warning)
+	}
+}
+
+// Reads the certificate of an update XBE into the report, warning if it doesn't belong to the title or if it's
+// truncated or corrupted.
+func inspectUpdateXBE(fsys fs.FS, filePath string, titleID string, updateReport *UpdateReport) {
+	xbe, err := readXBEFile(fsys, filePath)
+	if err != nil {
+		updateReport.Warnings = append(updateReport.Warnings, fmt.Sprintf("unable to read XBE header: %v", err))
+		return
+	}
+
+	updateReport.XBE = newXBEReport(xbe)
+	if xbe.TitleID != titleID {
+		updateReport.Warnings = append(updateReport.Warnings, fmt.Sprintf("XBE certificate title ID %s doesn't match parent folder %s", xbe.TitleID, titleID))
+	}
+
+	integrity, problems, err := checkXBEFile(fsys, filePath, xbe)
+	if err != nil {
+		updateReport.Warnings = append(updateReport.Warnings, fmt.Sprintf("unable to check XBE integrity: %v", err))
+		return
+	}
+	updateReport.Integrity = integrity
+	updateReport.Warnings = append(updateReport.Warnings, problems...)
+}
+
+func printXBEInfo(updateReport *UpdateReport) {
+	if xbe := updateReport.XBE; xbe != nil {
+		if guiEnabled {
+			addText(theme.ForegroundColor(), "XBE: %s (%s) version %d", xbe.TitleName, xbe.TitleID, xbe.Version)
+		}
+		printInfo(fatihColor.FgWhite, "XBE: %s (%s) version %d\n", xbe.TitleName, xbe.TitleID, xbe.Version)
+		printXBEFlags(xbe)
+	}
+	for _, warning := range updateReport.Warnings {
+		if guiEnabled {
+			addText(theme.PrimaryColorNamed(theme.ColorYellow), "Warning: %s", warning)
+		}
+		printInfo(fatihColor.FgYellow, "Warning: %s\n", warning)
+	}
+}
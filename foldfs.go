@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"sync"
+)
+
+// foldFS opens the files of a dump whatever the case of their path, as FATX does. Dumps copied to a case sensitive
+// drive keep the case each FTP client or copy tool wrote them with, so one has tdata or $C where another has TDATA
+// and $c, and scanners can look them all up by the names the console uses. Paths are only resolved when they don't
+// exist as given, so dumps with the usual case pay nothing for it.
+type foldFS struct {
+	fs.FS
+
+	// The paths resolved so far, keyed by the path asked for
+	resolved sync.Map
+}
+
+func newFoldFS(fsys fs.FS) *foldFS {
+	return &foldFS{FS: fsys}
+}
+
+func (f *foldFS) Open(name string) (fs.File, error) {
+	file, err := f.FS.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		if real, ok := f.resolve(name); ok {
+			return f.FS.Open(real)
+		}
+	}
+	return file, err
+}
+
+func (f *foldFS) Stat(name string) (fs.FileInfo, error) {
+	info, err := fs.Stat(f.FS, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		if real, ok := f.resolve(name); ok {
+			return fs.Stat(f.FS, real)
+		}
+	}
+	return info, err
+}
+
+func (f *foldFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(f.FS, name)
+	if errors.Is(err, fs.ErrNotExist) {
+		if real, ok := f.resolve(name); ok {
+			return fs.ReadDir(f.FS, real)
+		}
+	}
+	return entries, err
+}
+
+// Returns the path a file or link the listing of its folder named has on disk, which only its folder can differ
+// from. Links are looked up by name, as following them could fail.
+func (f *foldFS) diskPath(name string) string {
+	dir := path.Dir(name)
+	if _, err := fs.Stat(f.FS, dir); errors.Is(err, fs.ErrNotExist) {
+		if real, ok := f.resolve(dir); ok {
+			return path.Join(real, path.Base(name))
+		}
+	}
+	return name
+}
+
+// Returns the path name has on disk, matching each of its elements case-insensitively where it doesn't exist as
+// given.
+func (f *foldFS) resolve(name string) (string, bool) {
+	if name == "." {
+		return name, true
+	}
+	if real, ok := f.resolved.Load(name); ok {
+		return real.(string), true
+	}
+	dir, ok := f.resolve(path.Dir(name))
+	if !ok {
+		return "", false
+	}
+	real := path.Join(dir, path.Base(name))
+	if _, err := fs.Stat(f.FS, real); err != nil {
+		if real = findFileFold(f.FS, dir, path.Base(name)); real == "" {
+			return "", false
+		}
+	}
+	f.resolved.Store(name, real)
+	return real, true
+}
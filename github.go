@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variable holding a GitHub token to download with, which raises the API's rate limit from 60 requests
+// an hour per IP address, shared by everyone behind the same NAT, to 5000 for the token.
+const githubTokenEnv = "GITHUB_TOKEN"
+
+// Returned when GitHub refuses a download because the rate limit of the API is used up.
+var errRateLimited = errors.New("GitHub API rate limit reached")
+
+// Reports whether requests to host may carry the GitHub token. It's never sent to other servers, mirrors included.
+func isGitHubHost(host string) bool {
+	return host == "api.github.com" || host == "raw.githubusercontent.com"
+}
+
+// Adds the GitHub token, if one is set, to requests for GitHub.
+func authorizeGitHub(req *http.Request) {
+	if token := strings.TrimSpace(os.Getenv(githubTokenEnv)); token != "" && isGitHubHost(req.URL.Host) {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// Returns the error for a response GitHub refused, telling a used-up rate limit and a rejected token apart from other
+// failures.
+func githubResponseError(resp *http.Response) error {
+	if !isGitHubHost(resp.Request.URL.Host) {
+		return nil
+	}
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return fmt.Errorf("GitHub rejected the token in %s: %s", githubTokenEnv, resp.Status)
+	case http.StatusForbidden, http.StatusTooManyRequests:
+	default:
+		return nil
+	}
+
+	wait := ""
+	if retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		wait = fmt.Sprintf(", retry in %d seconds", retryAfter)
+	} else if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			resetAt := time.Unix(reset, 0)
+			wait = fmt.Sprintf(", it resets at %s (in %d minutes)", resetAt.Format("15:04"), int(time.Until(resetAt).Minutes())+1)
+		}
+	} else if resp.StatusCode == http.StatusForbidden {
+		return nil
+	}
+	hint := ""
+	if os.Getenv(githubTokenEnv) == "" {
+		hint = fmt.Sprintf("; set %s to a GitHub token to raise the limit", githubTokenEnv)
+	}
+	return fmt.Errorf("%w%s%s", errRateLimited, wait, hint)
+}
+
+// Returns the raw.githubusercontent.com URL of a file given by its GitHub contents API URL, which isn't counted
+// against the API's rate limit, or "" if u isn't one.
+func rawGitHubURL(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Host != "api.github.com" {
+		return ""
+	}
+	parts := strings.SplitN(strings.TrimPrefix(parsed.Path, "/"), "/", 5)
+	if len(parts) != 5 || parts[0] != "repos" || parts[3] != "contents" {
+		return ""
+	}
+	ref := parsed.Query().Get("ref")
+	if ref == "" {
+		ref = "HEAD"
+	}
+	raw := url.URL{Scheme: "https", Host: "raw.githubusercontent.com", Path: "/" + parts[1] + "/" + parts[2] + "/" + ref + "/" + parts[4]}
+	return raw.String()
+}
@@ -38,6 +38,13 @@ type Settings struct {
 	Discord  string `json:"discord"`
 	Twitter  string `json:"twitter"`
 	Reddit   string `json:"reddit"`
+	// Databases lists the database sources used when no --db-url is given
+	Databases []string `json:"databases,omitempty"`
+	// BackupTarget is where pinecone backup copies unarchived content when no -target is given
+	BackupTarget string `json:"backupTarget,omitempty"`
+	// TorrentTrackers and TorrentWebSeeds are used by pinecone torrent when no -tracker or -webseed is given
+	TorrentTrackers []string `json:"torrentTrackers,omitempty"`
+	TorrentWebSeeds []string `json:"torrentWebSeeds,omitempty"`
 }
 
 var (
@@ -185,6 +192,7 @@ func setDumpFolder(window fyne.Window) {
 
 		if _, err := os.Stat(path.Join(tmpDumpPath + "TDATA")); os.IsNotExist(err) {
 			dumpLocation = tmpDumpPath
+			locationsFlag = nil
 			output := canvas.NewText("Path set to: "+tmpDumpPath, theme.ForegroundColor())
 			outputContainer.Add(output)
 		} else {
@@ -201,7 +209,9 @@ func guiScanDump() {
 		addText(theme.ErrorColor(), err.Error())
 	}
 
-	err = checkParsingSettings()
+	ctx, release := newScanContext()
+	defer release()
+	err = checkParsingSettings(ctx, scanLocations())
 	if nil != err {
 		fmt.Println("ERROR: ", err.Error())
 		addText(theme.ErrorColor(), err.Error())
@@ -209,6 +219,10 @@ func guiScanDump() {
 }
 
 func guiStartScan(options GUIOptions, window fyne.Window) {
+	if scanRunning() {
+		addText(theme.ForegroundColor(), "A scan is already running.")
+		return
+	}
 	outputContainer.RemoveAll()
 	if dumpLocation == "" {
 		output := canvas.NewText("Please set a path first.", theme.ForegroundColor())
@@ -229,14 +243,14 @@ func guiShowDownloadConfirmation(window fyne.Window, filePath string, url string
 	confirmation := dialog.NewConfirm("Confirmation", message, func(confirmed bool) {
 		if confirmed {
 			// Action to perform if confirmed
-			err := loadJSONData(filePath, "Xbox-Preservation-Project", "Pinecone", dataPath+"/id_database.json", &titles, true)
+			err := loadDatabases(filePath, true)
 			if err != nil {
 				text := fmt.Sprintf("error downloading data: %v", err)
 				output := canvas.NewText(text, theme.ErrorColor())
 				outputContainer.Add(output)
 				return
 			}
-			guiScanDump()
+			go guiScanDump()
 		} else {
 			// Action to perform if canceled
 			output := canvas.NewText("Download aborted by user", theme.ErrorColor())
@@ -255,6 +269,10 @@ func saveOutput(settings *Settings) {
 	timestamp := t.Format("2006-01-02-15-04-05")
 	// Define the path to the output file
 	outputPath := filepath.Join(dataPath, "output", "output-"+timestamp+".txt")
+	if err := checkWritable(outputPath); err != nil {
+		addText(theme.ErrorColor(), err.Error())
+		return
+	}
 	// Create the 'output' directory if it doesn't exist
 	outputDir := filepath.Dir(outputPath)
 	if _, err := os.Stat(outputDir); os.IsNotExist(err) {
@@ -324,11 +342,17 @@ func startGUI(options GUIOptions) {
 	})
 	setFolder.SetToolTip("Set Dump Folder")
 
+	// Scans run in the background so the stop button stays responsive
 	scanPath := ttwidget.NewButtonWithIcon("", theme.SearchIcon(), func() {
-		guiStartScan(options, w)
+		go guiStartScan(options, w)
 	})
 	scanPath.SetToolTip("Scan For Content")
 
+	stopPath := ttwidget.NewButtonWithIcon("", theme.MediaStopIcon(), func() {
+		stopScan()
+	})
+	stopPath.SetToolTip("Stop Scan")
+
 	// Save output to a file in the homeDir with a timestamp.
 	saveOutput := ttwidget.NewButtonWithIcon("", theme.DocumentSaveIcon(), func() {
 		settings, err := loadSettings()
@@ -363,6 +387,7 @@ func startGUI(options GUIOptions) {
 
 	// Exit the application
 	exit := ttwidget.NewButtonWithIcon("", theme.LogoutIcon(), func() {
+		stopScan()
 		a.Quit()
 	})
 	exit.SetToolTip("Exit")
@@ -371,7 +396,7 @@ func startGUI(options GUIOptions) {
 	sideMenu := container.NewVBox()
 
 	// Create a container with vertical box layout for the buttons
-	buttons := container.NewVBox(setFolder, scanPath, updateJSON, saveOutput, settingsButton, exit)
+	buttons := container.NewVBox(setFolder, scanPath, stopPath, updateJSON, saveOutput, settingsButton, exit)
 
 	// Add the hamburger button to the hamburgerMenu
 	sideMenu.Add(buttons)
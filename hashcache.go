@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// hashCacheEntry is the hashes of a file as it was when they were computed.
+type hashCacheEntry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	SHA1    string    `json:"sha1"`
+	MD5     string    `json:"md5"`
+	CRC32   string    `json:"crc32"`
+	XXH64   string    `json:"xxh64"`
+}
+
+// hashCache remembers the hashes of files between scans, keyed by the path printed for them. Entries are only used
+// while the file's size and modification time are unchanged.
+type hashCache struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]hashCacheEntry
+	changed bool
+}
+
+// The cache set by --hash-cache, or nil.
+var fileHashCache *hashCache
+
+// The hashes of the files scanned so far while --watch waits for changes, or nil.
+var watchHashes *hashCache
+
+// Returns the caches files are looked up in before being hashed, and added to after: the --hash-cache, the
+// checkpoint of the scan in progress and the hashes kept by --watch.
+func activeHashCaches() []*hashCache {
+	var caches []*hashCache
+	if fileHashCache != nil {
+		caches = append(caches, fileHashCache)
+	}
+	if watchHashes != nil {
+		caches = append(caches, watchHashes)
+	}
+	if currentCheckpoint != nil {
+		caches = append(caches, currentCheckpoint.hashes)
+	}
+	return caches
+}
+
+// Loads the hash cache at path, starting an empty one if it doesn't exist yet.
+func loadHashCache(path string) (*hashCache, error) {
+	cache := &hashCache{path: path, entries: make(map[string]hashCacheEntry)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading hash cache: %v", err)
+	}
+	if err := json.Unmarshal(data, &cache.entries); err != nil {
+		return nil, fmt.Errorf("error parsing hash cache: %v", err)
+	}
+	return cache, nil
+}
+
+func (c *hashCache) get(key string, info os.FileInfo) (FileHashes, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || entry.Size != info.Size() || !entry.ModTime.Equal(info.ModTime()) || entry.XXH64 == "" {
+		return FileHashes{}, false
+	}
+	return FileHashes{SHA1: entry.SHA1, MD5: entry.MD5, CRC32: entry.CRC32, XXH64: entry.XXH64}, true
+}
+
+func (c *hashCache) put(key string, info os.FileInfo, fileHashes FileHashes) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = hashCacheEntry{
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		SHA1:    fileHashes.SHA1,
+		MD5:     fileHashes.MD5,
+		CRC32:   fileHashes.CRC32,
+		XXH64:   fileHashes.XXH64,
+	}
+	c.changed = true
+}
+
+// Writes the cache back to disk if the scan hashed anything new.
+func (c *hashCache) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.changed {
+		return nil
+	}
+	if err := checkWritable(c.path); err != nil {
+		return err
+	}
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(c.path, data, 0o644); err != nil {
+		return fmt.Errorf("error writing hash cache: %v", err)
+	}
+	c.changed = false
+	return nil
+}
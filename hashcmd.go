@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	fatihColor "github.com/fatih/color"
+)
+
+// Hashes each file given, or takes each SHA1 given as is, and prints what the database knows of it.
+func runHash(args []string) error {
+	if err := loadCommandDatabase(); err != nil {
+		return err
+	}
+	ctx, release := newScanContext()
+	defer release()
+
+	for _, arg := range args {
+		hash := strings.ToLower(arg)
+		if info, err := os.Stat(arg); err == nil && !info.IsDir() {
+			hashes, err := getFileHashes(ctx, dirFS(filepath.Dir(arg)), filepath.Base(arg))
+			if err != nil {
+				return fmt.Errorf("error hashing %s: %v", arg, scanError(ctx, err))
+			}
+			printHeader(filepath.Base(arg))
+			printInfo(fatihColor.FgWhite, "Size: %d bytes\n", info.Size())
+			printInfo(fatihColor.FgWhite, "SHA1: %s\n", hashes.SHA1)
+			printInfo(fatihColor.FgWhite, "MD5: %s\n", hashes.MD5)
+			printInfo(fatihColor.FgWhite, "CRC32: %s\n", hashes.CRC32)
+			hash = hashes.SHA1
+		} else if isLowerHex(hash, 40) {
+			printHeader(hash)
+		} else {
+			return fmt.Errorf("%s is neither a file nor a SHA1", arg)
+		}
+		if !lookupHash(hash) {
+			printInfo(fatihColor.FgYellow, "Not in the database. If it's a title update or content, please share it with the Pinecone team!\n")
+		}
+		fmt.Println()
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+)
+
+// hashPool hashes a source's files on jobsFlag workers ahead of the walk that needs them. The prefetch walk feeds
+// the workers, waiting whenever they're all busy, so it never runs far ahead of them. Files the walk asks for before
+// they're queued are hashed on the spot.
+type hashPool struct {
+	source *scanSource
+	queued chan queuedHash
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	pending map[string]*pendingHash
+}
+
+type pendingHash struct {
+	done   chan struct{}
+	hashes FileHashes
+	err    error
+}
+
+// queuedHash is a file waiting for a worker.
+type queuedHash struct {
+	name    string
+	quick   bool
+	pending *pendingHash
+}
+
+// Starts a hash pool whose workers stop when ctx is cancelled or the pool is closed.
+func newHashPool(ctx context.Context, source *scanSource, jobs int) *hashPool {
+	ctx, cancel := context.WithCancel(ctx)
+	p := &hashPool{
+		source:  source,
+		queued:  make(chan queuedHash),
+		ctx:     ctx,
+		cancel:  cancel,
+		pending: make(map[string]*pendingHash),
+	}
+	for i := 0; i < jobs; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// Hashes queued files until the pool stops.
+func (p *hashPool) work() {
+	for {
+		select {
+		case job := <-p.queued:
+			job.pending.hashes, job.pending.err = p.compute(p.ctx, job.name, job.quick)
+			close(job.pending.done)
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// Returns the pending hash of a file, and whether the caller is the first to ask for it and should compute it.
+func (p *hashPool) claim(name string, quick bool) (*pendingHash, bool) {
+	key := name
+	if quick {
+		key += "\x00quick"
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pending, ok := p.pending[key]; ok {
+		return pending, false
+	}
+	pending := &pendingHash{done: make(chan struct{})}
+	p.pending[key] = pending
+	return pending, true
+}
+
+// Hashes a file fully, or only with XXH64 when quick is set.
+func (p *hashPool) compute(ctx context.Context, name string, quick bool) (FileHashes, error) {
+	if quick {
+		return getQuickHash(ctx, p.source.FS, name)
+	}
+	return p.source.computeHashes(ctx, name)
+}
+
+// Queues a file to be hashed by the next free worker, waiting until one is free.
+func (p *hashPool) queue(name string, quick bool) {
+	pending, first := p.claim(name, quick)
+	if !first {
+		return
+	}
+	select {
+	case p.queued <- queuedHash{name: name, quick: quick, pending: pending}:
+	case <-p.ctx.Done():
+		pending.err = p.ctx.Err()
+		close(pending.done)
+	}
+}
+
+// Returns the hashes of a file, waiting for its worker if it's queued.
+func (p *hashPool) hash(ctx context.Context, name string, quick bool) (FileHashes, error) {
+	pending, first := p.claim(name, quick)
+	if first {
+		pending.hashes, pending.err = p.compute(ctx, name, quick)
+		close(pending.done)
+	}
+	select {
+	case <-pending.done:
+		return pending.hashes, pending.err
+	case <-ctx.Done():
+		return FileHashes{}, ctx.Err()
+	}
+}
+
+// Stops the workers, abandoning files that are queued or still being hashed.
+func (p *hashPool) close() {
+	p.cancel()
+}
+
+// Queues the updates and DLC files of every known title in TDATA, in the order checkForContent visits them.
+func (p *hashPool) prefetch(directory string) {
+	fs.WalkDir(p.source.FS, directory, func(filePath string, d fs.DirEntry, err error) error {
+		if p.ctx.Err() != nil {
+			return fs.SkipAll
+		}
+		if err != nil {
+			return nil
+		}
+		if isIgnored(filePath) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if path.Dir(filePath) == directory {
+				if _, _, ok := lookupTitle(strings.ToLower(d.Name())); !ok || len(d.Name()) != 8 || !titleSelected(d.Name()) {
+					return fs.SkipDir
+				}
+			}
+			return nil
+		}
+		// Only files under a title's $c and $u folders are hashed during the scan
+		parts := strings.Split(strings.TrimPrefix(filePath, directory+"/"), "/")
+		switch {
+		case len(parts) == 3 && strings.EqualFold(parts[1], "$u") && strings.EqualFold(path.Ext(parts[2]), ".xbe"):
+			p.queue(filePath, false)
+		case len(parts) >= 4 && strings.EqualFold(parts[1], "$c"):
+			titleData, _, _ := lookupTitle(strings.ToLower(parts[0]))
+			p.queue(filePath, !needsFullHashes(titleData, strings.ToLower(parts[2])))
+		}
+		return nil
+	})
+}
+
+// Starts hashing the source's TDATA files on a pool of workers ahead of the walk. The returned function stops it.
+func (source *scanSource) startHashing(ctx context.Context, jobs int) func() {
+	source.hashes = newHashPool(ctx, source, jobs)
+	go source.hashes.prefetch(tdataFolder)
+	return func() {
+		source.hashes.close()
+		source.hashes = nil
+	}
+}
+
+// Returns the hashes of a file in the source, using its hash pool when a scan has one running.
+func (source *scanSource) hash(ctx context.Context, name string) (FileHashes, error) {
+	if source.hashes != nil {
+		return source.hashes.hash(ctx, name, false)
+	}
+	return source.computeHashes(ctx, name)
+}
+
+// Returns only the XXH64 of a file in the source, for --fast-hash.
+func (source *scanSource) quickHash(ctx context.Context, name string) (FileHashes, error) {
+	if source.hashes != nil {
+		return source.hashes.hash(ctx, name, true)
+	}
+	return getQuickHash(ctx, source.FS, name)
+}
+
+// Hashes a file in the source, or takes its hashes from the --hash-cache or the checkpoint of a resumed scan when it
+// hasn't changed since. Files the same size as one already hashed are checked with XXH64 first, and take the other
+// file's hashes if it matches and their bytes turn out the same, which is still cheaper than SHA1, MD5 and CRC32.
+func (source *scanSource) computeHashes(ctx context.Context, name string) (FileHashes, error) {
+	info, err := fs.Stat(source.FS, name)
+	if err != nil {
+		return FileHashes{}, err
+	}
+	key := source.displayPath(name)
+	for _, cache := range activeHashCaches() {
+		if cached, ok := cache.get(key, info); ok {
+			progressHashed(info.Size())
+			return cached, nil
+		}
+	}
+
+	var fileHashes FileHashes
+	if sameSize := identicalFiles.sameSize(info.Size()); len(sameSize) > 0 {
+		quick, err := getQuickHash(ctx, source.FS, name)
+		if err != nil {
+			return FileHashes{}, err
+		}
+		for _, other := range sameSize {
+			if other.hashes.XXH64 != quick.XXH64 {
+				continue
+			}
+			same, err := sameContents(ctx, source.FS, name, other.fsys, other.name)
+			if ctx.Err() != nil {
+				return FileHashes{}, ctx.Err()
+			}
+			if err == nil && same {
+				fileHashes = other.hashes
+				break
+			}
+		}
+	}
+	if fileHashes.SHA1 == "" {
+		fileHashes, err = getFileHashes(ctx, source.FS, name)
+		if err != nil {
+			return FileHashes{}, err
+		}
+		identicalFiles.add(info.Size(), hashedFile{fsys: source.FS, name: name, hashes: fileHashes})
+	}
+
+	for _, cache := range activeHashCaches() {
+		cache.put(key, info, fileHashes)
+	}
+	return fileHashes, nil
+}
+
+// identicalFileIndex remembers the hashes of the files seen so far by size, so copies of a file can be spotted
+// with XXH64 instead of spending time on SHA1.
+type identicalFileIndex struct {
+	mu     sync.Mutex
+	bySize map[int64][]hashedFile
+}
+
+// hashedFile is a file in the index, with where to find it to compare another against.
+type hashedFile struct {
+	fsys   fs.FS
+	name   string
+	hashes FileHashes
+}
+
+var identicalFiles = newIdenticalFileIndex()
+
+func newIdenticalFileIndex() *identicalFileIndex {
+	return &identicalFileIndex{bySize: make(map[int64][]hashedFile)}
+}
+
+func (index *identicalFileIndex) sameSize(size int64) []hashedFile {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	return index.bySize[size]
+}
+
+func (index *identicalFileIndex) add(size int64, file hashedFile) {
+	index.mu.Lock()
+	defer index.mu.Unlock()
+	index.bySize[size] = append(index.bySize[size], file)
+}
+
+// Reports whether the file name in fsys holds the same bytes as otherName in otherFS.
+func sameContents(ctx context.Context, fsys fs.FS, name string, otherFS fs.FS, otherName string) (bool, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	other, err := otherFS.Open(otherName)
+	if err != nil {
+		return false, err
+	}
+	defer other.Close()
+
+	buf, otherBuf := make([]byte, hashReadSize()), make([]byte, hashReadSize())
+	for {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		n, err := io.ReadFull(file, buf)
+		otherN, otherErr := io.ReadFull(other, otherBuf)
+		if n != otherN || !bytes.Equal(buf[:n], otherBuf[:otherN]) {
+			return false, nil
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return otherErr == io.EOF || otherErr == io.ErrUnexpectedEOF, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if otherErr != nil {
+			return false, otherErr
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2/theme"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const historySchema = `
+CREATE TABLE IF NOT EXISTS scan_runs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	scanned_at TEXT NOT NULL,
+	version TEXT NOT NULL,
+	location TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS titles (
+	run_id INTEGER NOT NULL REFERENCES scan_runs(id),
+	title_id TEXT NOT NULL,
+	title_name TEXT,
+	known INTEGER NOT NULL,
+	path TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS content (
+	run_id INTEGER NOT NULL REFERENCES scan_runs(id),
+	title_id TEXT NOT NULL,
+	content_id TEXT NOT NULL,
+	name TEXT,
+	path TEXT NOT NULL,
+	known INTEGER NOT NULL,
+	archived INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS updates (
+	run_id INTEGER NOT NULL REFERENCES scan_runs(id),
+	title_id TEXT NOT NULL,
+	name TEXT,
+	path TEXT NOT NULL,
+	sha1 TEXT,
+	size INTEGER,
+	known INTEGER NOT NULL,
+	archived INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_content_run ON content(run_id);
+CREATE INDEX IF NOT EXISTS idx_updates_run ON updates(run_id);
+`
+
+// historyTotals are the per-run numbers used to show trends between scans.
+type historyTotals struct {
+	ScannedAt  string
+	Items      int
+	Unarchived int
+}
+
+func openHistory(dbPath string) (*sql.DB, error) {
+	if err := checkWritable(dbPath); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(historySchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	// The database revisions of each run were added after the first scans were recorded
+	if _, err := db.Exec("ALTER TABLE scan_runs ADD COLUMN databases TEXT"); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Stores a scan report as a new run and returns its id.
+func saveHistory(db *sql.DB, report *ScanReport, scannedAt time.Time) (int64, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var databases []string
+	for _, database := range report.Databases {
+		databases = append(databases, database.String())
+	}
+	result, err := tx.Exec("INSERT INTO scan_runs (scanned_at, version, location, databases) VALUES (?, ?, ?, ?)",
+		scannedAt.UTC().Format(time.RFC3339), report.Version, report.Location, strings.Join(databases, "\n"))
+	if err != nil {
+		return 0, err
+	}
+	runID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, title := range report.Titles {
+		_, err = tx.Exec("INSERT INTO titles (run_id, title_id, title_name, known, path) VALUES (?, ?, ?, ?, ?)",
+			runID, title.TitleID, title.TitleName, title.Known, title.Path)
+		if err != nil {
+			return 0, err
+		}
+		for _, content := range title.Content {
+			_, err = tx.Exec("INSERT INTO content (run_id, title_id, content_id, name, path, known, archived) VALUES (?, ?, ?, ?, ?, ?, ?)",
+				runID, title.TitleID, content.ContentID, content.Name, content.Path, content.Known, content.Archived)
+			if err != nil {
+				return 0, err
+			}
+		}
+		for _, update := range title.Updates {
+			_, err = tx.Exec("INSERT INTO updates (run_id, title_id, name, path, sha1, size, known, archived) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+				runID, title.TitleID, update.Name, update.Path, update.SHA1, update.Size, update.Known, update.Archived)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return runID, tx.Commit()
+}
+
+func loadHistoryTotals(db *sql.DB, runID int64) (historyTotals, error) {
+	var totals historyTotals
+	err := db.QueryRow(`SELECT r.scanned_at,
+		(SELECT COUNT(*) FROM content WHERE run_id = r.id) + (SELECT COUNT(*) FROM updates WHERE run_id = r.id),
+		(SELECT COUNT(*) FROM content WHERE run_id = r.id AND archived = 0) + (SELECT COUNT(*) FROM updates WHERE run_id = r.id AND archived = 0)
+		FROM scan_runs r WHERE r.id = ?`, runID).Scan(&totals.ScannedAt, &totals.Items, &totals.Unarchived)
+	return totals, err
+}
+
+// Returns the id of the most recent run of the same location before runID, or 0 if there is none.
+func previousHistoryRun(db *sql.DB, runID int64, location string) (int64, error) {
+	var previous int64
+	err := db.QueryRow("SELECT id FROM scan_runs WHERE location = ? AND id < ? ORDER BY id DESC LIMIT 1", location, runID).Scan(&previous)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return previous, err
+}
+
+// Records the last scan into the history database and prints the trend since the previous run.
+func recordHistory(dbPath string, report *ScanReport) error {
+	db, err := openHistory(dbPath)
+	if err != nil {
+		return fmt.Errorf("error opening history database: %v", err)
+	}
+	defer db.Close()
+
+	runID, err := saveHistory(db, report, time.Now())
+	if err != nil {
+		return fmt.Errorf("error saving scan history: %v", err)
+	}
+	current, err := loadHistoryTotals(db, runID)
+	if err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Scan saved to history as run %d: %d items, %d unarchived", runID, current.Items, current.Unarchived)
+	previousID, err := previousHistoryRun(db, runID, report.Location)
+	if err != nil {
+		return err
+	}
+	if previousID != 0 {
+		previous, err := loadHistoryTotals(db, previousID)
+		if err != nil {
+			return err
+		}
+		message += fmt.Sprintf(" (%+d items, %+d unarchived since %s)", current.Items-previous.Items, current.Unarchived-previous.Unarchived, previous.ScannedAt)
+	}
+
+	if guiEnabled {
+		addText(theme.ForegroundColor(), message)
+	}
+	fmt.Println(message)
+	return nil
+}
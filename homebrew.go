@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+const softwareTypeHomebrew = "homebrew"
+
+// HomebrewReport is a homebrew title found in TDATA. Homebrew is kept out of Titles so it doesn't count towards archived and unarchived retail content.
+type HomebrewReport struct {
+	TitleID   string `json:"titleID" xml:"titleID,attr"`
+	TitleName string `json:"titleName" xml:"titleName"`
+	Path      string `json:"path" xml:"path"`
+	Source    string `json:"source,omitempty" xml:"source,attr,omitempty"`
+}
+
+// Returns the homebrew title and version an XBE hash is listed under, if any.
+func homebrewByHash(hash string) (string, string, bool) {
+	xbe, ok := databaseIndex.homebrewXBEs[hash]
+	return xbe.titleID, xbe.id, ok
+}
+
+// Identifies a dashboard or app XBE as homebrew, by hash or by its certificate title ID.
+func identifyHomebrew(xbe *XBEInfo, hash string, software *SoftwareReport) bool {
+	titleID, version, ok := homebrewByHash(hash)
+	if ok {
+		software.Version = version
+		software.Known = true
+	} else {
+		titleID = xbe.TitleID
+		if _, ok := titles.Homebrew[titleID]; !ok {
+			return false
+		}
+		software.Version = fmt.Sprintf("XBE version %d", xbe.Version)
+	}
+	software.Name = titles.Homebrew[titleID].TitleName
+	software.Type = softwareTypeHomebrew
+	return true
+}
+
+// Records a TDATA folder belonging to a homebrew title.
+func recordHomebrew(source *scanSource, titleID string, reportedPath string, filePath string) {
+	data := titles.Homebrew[titleID]
+	if guiEnabled {
+		addText(theme.PrimaryColorNamed(theme.ColorBlue), "Homebrew: %s (%s) at %s", data.TitleName, titleID, source.displayPath(filePath))
+	}
+	printInfo(fatihColor.FgBlue, "Homebrew: %s (%s) at %s\n", data.TitleName, titleID, source.displayPath(filePath))
+	scanResults.Homebrew = append(scanResults.Homebrew, HomebrewReport{
+		TitleID:   titleID,
+		TitleName: data.TitleName,
+		Path:      reportedPath,
+		Source:    source.reportName(),
+	})
+}
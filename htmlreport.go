@@ -0,0 +1,112 @@
+package main
+
+import (
+	"html/template"
+	"os"
+)
+
+type htmlReportData struct {
+	Report     *ScanReport
+	Items      []ReportItem
+	Archived   int
+	Unarchived int
+	Unknown    int
+	Damaged    int
+	// Insignia counts the content downloaded from the Insignia revival, which isn't in the counts above
+	Insignia int
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Pinecone Report</title>
+<style>
+body { font-family: sans-serif; background: #1e1e1e; color: #ddd; margin: 2em; }
+h1 { color: #008b8b; }
+table { border-collapse: collapse; width: 100%; }
+th, td { padding: 4px 8px; border-bottom: 1px solid #444; text-align: left; font-size: 0.9em; }
+th { cursor: pointer; background: #2d2d2d; user-select: none; }
+th:hover { background: #3d3d3d; }
+td.hash { font-family: monospace; }
+tr.archived td.status { color: #4caf50; }
+tr.unarchived td.status { color: #ffc107; }
+tr.unknown td.status { color: #f44336; }
+.summary span { margin-right: 2em; }
+</style>
+</head>
+<body>
+<h1>Pinecone v{{.Report.Version}} Report</h1>
+<p>Location: {{.Report.Location}}</p>
+{{range .Report.Databases}}<p>Database: {{.}}</p>
+{{end}}<p class="summary">
+<span>Items: {{len .Items}}</span>
+<span>Archived: {{.Archived}}</span>
+<span>Unarchived: {{.Unarchived}}</span>
+{{if .Insignia}}<span>Insignia: {{.Insignia}}</span>
+{{end}}
+<span>Unknown: {{.Unknown}}</span>
+<span>Damaged: {{.Damaged}}</span>
+</p>
+<table id="items">
+<thead>
+<tr><th>Title ID</th><th>Title Name</th><th>Type</th><th>Content ID</th><th>Name</th><th>Path</th><th>SHA1</th><th>Status</th><th>Source</th></tr>
+</thead>
+<tbody>
+{{- range .Items}}
+<tr class="{{.Status}}"><td>{{.TitleID}}</td><td>{{.TitleName}}</td><td>{{.Type}}</td><td>{{.ContentID}}</td><td>{{.Name}}</td><td>{{.Path}}</td><td class="hash">{{.SHA1}}</td><td class="status">{{.Status}}</td><td>{{.Source}}</td></tr>
+{{- end}}
+</tbody>
+</table>
+<script>
+document.querySelectorAll("#items th").forEach(function (th, column) {
+	th.addEventListener("click", function () {
+		var tbody = document.querySelector("#items tbody");
+		var rows = Array.from(tbody.rows);
+		var ascending = th.dataset.order !== "asc";
+		rows.sort(function (a, b) {
+			var x = a.cells[column].textContent, y = b.cells[column].textContent;
+			return ascending ? x.localeCompare(y) : y.localeCompare(x);
+		});
+		th.dataset.order = ascending ? "asc" : "desc";
+		rows.forEach(function (row) { tbody.appendChild(row); });
+	});
+});
+</script>
+</body>
+</html>
+`))
+
+func writeHTMLReport(outputPath string, report *ScanReport) error {
+	if err := checkWritable(outputPath); err != nil {
+		return err
+	}
+	data := htmlReportData{Report: report, Items: reportItems(report)}
+	for _, item := range data.Items {
+		if item.Insignia {
+			data.Insignia++
+			continue
+		}
+		switch item.Status() {
+		case statusArchived:
+			data.Archived++
+		case statusUnarchived:
+			data.Unarchived++
+		case xbeTruncated, xbeCorrupted, statusKnownBad:
+			data.Damaged++
+		default:
+			data.Unknown++
+		}
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := htmlReportTemplate.Execute(file, data); err != nil {
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Attempts made at a download before giving up, and the wait before the first retry, which doubles after each.
+const (
+	downloadAttempts   = 4
+	downloadRetryDelay = 2 * time.Second
+	// Longest a server's Retry-After is waited for
+	downloadMaxRetryDelay = time.Minute
+)
+
+// The client downloads go through, built on first use from --proxy and --ca-bundle.
+var downloadClient *http.Client
+
+// Returns the client for downloads. Without --proxy it honours HTTP_PROXY, HTTPS_PROXY and NO_PROXY; --ca-bundle adds
+// the certificates of a PEM file to the system's, for networks that intercept TLS with their own CA.
+func httpClient() (*http.Client, error) {
+	if downloadClient != nil {
+		return downloadClient, nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if proxyFlag != "" {
+		proxy, err := url.Parse(proxyFlag)
+		if err != nil || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", proxyFlag)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if caBundleFlag != "" {
+		pem, err := os.ReadFile(caBundleFlag)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA bundle: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in %s", caBundleFlag)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+	downloadClient = &http.Client{Transport: transport}
+	return downloadClient, nil
+}
+
+// Reports whether a failed download may work if tried again: the connection failed, the server had an error, or a
+// server other than GitHub, whose rate limit is handled by falling back to raw downloads, asked to slow down.
+func retryableResponse(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= 500 || (resp.StatusCode == http.StatusTooManyRequests && !isGitHubHost(resp.Request.URL.Host))
+}
+
+// Sends req, retrying with backoff while it fails in a way that may not last.
+func doDownloadRequest(req *http.Request) (*http.Response, error) {
+	client, err := httpClient()
+	if err != nil {
+		return nil, err
+	}
+	delay := downloadRetryDelay
+	for attempt := 1; ; attempt++ {
+		resp, err := client.Do(req)
+		if attempt == downloadAttempts || !retryableResponse(resp, err) {
+			return resp, err
+		}
+		wait := delay
+		reason := ""
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = resp.Status
+			if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+				wait = min(time.Duration(seconds)*time.Second, downloadMaxRetryDelay)
+			}
+			resp.Body.Close()
+		}
+		printDatabaseStatus("Downloading %s failed (%s), retrying in %s", req.URL.Redacted(), reason, wait)
+		time.Sleep(wait)
+		delay *= 2
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// ignoreRule is a line of an ignore list. Rules are matched against paths relative to TDATA or UDATA, such as
+// 4d530004/$c/4d53000400000001/contentmeta.xbx:
+//
+//	*.tmp              a pattern without a slash matches the name of any file or folder
+//	4d530004/$u        a pattern with a slash matches the path, or a folder above it
+//	title:4d530004     skips every folder of the titles the ID or wildcard matches
+//	# a comment
+type ignoreRule struct {
+	pattern string
+	// titleID is set for title: rules, which pattern is empty for
+	titleID string
+}
+
+var (
+	ignoreFileFlag string
+	ignoreFlag     patternList
+	// The rules of --ignore-file and --ignore
+	ignoreRules []ignoreRule
+)
+
+// patternList collects every --ignore given. Patterns can hold commas, so each is taken whole.
+type patternList []string
+
+func (patterns *patternList) String() string {
+	return strings.Join(*patterns, " ")
+}
+
+func (patterns *patternList) Set(value string) error {
+	if value = strings.TrimSpace(value); value != "" {
+		*patterns = append(*patterns, value)
+	}
+	return nil
+}
+
+// Parses a line of an ignore list, returning false for blank lines and comments.
+func parseIgnoreRule(line string) (ignoreRule, bool, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false, nil
+	}
+	if titleID, ok := strings.CutPrefix(line, "title:"); ok {
+		titleID = strings.ToLower(strings.TrimSpace(titleID))
+		if _, err := path.Match(titleID, ""); err != nil || titleID == "" {
+			return ignoreRule{}, false, fmt.Errorf("bad title ID %q in ignore rule %q", titleID, line)
+		}
+		return ignoreRule{titleID: titleID}, true, nil
+	}
+	// FATX ignores case, and so do the rules
+	pattern := strings.ToLower(strings.Trim(strings.ReplaceAll(line, "\\", "/"), "/"))
+	if _, err := path.Match(pattern, ""); err != nil {
+		return ignoreRule{}, false, fmt.Errorf("bad pattern in ignore rule %q: %v", line, err)
+	}
+	return ignoreRule{pattern: pattern}, true, nil
+}
+
+// Loads an ignore list: a rule per line, or the JSON array of exact names older versions took.
+func loadIgnoreList(filepath string) ([]ignoreRule, error) {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		if err := json.Unmarshal(data, &lines); err != nil {
+			return nil, err
+		}
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+	}
+
+	var rules []ignoreRule
+	for i, line := range lines {
+		rule, ok, err := parseIgnoreRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", filepath, i+1, err)
+		}
+		if ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+// Loads the rules of --ignore-file and --ignore, before the scan starts.
+func loadIgnoreRules() error {
+	ignoreRules = nil
+	if ignoreFileFlag != "" {
+		rules, err := loadIgnoreList(ignoreFileFlag)
+		if err != nil {
+			return fmt.Errorf("error loading ignore file: %v", err)
+		}
+		ignoreRules = rules
+	}
+	for _, line := range ignoreFlag {
+		rule, ok, err := parseIgnoreRule(line)
+		if err != nil {
+			return err
+		}
+		if ok {
+			ignoreRules = append(ignoreRules, rule)
+		}
+	}
+	return nil
+}
+
+// Reports whether a file or folder of a source, given by its slash separated path such as
+// TDATA/4d530004/$c/4d53000400000001, is skipped by the ignore rules.
+func isIgnored(filePath string) bool {
+	if len(ignoreRules) == 0 {
+		return false
+	}
+	parts := strings.Split(strings.ToLower(filePath), "/")
+	if strings.EqualFold(parts[0], tdataFolder) || strings.EqualFold(parts[0], "UDATA") {
+		parts = parts[1:]
+	}
+	if len(parts) == 0 || parts[0] == "" {
+		return false
+	}
+	titleID := parts[0]
+	for _, rule := range ignoreRules {
+		if rule.titleID != "" {
+			if matched, _ := path.Match(rule.titleID, titleID); matched {
+				return true
+			}
+			continue
+		}
+		if !strings.Contains(rule.pattern, "/") {
+			for _, part := range parts {
+				if matched, _ := path.Match(rule.pattern, part); matched {
+					return true
+				}
+			}
+			continue
+		}
+		for i := range parts {
+			if matched, _ := path.Match(rule.pattern, strings.Join(parts[:i+1], "/")); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
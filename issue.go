@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+// Where new issues are opened for the project, and the label drafts ask for.
+const (
+	newIssueURL = "https://github.com/Xbox-Preservation-Project/Pinecone/issues/new"
+	issueLabel  = "new content"
+)
+
+// Longest issue URL opened in the browser. Longer ones are refused by GitHub, so the body is cut short.
+const maxIssueURLLength = 8000
+
+// Returns the title of an issue reporting items, naming the first title they belong to.
+func issueTitle(items []BundleItem) string {
+	titleIDs := make(map[string]bool)
+	for _, item := range items {
+		titleIDs[item.TitleID] = true
+	}
+	first := items[0]
+	name := first.TitleName
+	if name == "" {
+		name = "Unknown title"
+	}
+	title := fmt.Sprintf("Unarchived content found: %s (%s)", name, first.TitleID)
+	if len(titleIDs) > 1 {
+		title += fmt.Sprintf(" and %d more titles", len(titleIDs)-1)
+	}
+	return title
+}
+
+// Returns the table row of an item.
+func issueRow(item BundleItem) string {
+	id := item.ContentID
+	sha1 := ""
+	if len(item.Files) == 1 {
+		sha1 = item.Files[0].SHA1
+	}
+	if item.Type == bundleItemUnknownTitle {
+		id = fmt.Sprintf("%d files", len(item.Files))
+	}
+	return fmt.Sprintf("| %s | %s | %s | %s | %s | `%s` | %s |\n", item.Type, item.TitleID, mdCell(item.TitleName), id, mdCell(item.Name), mdCell(item.Path), sha1)
+}
+
+// Renders the body of an issue reporting items, listing at most limit of them, or all if limit is negative.
+func issueBody(report *ScanReport, items []BundleItem, limit int) string {
+	var sb strings.Builder
+	sb.WriteString("Pinecone found content that isn't archived yet.\n\n")
+	fmt.Fprintf(&sb, "- Pinecone v%s\n", report.Version)
+	for _, database := range report.Databases {
+		fmt.Fprintf(&sb, "- Database: %s\n", database)
+	}
+	sb.WriteString("\n| Type | Title ID | Title | Content ID | Name | Path | SHA1 |\n")
+	sb.WriteString("|---|---|---|---|---|---|---|\n")
+	for i, item := range items {
+		if limit >= 0 && i == limit {
+			fmt.Fprintf(&sb, "\n%d more items didn't fit here, paste the rest of the issue draft Pinecone wrote with --issue.\n", len(items)-limit)
+			break
+		}
+		sb.WriteString(issueRow(item))
+	}
+	sb.WriteString("\nThe files can be attached as a `--bundle` zip, or shared on request.\n")
+	return sb.String()
+}
+
+// Returns the URL of a new issue pre-filled with the title, label and as much of the body as fits.
+func issueURL(report *ScanReport, items []BundleItem) string {
+	build := func(body string) string {
+		query := url.Values{"title": {issueTitle(items)}, "labels": {issueLabel}, "body": {body}}
+		return newIssueURL + "?" + query.Encode()
+	}
+	u := build(issueBody(report, items, -1))
+	for limit := len(items) - 1; len(u) > maxIssueURLLength && limit >= 0; limit-- {
+		u = build(issueBody(report, items, limit))
+	}
+	return u
+}
+
+// Opens u in the default browser.
+func openBrowser(u string) error {
+	switch runtime.GOOS {
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", u).Start()
+	case "darwin":
+		return exec.Command("open", u).Start()
+	default:
+		return exec.Command("xdg-open", u).Start()
+	}
+}
+
+// Writes a GitHub issue draft reporting the unarchived and unknown content of a scan to issuePath, if given, and
+// opens a pre-filled new issue in the browser if open is set.
+func draftIssue(issuePath string, open bool, report *ScanReport) error {
+	items := bundleItems(report)
+	if len(items) == 0 {
+		if guiEnabled {
+			addText(theme.ForegroundColor(), "Nothing unarchived to report")
+		}
+		printInfo(fatihColor.FgWhite, "Nothing unarchived to report\n")
+		return nil
+	}
+	if issuePath != "" {
+		if err := checkWritable(issuePath); err != nil {
+			return err
+		}
+		draft := fmt.Sprintf("<!-- Title: %s -->\n<!-- Label: %s -->\n\n%s", issueTitle(items), issueLabel, issueBody(report, items, -1))
+		if err := os.WriteFile(issuePath, []byte(draft), 0o644); err != nil {
+			return err
+		}
+		if guiEnabled {
+			addText(theme.ForegroundColor(), "Issue draft saved to: %s, open one at %s", issuePath, newIssueURL)
+		}
+		printInfo(fatihColor.FgWhite, "Issue draft saved to: %s, open one at %s\n", issuePath, newIssueURL)
+	}
+	if !open {
+		return nil
+	}
+	u := issueURL(report, items)
+	if err := openBrowser(u); err != nil {
+		return fmt.Errorf("error opening the browser, open this URL instead: %s", u)
+	}
+	if guiEnabled {
+		addText(theme.ForegroundColor(), "New issue opened in the browser, review it before submitting")
+	}
+	printInfo(fatihColor.FgWhite, "New issue opened in the browser, review it before submitting\n")
+	return nil
+}
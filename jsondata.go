@@ -1,104 +1,407 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"crypto/sha1"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
-	"regexp"
 
 	"fyne.io/fyne/v2/theme"
 )
 
-func removeCommentsFromJSON(jsonStr string) string {
-	// remove // style comments
-	re := regexp.MustCompile(`(?m)^[ \t]*//.*\n?`)
-	jsonStr = re.ReplaceAllString(jsonStr, "")
+// commentReader strips the // line comments and /* */ block comments the database may carry as it's read, so the
+// file never has to be held in memory whole.
+type commentReader struct {
+	r         *bufio.Reader
+	lineStart bool
+	inBlock   bool
+}
 
-	// remove /* ... */ style comments
-	re = regexp.MustCompile(`/\*[\s\S]*?\*/`)
-	jsonStr = re.ReplaceAllString(jsonStr, "")
+func newCommentReader(r io.Reader) *commentReader {
+	return &commentReader{r: bufio.NewReader(r), lineStart: true}
+}
 
-	return jsonStr
+// Reports whether the next byte is b, consuming it if so.
+func (c *commentReader) skipNext(b byte) bool {
+	next, err := c.r.Peek(1)
+	if err != nil || next[0] != b {
+		return false
+	}
+	c.r.Discard(1)
+	return true
 }
 
-func downloadJSONData(url string) ([]byte, error) {
-	req, err := http.NewRequest("GET", url, nil)
+func (c *commentReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+		if c.inBlock {
+			c.inBlock = !(b == '*' && c.skipNext('/'))
+			continue
+		}
+		if b == '/' && c.skipNext('*') {
+			c.inBlock = true
+			continue
+		}
+		// Only whole line comments are stripped, since // also appears in URLs
+		if b == '/' && c.lineStart && c.skipNext('/') {
+			for {
+				if _, err := c.r.ReadSlice('\n'); err != bufio.ErrBufferFull {
+					break
+				}
+			}
+			continue
+		}
+		if b == '\n' {
+			c.lineStart = true
+		} else if b != ' ' && b != '\t' {
+			c.lineStart = false
+		}
+		p[n] = b
+		n++
+	}
+	return n, nil
+}
+
+// stringInterner hands out one shared copy of each string, for the names and paths repeated across titles.
+type stringInterner map[string]string
+
+func (strs stringInterner) intern(s string) string {
+	if interned, ok := strs[s]; ok {
+		return interned
+	}
+	strs[s] = s
+	return s
+}
+
+func (strs stringInterner) internValues(m map[string]string) {
+	for key, value := range m {
+		m[key] = strs.intern(value)
+	}
+}
+
+// Interns the update names, content names, content types and content file paths of a title.
+func (strs stringInterner) internTitle(data *TitleData) {
+	for _, known := range data.TitleUpdatesKnown {
+		strs.internValues(known)
+	}
+	for _, archived := range data.Archived {
+		strs.internValues(archived)
+	}
+	strs.internValues(data.ContentTypes)
+	for contentID, files := range data.ContentFiles {
+		interned := make(map[string]string, len(files))
+		for name, hash := range files {
+			interned[strs.intern(name)] = hash
+		}
+		data.ContentFiles[contentID] = interned
+	}
+}
+
+// Checks that the next token is the given delimiter.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
 	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, found %v", delim, token)
+	}
+	return nil
+}
+
+// Decodes a section of titles one title at a time.
+func decodeTitleSection(dec *json.Decoder, strs stringInterner) (map[string]TitleData, error) {
+	if err := expectDelim(dec, '{'); err != nil {
 		return nil, err
 	}
+	section := make(map[string]TitleData)
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		titleID, _ := token.(string)
+		var data TitleData
+		if err := dec.Decode(&data); err != nil {
+			return nil, fmt.Errorf("error decoding title %s: %v", titleID, err)
+		}
+		strs.internTitle(&data)
+		section[titleID] = data
+	}
+	return section, expectDelim(dec, '}')
+}
+
+// Streams the title database from r into list, decoding one title at a time instead of the whole file at once.
+func decodeTitleList(r io.Reader, list *TitleList) error {
+	return decodeTitleListWith(r, list, make(stringInterner))
+}
+
+// Decodes like decodeTitleList, sharing strs with other files of the same database.
+func decodeTitleListWith(r io.Reader, list *TitleList, strs stringInterner) error {
+	*list = TitleList{}
+	dec := json.NewDecoder(newCommentReader(r))
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch section, _ := token.(string); section {
+		case "Titles":
+			list.Titles, err = decodeTitleSection(dec, strs)
+		case "Chihiro":
+			list.Chihiro, err = decodeTitleSection(dec, strs)
+		case "Debug":
+			list.Debug, err = decodeTitleSection(dec, strs)
+		case "Software":
+			err = dec.Decode(&list.Software)
+		case "Homebrew":
+			err = dec.Decode(&list.Homebrew)
+		case "Known Bad":
+			err = dec.Decode(&list.KnownBad)
+		case "Version":
+			err = dec.Decode(&list.Version)
+		default:
+			var skipped json.RawMessage
+			err = dec.Decode(&skipped)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, '}')
+}
+
+// downloadState is what the server said about the last download of a file, kept next to it so the next update only
+// asks for the file if it changed.
+type downloadState struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	SHA1         string `json:"sha1"`
+}
+
+// Returns where the download state of a file is kept.
+func downloadStatePath(filePath string) string {
+	return filePath + ".etag"
+}
+
+// Returns the download state of a local file, or an empty one if the file was edited since it was downloaded, so an
+// edited file isn't kept just because the server hasn't changed its copy.
+func readDownloadState(filePath string) downloadState {
+	var state downloadState
+	data, err := os.ReadFile(downloadStatePath(filePath))
+	if err != nil || json.Unmarshal(data, &state) != nil {
+		return downloadState{}
+	}
+	existingData, err := os.ReadFile(filePath)
+	if err != nil || fmt.Sprintf("%x", sha1.Sum(existingData)) != state.SHA1 {
+		return downloadState{}
+	}
+	return state
+}
+
+func writeDownloadState(filePath string, state downloadState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := checkWritable(downloadStatePath(filePath)); err != nil {
+		return err
+	}
+	return os.WriteFile(downloadStatePath(filePath), data, 0o644)
+}
+
+// Returned when a file to download doesn't exist upstream.
+var errDownloadNotFound = errors.New("not found")
+
+// Downloads url unless it hasn't changed since the download described by state, in which case the data returned is
+// nil. Files the GitHub API fails to give, because its rate limit is used up or it's blocked, are downloaded from
+// raw.githubusercontent.com instead.
+func downloadJSONData(url string, state downloadState) ([]byte, downloadState, error) {
+	// A bad --proxy or --ca-bundle fails every download the same way, so no fallback is tried for it
+	if _, err := httpClient(); err != nil {
+		return nil, downloadState{}, err
+	}
+	data, newState, err := downloadFile(url, state)
+	if err == nil || errors.Is(err, errDownloadNotFound) {
+		return data, newState, err
+	}
+	raw := rawGitHubURL(url)
+	if raw == "" {
+		return nil, downloadState{}, err
+	}
+	printDatabaseStatus("%v, trying %s", err, raw)
+	return downloadFile(raw, state)
+}
+
+func downloadFile(url string, state downloadState) ([]byte, downloadState, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, downloadState{}, err
+	}
 	req.Header.Set("Accept", "application/vnd.github.v3.raw")
+	authorizeGitHub(req)
+	if state.ETag != "" {
+		req.Header.Set("If-None-Match", state.ETag)
+	}
+	if state.LastModified != "" {
+		req.Header.Set("If-Modified-Since", state.LastModified)
+	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := doDownloadRequest(req)
 	if err != nil {
-		return nil, err
+		return nil, downloadState{}, err
 	}
 	defer resp.Body.Close()
 
-	return io.ReadAll(resp.Body)
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, state, nil
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, downloadState{}, fmt.Errorf("error downloading %s: %w", url, errDownloadNotFound)
+	default:
+		if err := githubResponseError(resp); err != nil {
+			return nil, downloadState{}, fmt.Errorf("error downloading %s: %w", url, err)
+		}
+		return nil, downloadState{}, fmt.Errorf("error downloading %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, downloadState{}, err
+	}
+	state = downloadState{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		SHA1:         fmt.Sprintf("%x", sha1.Sum(data)),
+	}
+	return data, state, nil
+}
+
+// Returns the GitHub API URL of a file in a repository.
+func githubContentsURL(owner, repo, path string) string {
+	return fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s", owner, repo, path)
 }
 
-func loadJSONData(jsonFilePath, owner, repo, path string, v interface{}, updateFlag bool) error {
+func loadJSONData(source databaseSource, list *TitleList, updateFlag bool) error {
 	if updateFlag {
 
 		// Notify we're checking for updates
-		fmt.Printf("Checking for PineCone updates..\n")
-
-		// Download JSON data
-		jsonData, err := downloadJSONData(fmt.Sprintf("https://api.github.com/repos/%s/%s/contents/%s", owner, repo, path))
-		if err != nil {
-			return err
-		}
+		printLine("Checking for PineCone updates..")
 
-		// Check if downloaded JSON is different from existing JSON
-		if _, err := os.Stat(jsonFilePath); err == nil {
-			existingData, err := os.ReadFile(jsonFilePath)
-			if err != nil {
-				return err
+		// Mirrors are tried in order until one of them answers
+		var err error
+		for i, url := range source.urls {
+			if i > 0 {
+				printDatabaseStatus("%v, trying %s", err, url)
 			}
-			existingHash := fmt.Sprintf("%x", sha1.Sum(existingData))
-			newHash := fmt.Sprintf("%x", sha1.Sum(jsonData))
-			if existingHash == newHash {
-				return json.Unmarshal(existingData, &v)
+			if err = updateJSONData(source.path, url, list, source.verify); err == nil {
+				return nil
 			}
 		}
-
-		// Write the newly downloaded JSON to file
-		if guiEnabled {
-			addText(theme.ForegroundColor(), "Updating %s...", jsonFilePath)
-		} else {
-			fmt.Printf("Updating %s...\n", jsonFilePath)
-		}
-		err = os.WriteFile(jsonFilePath, jsonData, 0o644)
+		return err
+	} else if isShardedDatabase(source.path) {
+		return loadShardedDatabase(shardDir(source.path), list)
+	} else {
+		// Load existing JSON data
+		jsonFile, err := os.Open(source.path)
 		if err != nil {
 			return err
 		}
-
-		// Load the newly downloaded JSON data
-		if guiEnabled {
-			addText(theme.ForegroundColor(), "Reloading %s...", path)
-		} else {
-			fmt.Printf("Reloading %s...\n", path)
-		}
-		jsonStr := removeCommentsFromJSON(string(jsonData))
-		err = json.Unmarshal([]byte(jsonStr), &v)
+		defer jsonFile.Close()
+		err = decodeTitleList(jsonFile, list)
 		if err != nil {
 			return err
 		}
-	} else {
-		// Load existing JSON data
-		jsonData, err := os.ReadFile(jsonFilePath)
-		if err != nil {
+	}
+
+	return nil
+}
+
+// Updates the local database at jsonFilePath from url and loads it into list, checking its signature if verify is set.
+func updateJSONData(jsonFilePath, url string, list *TitleList, verify bool) error {
+	// Databases split into shards only fetch the shards that changed
+	sharded, err := updateShardedDatabase(shardDir(jsonFilePath), url, verify)
+	if err != nil {
+		return err
+	}
+	if sharded {
+		return loadShardedDatabase(shardDir(jsonFilePath), list)
+	}
+
+	// Download JSON data
+	jsonData, state, err := downloadJSONData(url, readDownloadState(jsonFilePath))
+	if err != nil {
+		return err
+	}
+
+	// The server says the database hasn't changed since it was downloaded
+	if jsonData == nil {
+		printDatabaseStatus("%s is up to date", jsonFilePath)
+		return loadJSONData(databaseSource{path: jsonFilePath}, list, false)
+	}
+
+	if verify {
+		if err := verifyDownloadSignature(url, jsonData); err != nil {
 			return err
 		}
-		jsonStr := removeCommentsFromJSON(string(jsonData))
-		err = json.Unmarshal([]byte(jsonStr), &v)
+	}
+
+	// Check if downloaded JSON is different from existing JSON
+	if _, err := os.Stat(jsonFilePath); err == nil {
+		existingData, err := os.ReadFile(jsonFilePath)
 		if err != nil {
 			return err
 		}
+		existingHash := fmt.Sprintf("%x", sha1.Sum(existingData))
+		if existingHash == state.SHA1 {
+			printDatabaseStatus("%s is up to date", jsonFilePath)
+			if err := writeDownloadState(jsonFilePath, state); err != nil {
+				return err
+			}
+			return decodeTitleList(bytes.NewReader(existingData), list)
+		}
 	}
 
-	return nil
+	// Write the newly downloaded JSON to file
+	if guiEnabled {
+		addText(theme.ForegroundColor(), "Updating %s...", jsonFilePath)
+	} else {
+		printLine("Updating %s...", jsonFilePath)
+	}
+	if err := checkWritable(jsonFilePath); err != nil {
+		return err
+	}
+	if err := keepPreviousDatabase(jsonFilePath); err != nil {
+		return err
+	}
+	err = os.WriteFile(jsonFilePath, jsonData, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := writeDownloadState(jsonFilePath, state); err != nil {
+		return err
+	}
+
+	// Load the newly downloaded JSON data
+	if guiEnabled {
+		addText(theme.ForegroundColor(), "Reloading %s...", jsonFilePath)
+	} else {
+		printLine("Reloading %s...", jsonFilePath)
+	}
+	return decodeTitleList(bytes.NewReader(jsonData), list)
 }
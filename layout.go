@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+// archiveLayout copies the identified content of a scan into a folder per title, for pinecone export -layout archive:
+//
+//	Title Name (titleid)/Updates/<update name>/default.xbe
+//	Title Name (titleid)/DLC/<content ID>/...
+type archiveLayout struct {
+	dir string
+	// hardLink links files of dump folders into the layout instead of copying them, when they're on the same drive
+	hardLink bool
+
+	copied     int
+	linked     int
+	unchanged  int
+	collisions int
+	// dryRun totals what a --dry-run would copy, when nothing is copied
+	dryRun dryRun
+}
+
+// The layout pinecone export copies the scan's content into once it finishes.
+var layoutExport *archiveLayout
+
+// Returns the SHA1 of a file.
+func fileSHA1(fsys fs.FS, name string) (string, error) {
+	in, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	fileReport, err := copyHashed(io.Discard, in, name)
+	return fileReport.SHA1, err
+}
+
+// Puts a file of the dump at outPath, a slash separated path in the layout. A file already there is left alone when
+// it's identical, and reported as a collision when it isn't, so nothing in the archive is ever overwritten.
+func (layout *archiveLayout) addFile(source *scanSource, filePath, sha1, outPath string) error {
+	localPath := filepath.Join(layout.dir, filepath.FromSlash(outPath))
+	existing, err := fileSHA1(dirFS(layout.dir), outPath)
+	if err == nil {
+		if sha1 == "" {
+			// --fast-hash leaves files without a SHA1 to compare
+			if sha1, err = fileSHA1(source.FS, filePath); err != nil {
+				return err
+			}
+		}
+		if existing == sha1 {
+			layout.unchanged++
+			return nil
+		}
+		layout.collisions++
+		if guiEnabled {
+			addText(theme.ErrorColor(), "%s differs from %s, which is already in the archive, and was skipped", source.displayPath(filePath), localPath)
+		}
+		printInfo(fatihColor.FgRed, "%s differs from %s, which is already in the archive, and was skipped\n", source.displayPath(filePath), localPath)
+		return nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	if dryRunFlag {
+		verb := "copy"
+		if layout.hardLink && source.Dir != "" {
+			verb = "hard-link"
+		}
+		_, err := layout.dryRun.add(verb, source, filePath, outPath, localPath)
+		return err
+	}
+	if err := os.MkdirAll(extendedPath(filepath.Dir(localPath)), 0o755); err != nil {
+		return err
+	}
+	// Linking fails for files on other drives, or not in a folder at all, which are copied instead
+	if layout.hardLink && source.Dir != "" && os.Link(extendedPath(filepath.Join(source.Dir, filepath.FromSlash(filePath))), extendedPath(localPath)) == nil {
+		layout.linked++
+		return nil
+	}
+	in, err := source.FS.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	// The copy only takes its name once complete, so an interrupted export doesn't leave a partial file that later
+	// exports would take for a collision
+	partPath := extendedPath(localPath + ".part")
+	out, err := os.Create(partPath)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(partPath, extendedPath(localPath))
+	}
+	if err != nil {
+		os.Remove(partPath)
+		return err
+	}
+	layout.copied++
+	return nil
+}
+
+// Copies the known updates and content of a scan into the archive layout.
+func (layout *archiveLayout) export(report *ScanReport) error {
+	if err := checkWritable(layout.dir); err != nil {
+		return err
+	}
+	for _, title := range report.Titles {
+		if !title.Known || title.Unknown != nil {
+			continue
+		}
+		source := report.titleSource(&title)
+		if source == nil {
+			continue
+		}
+		titleDir := sanitizeFileName(fmt.Sprintf("%s (%s)", title.TitleName, title.TitleID))
+		for _, update := range title.Updates {
+			if !update.Known || update.Error != "" {
+				continue
+			}
+			name := update.Name
+			if name == "" {
+				name = update.SHA1
+			}
+			filePath := path.Join(tdataFolder, update.Path)
+			outPath := path.Join(titleDir, "Updates", sanitizeFileName(name), path.Base(update.Path))
+			if err := layout.addFile(source, filePath, update.SHA1, outPath); err != nil {
+				return fmt.Errorf("error exporting %s: %v", source.displayPath(filePath), err)
+			}
+		}
+		for _, content := range title.Content {
+			if !content.Known {
+				continue
+			}
+			for _, file := range content.Files {
+				filePath := path.Join(tdataFolder, content.Path, file.Path)
+				outPath := path.Join(titleDir, "DLC", content.ContentID, file.Path)
+				if err := layout.addFile(source, filePath, file.SHA1, outPath); err != nil {
+					return fmt.Errorf("error exporting %s: %v", source.displayPath(filePath), err)
+				}
+			}
+		}
+	}
+
+	if dryRunFlag {
+		layout.dryRun.summary("exported into", layout.dir)
+	} else {
+		if guiEnabled {
+			addText(theme.ForegroundColor(), "%d files copied, %d hard-linked and %d already there in: %s", layout.copied, layout.linked, layout.unchanged, layout.dir)
+		}
+		printInfo(fatihColor.FgWhite, "%d files copied, %d hard-linked and %d already there in: %s\n", layout.copied, layout.linked, layout.unchanged, layout.dir)
+	}
+	if layout.collisions > 0 {
+		return fmt.Errorf("%d files collided with different files already in %s", layout.collisions, layout.dir)
+	}
+	return nil
+}
+
+// Scans what the flags before the command point at and copies the content identified into a folder laid out for
+// long-term archives. Exporting into the same folder again only adds what's new, like rsync.
+func runExport(args []string) error {
+	flags := newCommandFlags("export")
+	layoutName := flags.String("layout", "archive", "Folder layout to export into, only archive for now")
+	hardLink := flags.Bool("hardlink", false, "Hard-link files of dump folders instead of copying them")
+	addSourceFlags(flags)
+	addScanFlags(flags)
+	addReportFlags(flags)
+	addDatabaseFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: pinecone export [-layout archive] [-hardlink] [flags] folder")
+	}
+	applyLocationFlags()
+	if *layoutName != "archive" {
+		return fmt.Errorf("unknown export layout %q, expected archive", *layoutName)
+	}
+	layoutExport = &archiveLayout{dir: flags.Arg(0), hardLink: *hardLink}
+	defer func() { layoutExport = nil }()
+	return runCommandScan()
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+var followLinksFlag = false
+
+// What became of a symbolic link, or a junction on Windows, found in a dump folder.
+const (
+	linkFollowed  = "followed"
+	linkSkipped   = "skipped"
+	linkLoop      = "loop"
+	linkDuplicate = "duplicate"
+	linkBroken    = "broken"
+)
+
+// LinkReport is a symbolic link or junction found in a dump folder, and whether the scan followed it.
+type LinkReport struct {
+	Path    string `json:"path" xml:"path"`
+	Source  string `json:"source,omitempty" xml:"source,attr,omitempty"`
+	Target  string `json:"target,omitempty" xml:"target,omitempty"`
+	Problem string `json:"problem" xml:"problem,attr"`
+}
+
+// linkFS leaves the links of a dump folder out of its listings, so no scanner walks them. With --follow-links they're
+// listed as what they point to instead, unless that's already walked through another path: a link to a folder of
+// the dump itself, or to one of its parents, would walk the same files twice or forever. Junctions are links as far
+// as the os package is concerned, so they're handled the same way.
+type linkFS struct {
+	fs.FS
+	dir string
+
+	once     sync.Once
+	realRoot string
+
+	mu sync.Mutex
+	// The real folders links were followed to, keyed by the path of the link
+	followed map[string]string
+	links    map[string]LinkReport
+}
+
+func newLinkFS(fsys fs.FS, dir string) *linkFS {
+	return &linkFS{FS: fsys, dir: dir, followed: make(map[string]string), links: make(map[string]LinkReport)}
+}
+
+// ReadDir lists a folder with its links resolved as described on linkFS.
+func (l *linkFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(l.FS, name)
+	if err != nil {
+		return entries, err
+	}
+	listed := entries[:0]
+	for _, entry := range entries {
+		if entry.Type()&fs.ModeSymlink != 0 {
+			if entry = l.resolve(path.Join(name, entry.Name())); entry == nil {
+				continue
+			}
+		}
+		listed = append(listed, entry)
+	}
+	return listed, nil
+}
+
+// Returns the entry a link at name is listed as, or nil when it's left out.
+func (l *linkFS) resolve(name string) fs.DirEntry {
+	l.once.Do(func() {
+		l.realRoot, _ = filepath.EvalSymlinks(l.dir)
+	})
+	// Scanners ask for paths in the case the console uses, which the folders on disk may not have
+	diskName := name
+	if fold, ok := l.FS.(*foldFS); ok {
+		diskName = fold.diskPath(name)
+	}
+	linkPath := filepath.Join(l.dir, filepath.FromSlash(diskName))
+	target, err := filepath.EvalSymlinks(linkPath)
+	var info fs.FileInfo
+	if err == nil {
+		info, err = os.Stat(extendedPath(target))
+	}
+	if err != nil {
+		l.record(name, LinkReport{Path: name, Problem: linkBroken})
+		return nil
+	}
+	report := LinkReport{Path: name, Target: target, Problem: linkSkipped}
+	if !followLinksFlag {
+		l.record(name, report)
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.followed[name]; ok {
+		return fs.FileInfoToDirEntry(linkInfo{info, path.Base(name)})
+	}
+	realParent, _ := filepath.EvalSymlinks(filepath.Dir(linkPath))
+	switch {
+	case info.IsDir() && pathWithin(realParent, target):
+		report.Problem = linkLoop
+	case l.realRoot != "" && pathWithin(target, l.realRoot):
+		report.Problem = linkDuplicate
+	default:
+		for _, followed := range l.followed {
+			if pathWithin(target, followed) || pathWithin(followed, target) {
+				report.Problem = linkDuplicate
+			}
+		}
+	}
+	if report.Problem == linkSkipped {
+		report.Problem = linkFollowed
+		l.followed[name] = target
+	}
+	if _, ok := l.links[name]; !ok {
+		l.links[name] = report
+	}
+	if report.Problem != linkFollowed {
+		return nil
+	}
+	return fs.FileInfoToDirEntry(linkInfo{info, path.Base(name)})
+}
+
+// Keeps the first report of a link, which every walk over its folder finds again.
+func (l *linkFS) record(name string, report LinkReport) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.links[name]; !ok {
+		l.links[name] = report
+	}
+}
+
+// Returns the links found so far, in path order.
+func (l *linkFS) reports() []LinkReport {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	reports := make([]LinkReport, 0, len(l.links))
+	for _, report := range l.links {
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Path < reports[j].Path })
+	return reports
+}
+
+// linkInfo is the file info of a link's target, under the link's own name.
+type linkInfo struct {
+	fs.FileInfo
+	name string
+}
+
+func (info linkInfo) Name() string {
+	return info.name
+}
+
+// Reports whether name is dir or inside it.
+func pathWithin(name, dir string) bool {
+	rel, err := filepath.Rel(dir, name)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// Adds the links found in the sources' folders to the report and prints them, with what the scan did with each.
+func printLinks(sources []*scanSource) {
+	var displayPaths []string
+	for _, source := range sources {
+		if source.links == nil {
+			continue
+		}
+		for _, report := range source.links.reports() {
+			report.Source = source.reportName()
+			scanResults.Links = append(scanResults.Links, report)
+			displayPaths = append(displayPaths, source.displayPath(report.Path))
+		}
+	}
+	if len(scanResults.Links) == 0 {
+		return
+	}
+	if guiEnabled {
+		addHeader("Links")
+	}
+	printHeader("Links")
+	descriptions := map[string]string{
+		linkFollowed:  "followed",
+		linkSkipped:   "skipped, scan with --follow-links to follow it",
+		linkLoop:      "skipped, it leads back to a folder it's in",
+		linkDuplicate: "skipped, what it leads to is already scanned",
+		linkBroken:    "skipped, what it leads to doesn't exist",
+	}
+	for i, link := range scanResults.Links {
+		displayPath := displayPaths[i]
+		target := ""
+		if link.Target != "" {
+			target = " -> " + link.Target
+		}
+		textColor, guiColor := fatihColor.FgYellow, theme.PrimaryColorNamed(theme.ColorYellow)
+		if link.Problem == linkFollowed {
+			textColor, guiColor = fatihColor.FgWhite, theme.ForegroundColor()
+		}
+		if guiEnabled {
+			addText(guiColor, "%s%s: %s", displayPath, target, descriptions[link.Problem])
+		}
+		printInfo(textColor, "    %s%s: %s\n", displayPath, target, descriptions[link.Problem])
+	}
+}
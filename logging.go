@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/fatih/color"
+)
+
+var (
+	logFileFlag   string
+	logFormatFlag = "text"
+
+	// scanLog records everything printed to the --log-file, tagged with the scanner and source it came from. It's
+	// nil when no log file is written.
+	scanLog atomic.Pointer[slog.Logger]
+	// baseLog is scanLog without the scanner and source attributes
+	baseLog *slog.Logger
+)
+
+// Opens the --log-file for appending, so several runs against the same file build up a history of scans. Messages
+// keep being printed as before; the log file gets a timestamped copy of each, in text or JSON.
+func openLogFile() error {
+	if logFileFlag == "" || baseLog != nil {
+		return nil
+	}
+	if err := checkWritable(logFileFlag); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(logFileFlag, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("error opening log file: %v", err)
+	}
+	var handler slog.Handler
+	switch logFormatFlag {
+	case "text":
+		handler = slog.NewTextHandler(file, nil)
+	case "json":
+		handler = slog.NewJSONHandler(file, nil)
+	default:
+		file.Close()
+		return fmt.Errorf("unknown log format %q, expected text or json", logFormatFlag)
+	}
+	baseLog = slog.New(handler).With("pid", os.Getpid())
+	scanLog.Store(baseLog)
+	// Fatal errors go through the standard logger, and are the messages most worth having in the file
+	log.SetOutput(io.MultiWriter(os.Stderr, logWriter{}))
+	baseLog.Info("Pinecone v"+version+" started", "args", os.Args[1:])
+	return nil
+}
+
+// Tags what's logged from now on with the scanner running and the source it's reading, or clears the tags when
+// scanner is empty.
+func setLogScanner(scanner string, source *scanSource) {
+	if baseLog == nil {
+		return
+	}
+	if scanner == "" {
+		scanLog.Store(baseLog)
+		return
+	}
+	attrs := []any{"scanner", scanner}
+	if source.Location != "" {
+		attrs = append(attrs, "location", source.Location)
+	}
+	if source.Label != "" {
+		attrs = append(attrs, "partition", source.Label)
+	}
+	scanLog.Store(baseLog.With(attrs...))
+}
+
+// Records a message in the log file, at the level its color stands for.
+func logMessage(colorCode color.Attribute, msg string) {
+	logger := scanLog.Load()
+	if logger == nil {
+		return
+	}
+	msg = strings.TrimSpace(msg)
+	if msg == "" {
+		return
+	}
+	level := slog.LevelInfo
+	switch colorCode {
+	case color.FgRed, color.FgHiRed:
+		level = slog.LevelError
+	case color.FgYellow, color.FgHiYellow:
+		level = slog.LevelWarn
+	}
+	logger.Log(context.Background(), level, msg)
+}
+
+// logWriter logs what the standard logger writes as errors, without the date and time it starts them with.
+type logWriter struct{}
+
+func (logWriter) Write(p []byte) (int, error) {
+	msg := string(p)
+	if log.Flags() == log.LstdFlags && len(msg) > len("2006/01/02 15:04:05 ") {
+		msg = msg[len("2006/01/02 15:04:05 "):]
+	}
+	logMessage(color.FgRed, msg)
+	return len(p), nil
+}
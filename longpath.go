@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Device names Windows reserves in every folder, with or without an extension.
+var windowsReservedNames = []string{
+	"CON", "PRN", "AUX", "NUL",
+	"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+	"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9",
+}
+
+// Returns why a file or folder name can't be used on Windows without the \\?\ prefix, or "" if it can. Dumps copied
+// off a console on Linux keep names FATX allows but Windows tools choke on.
+func windowsNameProblem(name string) string {
+	base, _, _ := strings.Cut(name, ".")
+	if containsFold(windowsReservedNames, strings.TrimRight(base, " ")) {
+		return fmt.Sprintf("%s is a reserved device name", strings.ToUpper(strings.TrimRight(base, " ")))
+	}
+	if strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") {
+		return "it ends with a dot or space"
+	}
+	for _, c := range name {
+		if c < 0x20 || strings.ContainsRune(`<>:"|?*\`, c) {
+			return fmt.Sprintf("it holds %q", c)
+		}
+	}
+	return ""
+}
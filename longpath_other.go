@@ -0,0 +1,18 @@
+//go:build !windows
+
+package main
+
+import (
+	"io/fs"
+	"os"
+)
+
+// Returns the files of a folder as a file system. Only Windows limits path lengths and names.
+func dirFS(dir string) fs.FS {
+	return os.DirFS(dir)
+}
+
+// Returns name as it should be passed to the os package to create or open it.
+func extendedPath(name string) string {
+	return name
+}
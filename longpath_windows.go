@@ -0,0 +1,77 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Paths this long or longer need the \\?\ prefix, which lifts MAX_PATH, once the name of a file in the folder is
+// added to them.
+const maxShortPath = 248
+
+// Returns name with the \\?\ prefix when it's too long for MAX_PATH or holds a name Windows reserves, such as a
+// folder called AUX or a file ending with a dot. Prefixed paths are taken as they are, so nothing in them is
+// reserved. The os package already prefixes long local paths, but not UNC ones such as a dump on a network share,
+// or reserved names.
+func extendedPath(name string) string {
+	if strings.HasPrefix(name, `\\?\`) {
+		return name
+	}
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return name
+	}
+	extend := len(abs) >= maxShortPath
+	for _, part := range strings.Split(abs, `\`) {
+		if part != "" && !strings.HasSuffix(part, ":") && windowsNameProblem(part) != "" {
+			extend = true
+		}
+	}
+	if !extend {
+		return name
+	}
+	if strings.HasPrefix(abs, `\\`) {
+		return `\\?\UNC\` + abs[2:]
+	}
+	return `\\?\` + abs
+}
+
+// longPathFS is os.DirFS for deep dump folders: it opens every file through extendedPath, so TDATA trees past
+// MAX_PATH, or holding names Windows reserves, can still be walked.
+type longPathFS struct {
+	dir string
+}
+
+// Returns the files of a folder as a file system.
+func dirFS(dir string) fs.FS {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return os.DirFS(dir)
+	}
+	return longPathFS{dir: abs}
+}
+
+func (fsys longPathFS) join(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	return extendedPath(filepath.Join(fsys.dir, filepath.FromSlash(name))), nil
+}
+
+func (fsys longPathFS) Open(name string) (fs.File, error) {
+	fullName, err := fsys.join("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(fullName)
+}
+
+func (fsys longPathFS) Stat(name string) (fs.FileInfo, error) {
+	fullName, err := fsys.join("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(fullName)
+}
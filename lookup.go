@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	fatihColor "github.com/fatih/color"
+)
+
+// Titles printed at most for a name matching many of them.
+const lookupMaxTitles = 20
+
+// Loads the database for commands that only query it.
+func loadCommandDatabase() error {
+	jsonFilePath := filepath.Join(dataPath, "id_database.json")
+	if !databaseExists(jsonFilePath) {
+		return fmt.Errorf("no database in %s, run pinecone -u to download it", dataPath)
+	}
+	if err := loadDatabases(jsonFilePath, false); err != nil {
+		return fmt.Errorf("error loading data: %v", err)
+	}
+	return nil
+}
+
+// Looks a title ID, title name or SHA1 up in the loaded database without scanning anything, printing what's known and
+// archived of the titles found.
+func runLookup(query string) error {
+	if err := loadCommandDatabase(); err != nil {
+		return err
+	}
+	query = strings.TrimSpace(query)
+
+	if isLowerHex(strings.ToLower(query), 40) {
+		if !lookupHash(strings.ToLower(query)) {
+			printInfo(fatihColor.FgYellow, "SHA1 %s is not in the database\n", query)
+		}
+		return nil
+	}
+	if isLowerHex(strings.ToLower(query), 8) {
+		if data, platform, ok := lookupTitle(strings.ToLower(query)); ok {
+			printTitleLookup(strings.ToLower(query), data, platform)
+			return nil
+		}
+	}
+
+	// Anything else is part of a title name
+	type match struct {
+		titleID  string
+		data     TitleData
+		platform string
+	}
+	var matches []match
+	for _, section := range []struct {
+		titles   map[string]TitleData
+		platform string
+	}{{titles.Titles, ""}, {titles.Chihiro, platformChihiro}, {titles.Debug, platformDebug}} {
+		for titleID, data := range section.titles {
+			if strings.Contains(strings.ToLower(data.TitleName), strings.ToLower(query)) {
+				matches = append(matches, match{titleID, data, section.platform})
+			}
+		}
+	}
+	if len(matches) == 0 {
+		printInfo(fatihColor.FgYellow, "No title matches %q\n", query)
+		return nil
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].titleID < matches[j].titleID })
+	if len(matches) > lookupMaxTitles {
+		printInfo(fatihColor.FgYellow, "%d titles match %q, showing the first %d\n", len(matches), query, lookupMaxTitles)
+		matches = matches[:lookupMaxTitles]
+	}
+	for _, m := range matches {
+		printTitleLookup(m.titleID, m.data, m.platform)
+	}
+	return nil
+}
+
+// Prints a title's content IDs and known updates, and which of them are archived.
+func printTitleLookup(titleID string, data TitleData, platform string) {
+	printHeader(data.TitleName)
+	printInfo(fatihColor.FgWhite, "Title ID: %s\n", titleID)
+	if platform != "" {
+		printInfo(fatihColor.FgWhite, "Platform: %s\n", platform)
+	}
+
+	archived := make(map[string]string)
+	for _, items := range data.Archived {
+		for id, name := range items {
+			archived[id] = name
+		}
+	}
+	if len(data.ContentIDs) > 0 {
+		printInfo(fatihColor.FgCyan, "Content IDs:\n")
+	}
+	for _, contentID := range data.ContentIDs {
+		if name, ok := archived[contentID]; ok {
+			printInfo(fatihColor.FgGreen, "    %s: archived (%s)\n", contentID, name)
+		} else {
+			printInfo(fatihColor.FgYellow, "    %s: not archived\n", contentID)
+		}
+	}
+	if len(data.TitleUpdates) > 0 {
+		printInfo(fatihColor.FgCyan, "Title updates: %s\n", strings.Join(data.TitleUpdates, ", "))
+	}
+	if len(data.TitleUpdatesKnown) > 0 {
+		printInfo(fatihColor.FgCyan, "Known and archived title updates:\n")
+	}
+	for _, known := range data.TitleUpdatesKnown {
+		hashes := make([]string, 0, len(known))
+		for hash := range known {
+			hashes = append(hashes, hash)
+		}
+		sort.Strings(hashes)
+		for _, hash := range hashes {
+			printInfo(fatihColor.FgGreen, "    %s: %s\n", hash, known[hash])
+		}
+	}
+	fmt.Println()
+}
+
+// Prints every entry of the database with the given SHA1, reporting whether there were any.
+func lookupHash(hash string) bool {
+	found := false
+	for _, ref := range databaseIndex.hashes[hash] {
+		if ref.contentID == "" {
+			printInfo(fatihColor.FgGreen, "Known and archived title update of %s (%s): %s\n", ref.titleName, ref.titleID, ref.name)
+		} else {
+			printInfo(fatihColor.FgGreen, "File %s of archived content %s of %s (%s)\n", ref.name, ref.contentID, ref.titleName, ref.titleID)
+		}
+		found = true
+	}
+	if software, ok := titles.Software[hash]; ok {
+		printInfo(fatihColor.FgGreen, "Software: %s %s (%s)\n", software.Name, software.Version, software.Type)
+		found = true
+	}
+	if xbe, ok := databaseIndex.homebrewXBEs[hash]; ok {
+		printInfo(fatihColor.FgGreen, "Homebrew: %s (%s) version %s\n", titles.Homebrew[xbe.titleID].TitleName, xbe.titleID, xbe.id)
+		found = true
+	}
+	if bad, ok := titles.KnownBad[hash]; ok {
+		printInfo(fatihColor.FgRed, "Known bad dump of %s for %s: %s\n", bad.Name, bad.TitleID, bad.Reason)
+		found = true
+	}
+	return found
+}
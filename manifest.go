@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+var manifestFolders = []string{"TDATA", "UDATA"}
+
+// Hashes every file under the TDATA/UDATA folders of each source, keyed by slash separated path.
+// Paths are prefixed with the source label when there is one, such as the partition of an HDD image.
+func hashDumpFiles(ctx context.Context, sources []*scanSource) (map[string]string, error) {
+	hashes := make(map[string]string)
+	for _, source := range sources {
+		for _, folder := range manifestFolders {
+			if _, err := fs.Stat(source.FS, folder); err != nil {
+				continue
+			}
+			err := fs.WalkDir(source.FS, folder, func(filePath string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if d.IsDir() {
+					return nil
+				}
+				fileHash, err := getSHA1Hash(ctx, source.FS, filePath)
+				if err != nil {
+					return err
+				}
+				hashes[path.Join(source.reportName(), filePath)] = fileHash
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return hashes, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Writes a SHA1SUMS style manifest covering every file under TDATA/UDATA.
+func writeManifest(ctx context.Context, sources []*scanSource, manifestPath string) error {
+	if err := checkWritable(manifestPath); err != nil {
+		return err
+	}
+	hashes, err := hashDumpFiles(ctx, sources)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	for _, path := range sortedKeys(hashes) {
+		fmt.Fprintf(&sb, "%s  %s\n", hashes[path], path)
+	}
+	err = os.WriteFile(manifestPath, []byte(sb.String()), 0o644)
+	if err != nil {
+		return err
+	}
+
+	if guiEnabled {
+		addText(theme.ForegroundColor(), "Manifest of %d files saved to: %s", len(hashes), manifestPath)
+	}
+	printLine("Manifest of %d files saved to: %s", len(hashes), manifestPath)
+	return nil
+}
+
+// Reads a SHA1SUMS style manifest. Both text ("hash  path") and binary ("hash *path") entries are accepted.
+func readManifest(manifestPath string) (map[string]string, error) {
+	file, err := os.Open(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	hashes := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hash, path, found := strings.Cut(line, " ")
+		if !found || len(hash) != 40 {
+			return nil, fmt.Errorf("malformed manifest entry on line %d", lineNumber)
+		}
+		path = strings.TrimPrefix(strings.TrimLeft(path, " "), "*")
+		hashes[filepath.ToSlash(path)] = strings.ToLower(hash)
+	}
+	return hashes, scanner.Err()
+}
+
+// Re-hashes the dump and compares it against a previously written manifest.
+func verifyManifest(ctx context.Context, sources []*scanSource, manifestPath string) error {
+	expected, err := readManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+	actual, err := hashDumpFiles(ctx, sources)
+	if err != nil {
+		return err
+	}
+
+	printHeader("Manifest Verification")
+	if guiEnabled {
+		addHeader("Manifest Verification")
+	}
+
+	var added, deleted, corrupted int
+	for _, path := range sortedKeys(expected) {
+		actualHash, ok := actual[path]
+		if !ok {
+			deleted++
+			if guiEnabled {
+				addText(theme.ErrorColor(), "Missing: %s", path)
+			}
+			printInfo(fatihColor.FgRed, "Missing: %s\n", path)
+		} else if actualHash != expected[path] {
+			corrupted++
+			if guiEnabled {
+				addText(theme.ErrorColor(), "Changed: %s (expected %s, found %s)", path, expected[path], actualHash)
+			}
+			printInfo(fatihColor.FgRed, "Changed: %s (expected %s, found %s)\n", path, expected[path], actualHash)
+		}
+	}
+	for _, path := range sortedKeys(actual) {
+		if _, ok := expected[path]; !ok {
+			added++
+			if guiEnabled {
+				addText(theme.PrimaryColorNamed(theme.ColorYellow), "Added: %s", path)
+			}
+			printInfo(fatihColor.FgYellow, "Added: %s\n", path)
+		}
+	}
+
+	if guiEnabled {
+		addText(theme.ForegroundColor(), "%d files verified, %d added, %d missing, %d changed", len(expected)-deleted-corrupted, added, deleted, corrupted)
+	}
+	printLine("%d files verified, %d added, %d missing, %d changed", len(expected)-deleted-corrupted, added, deleted, corrupted)
+
+	if added+deleted+corrupted > 0 {
+		return fmt.Errorf("dump does not match manifest %s", manifestPath)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Escapes characters that would break a Markdown table cell.
+func mdCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+func titleDisplayName(title TitleReport) string {
+	if title.TitleName == "" {
+		return "Unknown Title"
+	}
+	return title.TitleName
+}
+
+// Renders the report as Markdown suitable for GitHub issues and forum posts.
+func markdownReport(report *ScanReport) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "# Pinecone v%s Report\n\n", report.Version)
+	for _, database := range report.Databases {
+		fmt.Fprintf(&sb, "Database: %s\n\n", database)
+	}
+	sb.WriteString("| Title ID | Title Name | Content | Updates | Unarchived |\n")
+	sb.WriteString("|---|---|---|---|---|\n")
+	for _, title := range report.Titles {
+		unarchived := 0
+		for _, content := range title.Content {
+			if !content.Archived {
+				unarchived++
+			}
+		}
+		for _, update := range title.Updates {
+			if !update.Archived {
+				unarchived++
+			}
+		}
+		fmt.Fprintf(&sb, "| %s | %s | %d | %d | %d |\n", title.TitleID, mdCell(titleDisplayName(title)), len(title.Content), len(title.Updates), unarchived)
+	}
+
+	for _, title := range report.Titles {
+		var items []ReportItem
+		for _, item := range reportItems(&ScanReport{Titles: []TitleReport{title}}) {
+			if !item.Archived && item.Type != itemTypeTitle {
+				items = append(items, item)
+			}
+		}
+		if len(items) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&sb, "\n## %s (%s)\n\n", mdCell(titleDisplayName(title)), title.TitleID)
+		sb.WriteString("| Type | Content ID | Path | SHA1 | Status |\n")
+		sb.WriteString("|---|---|---|---|---|\n")
+		for _, item := range items {
+			fmt.Fprintf(&sb, "| %s | %s | `%s` | %s | %s |\n", item.Type, item.ContentID, mdCell(item.Path), item.SHA1, item.Status())
+		}
+	}
+
+	if len(report.Missing) > 0 {
+		sb.WriteString("\n## Missing Content\n\n")
+		sb.WriteString("| Title ID | Title Name | Missing Content | Missing Updates |\n")
+		sb.WriteString("|---|---|---|---|\n")
+		for _, missing := range report.Missing {
+			fmt.Fprintf(&sb, "| %s | %s | %d of %d | %d of %d |\n", missing.TitleID, mdCell(missing.TitleName),
+				len(missing.Content), missing.KnownContent, len(missing.Updates), missing.KnownUpdates)
+		}
+	}
+
+	if len(report.Regions) > 0 {
+		sb.WriteString("\n## Region Profile\n\n")
+		sb.WriteString("| Region | XBEs |\n")
+		sb.WriteString("|---|---|\n")
+		for _, region := range report.Regions {
+			fmt.Fprintf(&sb, "| %s | %d |\n", region.Region, region.XBEs)
+		}
+	}
+
+	return sb.String()
+}
+
+func writeMarkdownReport(outputPath string, report *ScanReport) error {
+	if err := checkWritable(outputPath); err != nil {
+		return err
+	}
+	return os.WriteFile(outputPath, []byte(markdownReport(report)), 0o644)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+// MissingReport lists the content and title updates the database knows of for a title found in a scan that aren't
+// on the scanned drive, which might still be found on other consoles.
+type MissingReport struct {
+	TitleID   string `json:"titleID" xml:"titleID,attr"`
+	TitleName string `json:"titleName" xml:"titleName"`
+	// The number of content IDs and known updates the database lists for the title
+	KnownContent int           `json:"knownContent" xml:"knownContent,attr"`
+	KnownUpdates int           `json:"knownUpdates" xml:"knownUpdates,attr"`
+	Content      []MissingItem `json:"content,omitempty" xml:"content,omitempty"`
+	Updates      []MissingItem `json:"updates,omitempty" xml:"update,omitempty"`
+}
+
+// MissingItem is a content ID or known update hash not found on the drive.
+type MissingItem struct {
+	ID       string `json:"id" xml:"id,attr"`
+	Name     string `json:"name,omitempty" xml:"name,omitempty"`
+	Archived bool   `json:"archived" xml:"archived,attr"`
+}
+
+// Returns the name to print for a missing item.
+func (item MissingItem) String() string {
+	if item.Name == "" {
+		return item.ID
+	}
+	return fmt.Sprintf("%s (%s)", item.Name, item.ID)
+}
+
+// Lists, once every source has been scanned, the known content and updates of each title found that the drive
+// doesn't have. A title found on several partitions counts what each of them has.
+func checkMissingContent() {
+	type foundItems struct {
+		content map[string]bool
+		updates map[string]bool
+	}
+	var titleIDs []string
+	found := make(map[string]*foundItems)
+	for _, title := range scanResults.Titles {
+		if !title.Known {
+			continue
+		}
+		items, ok := found[title.TitleID]
+		if !ok {
+			items = &foundItems{content: make(map[string]bool), updates: make(map[string]bool)}
+			found[title.TitleID] = items
+			titleIDs = append(titleIDs, title.TitleID)
+		}
+		for _, content := range title.Content {
+			items.content[content.ContentID] = true
+		}
+		for _, update := range title.Updates {
+			items.updates[update.SHA1] = true
+		}
+	}
+	sort.Strings(titleIDs)
+
+	printed := false
+	for _, titleID := range titleIDs {
+		titleData, _, ok := lookupTitle(titleID)
+		if !ok {
+			continue
+		}
+		missing := MissingReport{TitleID: titleID, TitleName: titleData.TitleName, KnownContent: len(titleData.ContentIDs)}
+		for _, contentID := range titleData.ContentIDs {
+			if found[titleID].content[contentID] {
+				continue
+			}
+			name := databaseIndex.archivedName(titleID, contentID)
+			missing.Content = append(missing.Content, MissingItem{ID: contentID, Name: name, Archived: name != ""})
+		}
+		for _, known := range titleData.TitleUpdatesKnown {
+			for hash, name := range known {
+				missing.KnownUpdates++
+				if !found[titleID].updates[hash] {
+					missing.Updates = append(missing.Updates, MissingItem{ID: hash, Name: name, Archived: true})
+				}
+			}
+		}
+		if len(missing.Content) == 0 && len(missing.Updates) == 0 {
+			continue
+		}
+		// Updates come from a map, and several can share a name, so they're ordered by hash too to keep the report stable
+		sort.SliceStable(missing.Updates, func(i, j int) bool {
+			if missing.Updates[i].Name != missing.Updates[j].Name {
+				return missing.Updates[i].Name < missing.Updates[j].Name
+			}
+			return missing.Updates[i].ID < missing.Updates[j].ID
+		})
+
+		if !printed {
+			if guiEnabled {
+				addHeader("Missing Content")
+			}
+			printHeader("Missing Content")
+			printed = true
+		}
+		var counts []string
+		if len(missing.Content) > 0 {
+			counts = append(counts, fmt.Sprintf("%d of %d known content items", len(missing.Content), missing.KnownContent))
+		}
+		if len(missing.Updates) > 0 {
+			counts = append(counts, fmt.Sprintf("%d of %d known title updates", len(missing.Updates), missing.KnownUpdates))
+		}
+		if guiEnabled {
+			addText(theme.ForegroundColor(), "%s (%s): %s aren't on this drive", missing.TitleName, titleID, strings.Join(counts, " and "))
+		}
+		printInfo(fatihColor.FgWhite, "%s (%s): %s aren't on this drive\n", missing.TitleName, titleID, strings.Join(counts, " and "))
+		// Titles know many updates for other regions and languages, so only the report lists each of them
+		for _, item := range missing.Content {
+			status := "not archived by anyone yet"
+			if item.Archived {
+				status = "archived"
+			}
+			if guiEnabled {
+				addText(theme.ForegroundColor(), "    Content %s, %s", item, status)
+			}
+			printInfo(fatihColor.FgWhite, "    Content %s, %s\n", item, status)
+		}
+		scanResults.Missing = append(scanResults.Missing, missing)
+	}
+}
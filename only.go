@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Categories of scan results that --only narrows the printed output to.
+const (
+	onlyUnknown    = "unknown"
+	onlyUnarchived = "unarchived"
+	onlyArchived   = "archived"
+	onlyErrors     = "errors"
+)
+
+var onlyCategories = []string{onlyUnknown, onlyUnarchived, onlyArchived, onlyErrors}
+
+// categoryList is the value of --only, which can be repeated or comma-separated.
+type categoryList []string
+
+func (categories *categoryList) String() string {
+	return strings.Join(*categories, ",")
+}
+
+func (categories *categoryList) Set(value string) error {
+	for _, category := range strings.Split(value, ",") {
+		category = strings.ToLower(strings.TrimSpace(category))
+		if category == "" {
+			continue
+		}
+		if !contains(onlyCategories, category) {
+			return fmt.Errorf("unknown category %q, expected %s", category, strings.Join(onlyCategories, ", "))
+		}
+		*categories = append(*categories, category)
+	}
+	return nil
+}
+
+var (
+	onlyFlag categoryList
+	// outputHidden is set while the lines of a title or item --only leaves out are printed
+	outputHidden bool
+	// heldHeader is the header of the title being scanned, held back with --only until one of its items is shown
+	heldHeader string
+)
+
+// Starts the printed output of a title, under its header when it has one. With --only, the title's lines are held
+// back until an item of one of the categories asked for is shown, unless the title itself is of one.
+func beginTitleOutput(title, category string) {
+	if len(onlyFlag) == 0 {
+		if title != "" {
+			printHeader(title)
+		}
+		return
+	}
+	heldHeader = title
+	outputHidden = !contains(onlyFlag, category)
+}
+
+// Ends the printed output of a title, so what's printed after it is shown again.
+func endTitleOutput() {
+	heldHeader = ""
+	outputHidden = false
+}
+
+// Shows or hides the lines of an item of the given category, as --only asks. The returned function goes back to
+// showing what was shown before, once they're printed.
+func itemOutput(category string) func() {
+	if len(onlyFlag) == 0 {
+		return func() {}
+	}
+	hidden := outputHidden
+	outputHidden = !contains(onlyFlag, category)
+	return func() { outputHidden = hidden }
+}
+
+// Reports whether a line should be printed, first printing the header of its title if it was held back.
+func showOutput() bool {
+	if outputHidden {
+		return false
+	}
+	if heldHeader != "" {
+		title := heldHeader
+		heldHeader = ""
+		printHeader(title)
+	}
+	return true
+}
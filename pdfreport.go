@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Letter sized pages, measured in points.
+const (
+	pdfPageWidth  = 612
+	pdfPageHeight = 792
+	pdfMargin     = 50
+	pdfLineHeight = 14
+)
+
+type pdfColor [3]float64
+
+var (
+	pdfBlack  = pdfColor{0, 0, 0}
+	pdfGreen  = pdfColor{0.1, 0.5, 0.1}
+	pdfOrange = pdfColor{0.8, 0.45, 0}
+	pdfRed    = pdfColor{0.75, 0.1, 0.1}
+	pdfCyan   = pdfColor{0, 0.45, 0.45}
+)
+
+// pdfDocument lays out lines of text onto pages using the standard Helvetica fonts.
+type pdfDocument struct {
+	pages   []*bytes.Buffer
+	current *bytes.Buffer
+	y       float64
+}
+
+func newPDFDocument() *pdfDocument {
+	doc := &pdfDocument{}
+	doc.newPage()
+	return doc
+}
+
+func (doc *pdfDocument) newPage() {
+	doc.current = &bytes.Buffer{}
+	doc.pages = append(doc.pages, doc.current)
+	doc.y = pdfPageHeight - pdfMargin
+}
+
+// Starts a new page unless there is room for the given number of lines.
+func (doc *pdfDocument) keepLines(lines int) {
+	if doc.y-float64(lines*pdfLineHeight) < pdfMargin+pdfLineHeight {
+		doc.newPage()
+	}
+}
+
+// Escapes text for a PDF string literal, replacing anything outside of printable ASCII.
+func pdfEscape(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r < 32 || r > 126:
+			sb.WriteByte('?')
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+func (doc *pdfDocument) line(font string, size int, textColor pdfColor, indent float64, text string) {
+	doc.keepLines(1)
+	fmt.Fprintf(doc.current, "BT /%s %d Tf %.2f %.2f %.2f rg %.2f %.2f Td (%s) Tj ET\n",
+		font, size, textColor[0], textColor[1], textColor[2], pdfMargin+indent, doc.y, pdfEscape(text))
+	doc.y -= pdfLineHeight
+}
+
+func (doc *pdfDocument) space() {
+	doc.y -= pdfLineHeight / 2
+}
+
+// Serializes the document, adding a page number footer to every page.
+func (doc *pdfDocument) bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+	pageCount := len(doc.pages)
+	kids := make([]string, pageCount)
+	for i := range doc.pages {
+		// Objects 1-4 are the catalog, page tree and fonts; each page is followed by its content stream
+		kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), pageCount))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range doc.pages {
+		fmt.Fprintf(page, "BT /F1 8 Tf 0 0 0 rg %d %d Td (Page %d of %d) Tj ET\n", pdfPageWidth/2-20, pdfMargin/2, i+1, pageCount)
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 6+i*2))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+func pdfStatusColor(item ReportItem) pdfColor {
+	switch item.Status() {
+	case statusArchived:
+		return pdfGreen
+	case statusUnarchived:
+		return pdfOrange
+	default:
+		return pdfRed
+	}
+}
+
+// Renders a paginated, printable summary with totals and one section per title.
+func pdfReport(report *ScanReport) []byte {
+	items := reportItems(report)
+	counts := make(map[string]int)
+	for _, item := range items {
+		counts[item.Status()]++
+	}
+
+	doc := newPDFDocument()
+	doc.line("F2", 18, pdfCyan, 0, "Pinecone v"+report.Version+" Report")
+	doc.line("F1", 10, pdfBlack, 0, "Location: "+report.Location)
+	doc.space()
+	doc.line("F2", 12, pdfBlack, 0, "Totals")
+	doc.line("F1", 10, pdfBlack, 10, fmt.Sprintf("Titles: %d", len(report.Titles)))
+	doc.line("F1", 10, pdfBlack, 10, fmt.Sprintf("Items: %d", len(items)))
+	doc.line("F1", 10, pdfGreen, 10, fmt.Sprintf("Archived: %d", counts[statusArchived]))
+	doc.line("F1", 10, pdfOrange, 10, fmt.Sprintf("Unarchived: %d", counts[statusUnarchived]))
+	doc.line("F1", 10, pdfRed, 10, fmt.Sprintf("Unknown: %d", counts[statusUnknown]))
+	doc.line("F1", 10, pdfRed, 10, fmt.Sprintf("Damaged: %d", counts[xbeTruncated]+counts[xbeCorrupted]+counts[statusKnownBad]))
+
+	for _, title := range report.Titles {
+		titleItems := reportItems(&ScanReport{Titles: []TitleReport{title}})
+		doc.space()
+		doc.keepLines(3)
+		doc.line("F2", 12, pdfBlack, 0, fmt.Sprintf("%s (%s)", titleDisplayName(title), title.TitleID))
+		for _, item := range titleItems {
+			if item.Type == itemTypeTitle {
+				doc.line("F1", 10, pdfRed, 10, "Title ID is not in the database: "+item.Path)
+				continue
+			}
+			description := item.ContentID
+			if item.Type == itemTypeUpdate {
+				description = item.Path
+			}
+			if item.Name != "" {
+				description += " - " + item.Name
+			}
+			doc.line("F1", 10, pdfStatusColor(item), 10, fmt.Sprintf("[%s] %s %s", item.Status(), item.Type, description))
+			if item.SHA1 != "" {
+				doc.line("F1", 8, pdfBlack, 20, "SHA1: "+item.SHA1)
+			}
+		}
+	}
+
+	return doc.bytes()
+}
+
+func writePDFReport(outputPath string, report *ScanReport) error {
+	if err := checkWritable(outputPath); err != nil {
+		return err
+	}
+	return os.WriteFile(outputPath, pdfReport(report), 0o644)
+}
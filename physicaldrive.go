@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PhysicalDrive is an attached disk that can be passed to -image.
+type PhysicalDrive struct {
+	Path string
+	Size int64
+	Xbox bool
+}
+
+// Reports whether a disk has an Xbox partition table or a FATX filesystem where the retail E partition lives.
+func isXboxDisk(r io.ReaderAt) bool {
+	sector := make([]byte, fatxSectorSize)
+	if _, err := r.ReadAt(sector, 0); err == nil && string(sector[:len(xboxPartitionTableMagic)]) == xboxPartitionTableMagic {
+		return true
+	}
+	if _, err := r.ReadAt(sector, xboxRetailPartitions[0].Offset); err == nil && string(sector[:len(fatxMagic)]) == fatxMagic {
+		return true
+	}
+	return false
+}
+
+// Reports whether a path names a device rather than an image file.
+func isDevicePath(devicePath string) bool {
+	if strings.HasPrefix(devicePath, `\\.\`) {
+		return true
+	}
+	info, err := os.Stat(devicePath)
+	return err == nil && info.Mode()&os.ModeDevice != 0
+}
+
+// sectorReader rounds reads out to whole sectors, as raw disk devices reject unaligned access.
+type sectorReader struct {
+	r io.ReaderAt
+}
+
+func (s sectorReader) ReadAt(b []byte, off int64) (int, error) {
+	start := off &^ (fatxSectorSize - 1)
+	end := (off + int64(len(b)) + fatxSectorSize - 1) &^ (fatxSectorSize - 1)
+	if start == off && end == off+int64(len(b)) {
+		return s.r.ReadAt(b, off)
+	}
+
+	buf := make([]byte, end-start)
+	n, err := s.r.ReadAt(buf, start)
+	if int64(n) <= off-start {
+		return 0, err
+	}
+	n = copy(b, buf[off-start:n])
+	if n == len(b) {
+		return n, nil
+	}
+	return n, err
+}
+
+func printDrives(drives []PhysicalDrive) {
+	for i, drive := range drives {
+		kind := ""
+		if drive.Xbox {
+			kind = " (Xbox drive)"
+		}
+		fmt.Printf("  %d: %s %.1f GB%s\n", i+1, drive.Path, float64(drive.Size)/1e9, kind)
+	}
+}
+
+// Lists attached drives and asks which one to scan, returning its path.
+func pickPhysicalDrive() (string, error) {
+	drives, err := listPhysicalDrives()
+	if err != nil {
+		return "", err
+	}
+	if len(drives) == 0 {
+		return "", fmt.Errorf("no drives found, Pinecone may need to be run as administrator")
+	}
+
+	fmt.Println("Attached drives:")
+	printDrives(drives)
+	fmt.Print("Drive to scan: ")
+	var response string
+	fmt.Scanln(&response)
+	choice, err := strconv.Atoi(strings.TrimSpace(response))
+	if err != nil || choice < 1 || choice > len(drives) {
+		return "", fmt.Errorf("no drive selected")
+	}
+	return drives[choice-1].Path, nil
+}
+
+// Prints the attached drives for the "drives" command.
+func runDrives() error {
+	drives, err := listPhysicalDrives()
+	if err != nil {
+		return err
+	}
+	if len(drives) == 0 {
+		fmt.Println("No drives found, Pinecone may need to be run as administrator")
+		return nil
+	}
+	printDrives(drives)
+	return nil
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+func deviceSize(file *os.File) (int64, error) {
+	return file.Seek(0, io.SeekEnd)
+}
+
+// Physical drive enumeration is only needed on Windows; elsewhere devices such as /dev/sdb can be passed to -image.
+func listPhysicalDrives() ([]PhysicalDrive, error) {
+	return nil, fmt.Errorf("listing drives is only available on Windows, pass a device such as /dev/sdb to -image instead")
+}
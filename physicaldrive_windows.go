@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	ioctlDiskGetLengthInfo = 0x7405C
+	maxPhysicalDrives      = 32
+)
+
+// Returns the size of a disk device, which can't be found by seeking on Windows.
+func deviceSize(file *os.File) (int64, error) {
+	var length int64
+	var returned uint32
+	err := syscall.DeviceIoControl(syscall.Handle(file.Fd()), ioctlDiskGetLengthInfo, nil, 0,
+		(*byte)(unsafe.Pointer(&length)), uint32(unsafe.Sizeof(length)), &returned, nil)
+	if err != nil {
+		return 0, err
+	}
+	return length, nil
+}
+
+// Lists the \\.\PhysicalDriveN devices that can be opened, which requires administrator rights.
+func listPhysicalDrives() ([]PhysicalDrive, error) {
+	var drives []PhysicalDrive
+	for i := 0; i < maxPhysicalDrives; i++ {
+		devicePath := fmt.Sprintf(`\\.\PhysicalDrive%d`, i)
+		file, err := os.Open(devicePath)
+		if err != nil {
+			continue
+		}
+		size, err := deviceSize(file)
+		if err == nil {
+			drives = append(drives, PhysicalDrive{Path: devicePath, Size: size, Xbox: isXboxDisk(sectorReader{file})})
+		}
+		file.Close()
+	}
+	return drives, nil
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// How often the progress bar is redrawn, and how wide its bar is.
+const (
+	progressInterval = 250 * time.Millisecond
+	progressBarWidth = 20
+)
+
+var noProgressFlag = false
+
+// scanProgress follows how much of what a scan will hash it has got through, for the progress bar drawn on the
+// terminal while scanning.
+type scanProgress struct {
+	start time.Time
+	// The totals are counted in the background while the scan runs, and are -1 until the count is done
+	totalBytes  atomic.Int64
+	totalTitles atomic.Int64
+	doneBytes   atomic.Int64
+	doneTitles  atomic.Int64
+
+	// mu is held while the bar is drawn, or cleared for a message to be printed in its place
+	mu    sync.Mutex
+	drawn int
+}
+
+// The progress of the scan in progress, or nil when no bar is drawn.
+var activeProgress atomic.Pointer[scanProgress]
+
+// Reports whether a progress bar should be drawn: only for CLI scans whose stderr is a terminal, so it never ends up
+// in redirected output, --porcelain events or the log of a daemon.
+func progressEnabled() bool {
+	if noProgressFlag || guiEnabled || porcelainFlag || everyFlag > 0 {
+		return false
+	}
+	info, err := os.Stderr.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Starts drawing a progress bar of the scan of sources. The returned function stops it and clears the bar.
+func startProgress(ctx context.Context, sources []*scanSource) func() {
+	if !progressEnabled() {
+		return func() {}
+	}
+	progress := &scanProgress{start: time.Now()}
+	progress.totalBytes.Store(-1)
+	progress.totalTitles.Store(-1)
+	activeProgress.Store(progress)
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		progress.count(ctx, sources)
+	}()
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				progress.mu.Lock()
+				progress.draw()
+				progress.mu.Unlock()
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		wg.Wait()
+		activeProgress.Store(nil)
+		progress.mu.Lock()
+		progress.clear()
+		progress.mu.Unlock()
+	}
+}
+
+// Counts the titles in the sources' TDATA folders and the size of the files the content scanner hashes in them:
+// the DLC and update XBEs of known titles, every file of theirs with --deep, and the XBEs of unknown titles.
+func (progress *scanProgress) count(ctx context.Context, sources []*scanSource) {
+	var totalBytes, totalTitles int64
+	for _, source := range sources {
+		fs.WalkDir(source.FS, tdataFolder, func(filePath string, d fs.DirEntry, err error) error {
+			if ctx.Err() != nil {
+				return fs.SkipAll
+			}
+			if err != nil {
+				return nil
+			}
+			if isIgnored(filePath) {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			parts := strings.Split(strings.TrimPrefix(filePath, tdataFolder+"/"), "/")
+			titleID := strings.ToLower(parts[0])
+			if d.IsDir() {
+				if len(parts) == 1 && filePath != tdataFolder {
+					_, homebrew := titles.Homebrew[titleID]
+					if len(titleID) != 8 || titleID == dashboardTitleID || homebrew || !titleSelected(titleID) {
+						return fs.SkipDir
+					}
+					totalTitles++
+				}
+				return nil
+			}
+			if len(parts) < 2 {
+				return nil
+			}
+			_, _, known := lookupTitle(titleID)
+			hashed := strings.EqualFold(path.Ext(filePath), ".xbe") && (!known || strings.EqualFold(parts[1], "$u"))
+			if known && (deepFlag || strings.EqualFold(parts[1], "$c")) {
+				hashed = true
+			}
+			if hashed {
+				if info, err := d.Info(); err == nil {
+					totalBytes += info.Size()
+				}
+			}
+			return nil
+		})
+	}
+	if ctx.Err() == nil {
+		progress.totalBytes.Store(totalBytes)
+		progress.totalTitles.Store(totalTitles)
+	}
+}
+
+// Draws the bar over the last one, with how much has been hashed, how fast, and how long the rest should take.
+func (progress *scanProgress) draw() {
+	done := progress.doneBytes.Load()
+	total := progress.totalBytes.Load()
+	elapsed := time.Since(progress.start)
+	var line string
+	if total < 0 {
+		line = fmt.Sprintf("Scanning... %s hashed, %d titles walked, counting the rest", formatBytes(done), progress.doneTitles.Load())
+	} else {
+		fraction := 1.0
+		if total > 0 {
+			fraction = float64(done) / float64(total)
+		}
+		// Files outside the count, such as saves, are hashed too, so the bar only fills once the scan is over
+		fraction = min(fraction, 0.99)
+		filled := int(fraction * progressBarWidth)
+		line = fmt.Sprintf("[%s%s] %3.0f%% %d/%d titles, %s/%s", strings.Repeat("#", filled), strings.Repeat("-", progressBarWidth-filled),
+			fraction*100, progress.doneTitles.Load(), progress.totalTitles.Load(), formatBytes(done), formatBytes(total))
+		if done > 0 && elapsed > time.Second {
+			remaining := time.Duration(float64(elapsed) * float64(total-done) / float64(done))
+			line += fmt.Sprintf(", ETA %s", max(remaining, 0).Round(time.Second))
+		}
+	}
+	if seconds := elapsed.Seconds(); seconds >= 1 {
+		line += fmt.Sprintf(" (%.1f MB/s)", float64(done)/1e6/seconds)
+	}
+	padding := max(progress.drawn-len(line), 0)
+	fmt.Fprint(os.Stderr, "\r"+line+strings.Repeat(" ", padding))
+	progress.drawn = len(line)
+}
+
+// Blanks out the bar, leaving the cursor at the start of its line.
+func (progress *scanProgress) clear() {
+	if progress.drawn > 0 {
+		fmt.Fprint(os.Stderr, "\r"+strings.Repeat(" ", progress.drawn)+"\r")
+		progress.drawn = 0
+	}
+}
+
+// Clears the progress bar so a message can be printed in its place, returning the function to call once it's
+// printed. The bar is drawn again under the message at its next redraw.
+func pauseProgress() func() {
+	progress := activeProgress.Load()
+	if progress == nil {
+		return func() {}
+	}
+	progress.mu.Lock()
+	progress.clear()
+	return progress.mu.Unlock
+}
+
+// Adds bytes read for hashing, or taken from a cache, to the progress of the scan.
+func progressHashed(n int64) {
+	if progress := activeProgress.Load(); progress != nil {
+		progress.doneBytes.Add(n)
+	}
+}
+
+// Counts a title folder the scan has finished with.
+func progressTitleWalked() {
+	if progress := activeProgress.Load(); progress != nil {
+		progress.doneTitles.Add(1)
+	}
+}
+
+// Returns a size in MB, or GB once it's that big.
+func formatBytes(size int64) string {
+	if size >= 1e9 {
+		return fmt.Sprintf("%.1f GB", float64(size)/1e9)
+	}
+	return fmt.Sprintf("%.1f MB", float64(size)/1e6)
+}
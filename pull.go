@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+// Downloads a file from the console to localPath, reporting it under outPath.
+func pullFile(source *scanSource, filePath, outPath, localPath string) (FileReport, error) {
+	in, err := source.FS.Open(filePath)
+	if err != nil {
+		return FileReport{}, err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(extendedPath(filepath.Dir(localPath)), 0o755); err != nil {
+		return FileReport{}, err
+	}
+	out, err := os.Create(extendedPath(localPath))
+	if err != nil {
+		return FileReport{}, err
+	}
+	fileReport, err := copyHashed(out, in, outPath)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(extendedPath(localPath))
+	}
+	return fileReport, err
+}
+
+// Downloads the unarchived and unknown content found on a console scanned over --ftp into a local folder, laid out
+// as on the console with the manifest a --bundle would hold, ready for submission.
+func pullFindings(dir string, report *ScanReport) error {
+	items := bundleItems(report)
+	if len(items) == 0 {
+		if guiEnabled {
+			addText(theme.ForegroundColor(), "Nothing unarchived to pull")
+		}
+		printInfo(fatihColor.FgWhite, "Nothing unarchived to pull\n")
+		return nil
+	}
+	if err := checkWritable(dir); err != nil {
+		return err
+	}
+	if dryRunFlag {
+		var run dryRun
+		_, err := copyBundleItems(report, items, time.Now(), func(source *scanSource, filePath, outPath string) (FileReport, error) {
+			return run.add("pull", source, filePath, outPath, filepath.Join(dir, filepath.FromSlash(outPath)))
+		})
+		if err != nil {
+			return err
+		}
+		run.summary("pulled from the console to", dir)
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	manifest, err := copyBundleItems(report, items, time.Now(), func(source *scanSource, filePath, outPath string) (FileReport, error) {
+		if guiEnabled {
+			addText(theme.ForegroundColor(), "Pulling %s", source.displayPath(filePath))
+		}
+		printInfo(fatihColor.FgWhite, "Pulling %s\n", source.displayPath(filePath))
+		return pullFile(source, filePath, outPath, filepath.Join(dir, filepath.FromSlash(outPath)))
+	})
+	if err != nil {
+		return err
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, bundleManifestName), manifestData, 0o644); err != nil {
+		return err
+	}
+
+	if guiEnabled {
+		addText(theme.PrimaryColorNamed(theme.ColorGreen), "%d items pulled from the console to: %s", len(manifest.Items), dir)
+	}
+	printInfo(fatihColor.FgGreen, "%d items pulled from the console to: %s\n", len(manifest.Items), dir)
+	return nil
+}
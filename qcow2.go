@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// qcow2 header offsets and table entry flags, as used by Xemu's virtual hard drives.
+const (
+	qcow2Magic                  = "QFI\xfb"
+	qcow2HeaderSize             = 104
+	qcow2VersionOffset          = 4
+	qcow2BackingFileOffset      = 8
+	qcow2ClusterBitsOffset      = 20
+	qcow2SizeOffset             = 24
+	qcow2CryptMethodOffset      = 32
+	qcow2L1SizeOffset           = 36
+	qcow2L1TableOffset          = 40
+	qcow2IncompatibleOffset     = 72
+	qcow2OffsetMask             = 0x00FFFFFFFFFFFE00
+	qcow2CompressedFlag         = 1 << 62
+	qcow2ZeroFlag               = 1
+	qcow2SupportedIncompatibles = 0x3 // Dirty and corrupt bits only affect writing
+	qcow2CompressedSectorSize   = 512
+)
+
+// Qcow2Image exposes the virtual disk of a qcow2 file as an io.ReaderAt.
+type Qcow2Image struct {
+	r           io.ReaderAt
+	size        int64
+	clusterBits uint32
+	l1          []uint64
+
+	mu       sync.Mutex
+	l2Tables map[uint64][]uint64
+}
+
+// Reports whether the data at the start of a file is a qcow2 header.
+func isQcow2(header []byte) bool {
+	return bytes.HasPrefix(header, []byte(qcow2Magic))
+}
+
+func openQcow2(r io.ReaderAt) (*Qcow2Image, error) {
+	header := make([]byte, qcow2HeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if !isQcow2(header) {
+		return nil, fmt.Errorf("not a qcow2 image")
+	}
+
+	version := binary.BigEndian.Uint32(header[qcow2VersionOffset:])
+	if version != 2 && version != 3 {
+		return nil, fmt.Errorf("unsupported qcow2 version %d", version)
+	}
+	if binary.BigEndian.Uint64(header[qcow2BackingFileOffset:]) != 0 {
+		return nil, fmt.Errorf("qcow2 images with a backing file are not supported")
+	}
+	if binary.BigEndian.Uint32(header[qcow2CryptMethodOffset:]) != 0 {
+		return nil, fmt.Errorf("encrypted qcow2 images are not supported")
+	}
+	if version == 3 {
+		if features := binary.BigEndian.Uint64(header[qcow2IncompatibleOffset:]); features&^qcow2SupportedIncompatibles != 0 {
+			return nil, fmt.Errorf("unsupported qcow2 features 0x%x", features)
+		}
+	}
+
+	img := &Qcow2Image{
+		r:           r,
+		size:        int64(binary.BigEndian.Uint64(header[qcow2SizeOffset:])),
+		clusterBits: binary.BigEndian.Uint32(header[qcow2ClusterBitsOffset:]),
+		l2Tables:    make(map[uint64][]uint64),
+	}
+	if img.clusterBits < 9 || img.clusterBits > 21 {
+		return nil, fmt.Errorf("invalid qcow2 cluster size 2^%d", img.clusterBits)
+	}
+
+	l1Size := binary.BigEndian.Uint32(header[qcow2L1SizeOffset:])
+	l1, err := img.readTable(binary.BigEndian.Uint64(header[qcow2L1TableOffset:]), int(l1Size))
+	if err != nil {
+		return nil, fmt.Errorf("error reading qcow2 L1 table: %v", err)
+	}
+	img.l1 = l1
+	return img, nil
+}
+
+func (img *Qcow2Image) readTable(offset uint64, entries int) ([]uint64, error) {
+	raw := make([]byte, entries*8)
+	if _, err := img.r.ReadAt(raw, int64(offset)); err != nil {
+		return nil, err
+	}
+	table := make([]uint64, entries)
+	for i := range table {
+		table[i] = binary.BigEndian.Uint64(raw[i*8:])
+	}
+	return table, nil
+}
+
+// Size returns the size of the virtual disk.
+func (img *Qcow2Image) Size() int64 {
+	return img.size
+}
+
+// Returns the L2 entry describing the cluster holding a virtual disk offset, or 0 if it isn't allocated.
+func (img *Qcow2Image) l2Entry(offset int64) (uint64, error) {
+	l2Bits := img.clusterBits - 3
+	l1Index := uint64(offset) >> (img.clusterBits + l2Bits)
+	if l1Index >= uint64(len(img.l1)) {
+		return 0, nil
+	}
+	l2Offset := img.l1[l1Index] & qcow2OffsetMask
+	if l2Offset == 0 {
+		return 0, nil
+	}
+
+	img.mu.Lock()
+	defer img.mu.Unlock()
+	l2, ok := img.l2Tables[l2Offset]
+	if !ok {
+		var err error
+		l2, err = img.readTable(l2Offset, 1<<l2Bits)
+		if err != nil {
+			return 0, fmt.Errorf("error reading qcow2 L2 table: %v", err)
+		}
+		img.l2Tables[l2Offset] = l2
+	}
+	return l2[uint64(offset)>>img.clusterBits&(1<<l2Bits-1)], nil
+}
+
+// Inflates a compressed cluster.
+func (img *Qcow2Image) readCompressed(entry uint64, clusterSize int64) ([]byte, error) {
+	offsetBits := 62 - (img.clusterBits - 8)
+	hostOffset := entry & (1<<offsetBits - 1)
+	sectors := entry>>offsetBits&(1<<(62-offsetBits)-1) + 1
+	compressed := make([]byte, sectors*qcow2CompressedSectorSize-hostOffset%qcow2CompressedSectorSize)
+	n, err := img.r.ReadAt(compressed, int64(hostOffset))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	data := make([]byte, clusterSize)
+	if _, err := io.ReadFull(flate.NewReader(bytes.NewReader(compressed[:n])), data); err != nil {
+		return nil, fmt.Errorf("error inflating qcow2 cluster: %v", err)
+	}
+	return data, nil
+}
+
+// ReadAt reads from the virtual disk, returning zeros for unallocated clusters.
+func (img *Qcow2Image) ReadAt(b []byte, off int64) (int, error) {
+	if off >= img.size {
+		return 0, io.EOF
+	}
+	eof := false
+	if remaining := img.size - off; int64(len(b)) > remaining {
+		b = b[:remaining]
+		eof = true
+	}
+
+	clusterSize := int64(1) << img.clusterBits
+	read := 0
+	for read < len(b) {
+		position := off + int64(read)
+		within := position % clusterSize
+		chunk := b[read:]
+		if int64(len(chunk)) > clusterSize-within {
+			chunk = chunk[:clusterSize-within]
+		}
+
+		entry, err := img.l2Entry(position)
+		if err != nil {
+			return read, err
+		}
+		switch {
+		case entry&qcow2CompressedFlag != 0:
+			data, err := img.readCompressed(entry, clusterSize)
+			if err != nil {
+				return read, err
+			}
+			copy(chunk, data[within:])
+		case entry&qcow2OffsetMask == 0 || entry&qcow2ZeroFlag != 0:
+			clear(chunk)
+		default:
+			if _, err := img.r.ReadAt(chunk, int64(entry&qcow2OffsetMask)+within); err != nil {
+				return read, err
+			}
+		}
+		read += len(chunk)
+	}
+	if eof {
+		return read, io.EOF
+	}
+	return read, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Everything Pinecone scans is read through fs.FS or os.Open, which can't write, so --read-only only has to keep
+// the files Pinecone writes itself, such as reports and exports, out of the dump, and refuse the operations that
+// need a drive opened for writing.
+
+// Returns the paths being scanned: dump folders and archives, an HDD image or device, or the drives FatXplorer mounted.
+func scanRoots() []string {
+	switch {
+	case imageFlag != "":
+		return []string{imageFlag}
+	case fatxplorer:
+		drives, _ := fatXplorerDrives()
+		var roots []string
+		for _, drive := range drives {
+			roots = append(roots, drive.Root)
+		}
+		return roots
+	case isoFlag != "":
+		return []string{isoFlag}
+	case ftpFlag != "":
+		// Nothing written locally can end up on the console
+		return nil
+	}
+	return scanLocations()
+}
+
+// Returns path made absolute with its symlinks resolved, as far as it exists, so a link can't lead a write into the
+// dump.
+func resolvePath(p string) string {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return p
+	}
+	rest := ""
+	for dir := abs; ; {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return abs
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+		dir = parent
+	}
+}
+
+// Reports whether target is one of the scanned paths or inside one.
+func insideScanRoots(target string) bool {
+	target = resolvePath(target)
+	for _, root := range scanRoots() {
+		rel, err := filepath.Rel(resolvePath(root), target)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Refuses to write target with --read-only when it would land inside the dump being scanned.
+func checkWritable(target string) error {
+	if readOnlyFlag && insideScanRoots(target) {
+		return fmt.Errorf("--read-only: refusing to write %s inside the scanned dump", target)
+	}
+	return nil
+}
+
+// Refuses an operation that needs the dump opened for writing with --read-only.
+func checkReadOnly(operation string) error {
+	if readOnlyFlag {
+		return fmt.Errorf("--read-only: refusing to %s", operation)
+	}
+	return nil
+}
+
+// Returns every file or folder the current settings would write: reports, exports, caches, the log and the
+// checkpoint.
+func plannedOutputs() []string {
+	outputs := []string{outputFlag, csvFlag, htmlFlag, exportMDFlag, datFlag, pdfFlag, thumbnailsFlag, soundtracksFlag, bundleFlag, pullFlag, issueFlag, historyFlag, hashManifestFlag, hashCacheFlag, logFileFlag}
+	if layoutExport != nil {
+		outputs = append(outputs, layoutExport.dir)
+	}
+	return append(outputs, checkpointPath())
+}
+
+// Checks every file the current settings would write before the scan starts, so a refused write doesn't waste a
+// scan.
+func validateReadOnly() error {
+	for _, output := range plannedOutputs() {
+		if output == "" {
+			continue
+		}
+		if err := checkWritable(output); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Scans dir with --read-only for the rest of the test, with the data folder outside it.
+func readOnlyScan(t *testing.T, dir string) {
+	t.Helper()
+	savedReadOnly, savedDump, savedLocations := readOnlyFlag, dumpLocation, locationsFlag
+	savedImage, savedISO, savedFTP, savedFatXplorer, savedDataPath := imageFlag, isoFlag, ftpFlag, fatxplorer, dataPath
+	t.Cleanup(func() {
+		readOnlyFlag, dumpLocation, locationsFlag = savedReadOnly, savedDump, savedLocations
+		imageFlag, isoFlag, ftpFlag, fatxplorer, dataPath = savedImage, savedISO, savedFTP, savedFatXplorer, savedDataPath
+	})
+	readOnlyFlag, dumpLocation, locationsFlag = true, dir, nil
+	imageFlag, isoFlag, ftpFlag, fatxplorer = "", "", "", false
+	dataPath = t.TempDir()
+}
+
+// Returns a dump folder with an empty TDATA folder inside a temporary folder, and a folder next to it.
+func makeDump(t *testing.T) (string, string) {
+	t.Helper()
+	root := t.TempDir()
+	dump := filepath.Join(root, "dump")
+	outside := filepath.Join(root, "reports")
+	for _, dir := range []string{filepath.Join(dump, tdataFolder), outside} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dump, outside
+}
+
+func TestCheckWritable(t *testing.T) {
+	dump, outside := makeDump(t)
+	readOnlyScan(t, dump)
+
+	for _, target := range []string{
+		dump,
+		filepath.Join(dump, "report.json"),
+		filepath.Join(dump, tdataFolder, "4d530004", "report.json"),
+		filepath.Join(outside, "..", "dump", "report.json"),
+	} {
+		if err := checkWritable(target); err == nil {
+			t.Errorf("checkWritable(%s) allowed a write inside the dump", target)
+		}
+	}
+	for _, target := range []string{
+		filepath.Join(outside, "report.json"),
+		filepath.Join(outside, "new", "report.json"),
+		dump + "-report.json",
+	} {
+		if err := checkWritable(target); err != nil {
+			t.Errorf("checkWritable(%s) = %v, want nil", target, err)
+		}
+	}
+
+	readOnlyFlag = false
+	if err := checkWritable(filepath.Join(dump, "report.json")); err != nil {
+		t.Errorf("checkWritable without --read-only = %v, want nil", err)
+	}
+}
+
+func TestCheckWritableSymlinkedFolder(t *testing.T) {
+	dump, outside := makeDump(t)
+	readOnlyScan(t, dump)
+	link := filepath.Join(outside, "link")
+	if err := os.Symlink(filepath.Join(dump, tdataFolder), link); err != nil {
+		t.Skipf("can't make symlinks: %v", err)
+	}
+
+	if err := checkWritable(filepath.Join(link, "report.json")); err == nil {
+		t.Error("checkWritable allowed a write through a symlink into the dump")
+	}
+	if err := checkWritable(filepath.Join(link, "new", "report.json")); err == nil {
+		t.Error("checkWritable allowed a write through a symlink into a new folder of the dump")
+	}
+
+	// The dump itself reached through a link is still the dump
+	linkedDump := filepath.Join(outside, "linked-dump")
+	if err := os.Symlink(dump, linkedDump); err != nil {
+		t.Fatal(err)
+	}
+	dumpLocation = linkedDump
+	if err := checkWritable(filepath.Join(dump, "report.json")); err == nil {
+		t.Error("checkWritable allowed a write into a dump scanned through a symlink")
+	}
+}
+
+func TestCheckWritableArchiveAndImageRoots(t *testing.T) {
+	root := t.TempDir()
+	archive := filepath.Join(root, "dump.zip")
+	image := filepath.Join(root, "xbox.img")
+	for _, file := range []string{archive, image} {
+		if err := os.WriteFile(file, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	readOnlyScan(t, archive)
+	if err := checkWritable(archive); err == nil {
+		t.Error("checkWritable allowed overwriting the archive being scanned")
+	}
+	if err := checkWritable(filepath.Join(root, "report.json")); err != nil {
+		t.Errorf("checkWritable next to the archive = %v, want nil", err)
+	}
+
+	imageFlag = image
+	if err := checkWritable(image); err == nil {
+		t.Error("checkWritable allowed overwriting the image being scanned")
+	}
+	if err := checkWritable(archive); err != nil {
+		t.Errorf("checkWritable of a folder that isn't scanned with -image = %v, want nil", err)
+	}
+}
+
+func TestValidateReadOnly(t *testing.T) {
+	dump, outside := makeDump(t)
+	readOnlyScan(t, dump)
+	savedLayout := layoutExport
+	t.Cleanup(func() { layoutExport = savedLayout })
+	layoutExport = nil
+
+	outputs := map[string]*string{
+		"-o":                &outputFlag,
+		"--csv":             &csvFlag,
+		"--html":            &htmlFlag,
+		"--md":              &exportMDFlag,
+		"--dat":             &datFlag,
+		"--pdf":             &pdfFlag,
+		"--thumbnails":      &thumbnailsFlag,
+		"--soundtracks":     &soundtracksFlag,
+		"--bundle":          &bundleFlag,
+		"--pull":            &pullFlag,
+		"--issue":           &issueFlag,
+		"--history":         &historyFlag,
+		"--hash-manifest":   &hashManifestFlag,
+		"--hash-cache":      &hashCacheFlag,
+		"--log-file":        &logFileFlag,
+		"export folder":     nil,
+		"checkpoint folder": &dataPath,
+	}
+	for name, output := range outputs {
+		var saved string
+		if output != nil {
+			saved = *output
+			*output = filepath.Join(dump, "output")
+		} else {
+			layoutExport = &archiveLayout{dir: filepath.Join(dump, "output")}
+		}
+		if err := validateReadOnly(); err == nil {
+			t.Errorf("validateReadOnly allowed %s inside the dump", name)
+		}
+		if output != nil {
+			*output = filepath.Join(outside, "output")
+		} else {
+			layoutExport = &archiveLayout{dir: filepath.Join(outside, "output")}
+		}
+		if err := validateReadOnly(); err != nil {
+			t.Errorf("validateReadOnly refused %s outside the dump: %v", name, err)
+		}
+		if output != nil {
+			*output = saved
+		} else {
+			layoutExport = nil
+		}
+	}
+}
+
+func TestCheckDataFolder(t *testing.T) {
+	dump, outside := makeDump(t)
+	readOnlyScan(t, dump)
+
+	inside := filepath.Join(dump, "data")
+	if err := checkDataFolder(inside); err == nil {
+		t.Error("checkDataFolder created a data folder inside the dump")
+	}
+	if _, err := os.Stat(inside); !os.IsNotExist(err) {
+		t.Errorf("data folder inside the dump exists after a refused write: %v", err)
+	}
+	if err := checkDataFolder(filepath.Join(outside, "data")); err != nil {
+		t.Errorf("checkDataFolder outside the dump = %v, want nil", err)
+	}
+}
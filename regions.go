@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strings"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+// RegionCount is the number of XBEs found for a region in a scan.
+type RegionCount struct {
+	Region string `json:"region" xml:"name,attr"`
+	XBEs   int    `json:"xbes" xml:"xbes"`
+}
+
+// Prints the region and media flags of an XBE's certificate.
+func printXBEFlags(xbe *XBEReport) {
+	regions := "none"
+	if len(xbe.Regions) > 0 {
+		regions = strings.Join(xbe.Regions, ", ")
+	}
+	media := "none"
+	if len(xbe.Media) > 0 {
+		media = strings.Join(xbe.Media, ", ")
+	}
+	if guiEnabled {
+		addText(theme.ForegroundColor(), "Regions: %s, media: %s", regions, media)
+	}
+	printInfo(fatihColor.FgWhite, "Regions: %s, media: %s\n", regions, media)
+}
+
+// Counts the XBEs found in a scan by region, so the report shows which regions a dump's content comes from.
+func summarizeRegions() {
+	var xbes []*XBEReport
+	for _, title := range scanResults.Titles {
+		for _, update := range title.Updates {
+			xbes = append(xbes, update.XBE)
+		}
+		if title.Unknown != nil {
+			for _, found := range title.Unknown.XBEs {
+				xbes = append(xbes, found.XBE)
+			}
+		}
+	}
+	for _, disc := range scanResults.Discs {
+		xbes = append(xbes, disc.XBE)
+	}
+	for _, software := range scanResults.Software {
+		xbes = append(xbes, software.XBE)
+	}
+
+	counts := make(map[string]int)
+	for _, xbe := range xbes {
+		if xbe == nil {
+			continue
+		}
+		for _, region := range xbe.Regions {
+			counts[region]++
+		}
+	}
+	if len(counts) == 0 {
+		return
+	}
+
+	if guiEnabled {
+		addHeader("Region Profile")
+	}
+	printHeader("Region Profile")
+	// Regions are listed in certificate flag order
+	for _, region := range xbeRegionNames {
+		if counts[region.name] == 0 {
+			continue
+		}
+		scanResults.Regions = append(scanResults.Regions, RegionCount{Region: region.name, XBEs: counts[region.name]})
+		if guiEnabled {
+			addText(theme.ForegroundColor(), "%s XBEs: %d", region.name, counts[region.name])
+		}
+		printInfo(fatihColor.FgWhite, "%s XBEs: %d\n", region.name, counts[region.name])
+	}
+}
@@ -0,0 +1,422 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"fyne.io/fyne/v2/theme"
+)
+
+// ScanReport holds the structured results of a content scan.
+type ScanReport struct {
+	XMLName     xml.Name           `json:"-" xml:"pineconeReport"`
+	Version     string             `json:"version" xml:"version,attr"`
+	Location    string             `json:"location" xml:"location"`
+	Titles      []TitleReport      `json:"titles" xml:"titles>title"`
+	Discs       []DiscReport       `json:"discs,omitempty" xml:"discs>disc,omitempty"`
+	Caches      []CacheReport      `json:"caches,omitempty" xml:"caches>cache,omitempty"`
+	Software    []SoftwareReport   `json:"software,omitempty" xml:"software>xbe,omitempty"`
+	Softmods    []SoftmodReport    `json:"softmods,omitempty" xml:"softmods>softmod,omitempty"`
+	Homebrew    []HomebrewReport   `json:"homebrew,omitempty" xml:"homebrew>title,omitempty"`
+	Saves       []SaveReport       `json:"saves,omitempty" xml:"saves>title,omitempty"`
+	Xbox360     []Xbox360Content   `json:"xbox360,omitempty" xml:"xbox360>content,omitempty"`
+	Soundtracks []SoundtrackReport `json:"soundtracks,omitempty" xml:"soundtracks>soundtrack,omitempty"`
+	Artifacts   []ArtifactReport   `json:"artifacts,omitempty" xml:"artifacts>artifact,omitempty"`
+	// Regions counts the XBEs found for each region
+	Regions []RegionCount `json:"regions,omitempty" xml:"regions>region,omitempty"`
+	// DuplicateUpdates are updates found under several titles or under the wrong one
+	DuplicateUpdates []DuplicateUpdateReport `json:"duplicateUpdates,omitempty" xml:"duplicateUpdates>update,omitempty"`
+	// Missing are the known content and updates of the titles found that aren't on the drive
+	Missing []MissingReport `json:"missing,omitempty" xml:"missing>title,omitempty"`
+	// Databases are the revisions of the databases the scan identified content with
+	Databases []DatabaseReport `json:"databases,omitempty" xml:"databases>database,omitempty"`
+	// Errors are the files and folders the scan couldn't read and skipped
+	Errors []ErrorReport `json:"errors,omitempty" xml:"errors>error,omitempty"`
+	// Links are the symbolic links and junctions found in dump folders, and whether they were followed
+	Links []LinkReport `json:"links,omitempty" xml:"links>link,omitempty"`
+
+	// The sources scanned to produce the report, used to read files back when exporting
+	sources []*scanSource
+}
+
+// DatabaseReport identifies the revision of a database used for a scan.
+type DatabaseReport struct {
+	Source string `json:"source" xml:"source,attr"`
+	// Revision is the git blob SHA1 of the database file, or of the index of a sharded one, which git log
+	// --find-object turns back into the commit it came from
+	Revision string `json:"revision" xml:"revision,attr"`
+	Version  string `json:"version,omitempty" xml:"version,attr,omitempty"`
+	// Pinned is the revision asked for with --db-version
+	Pinned string `json:"pinned,omitempty" xml:"pinned,attr,omitempty"`
+}
+
+func (d DatabaseReport) String() string {
+	revision := d.Revision
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if d.Version != "" {
+		revision = d.Version + " (" + revision + ")"
+	}
+	return revision + " from " + d.Source
+}
+
+// TitleReport describes a single titleID directory found during a scan.
+type TitleReport struct {
+	TitleID   string          `json:"titleID" xml:"titleID,attr"`
+	TitleName string          `json:"titleName,omitempty" xml:"titleName,omitempty"`
+	Known     bool            `json:"known" xml:"known,attr"`
+	Path      string          `json:"path" xml:"path"`
+	Source    string          `json:"source,omitempty" xml:"source,attr,omitempty"`
+	Platform  string          `json:"platform,omitempty" xml:"platform,attr,omitempty"`
+	Thumbnail string          `json:"thumbnail,omitempty" xml:"thumbnail,omitempty"`
+	Content   []ContentReport `json:"content,omitempty" xml:"content,omitempty"`
+	Updates   []UpdateReport  `json:"updates,omitempty" xml:"update,omitempty"`
+	// DataFiles are the files the title keeps outside its reserved folders, inventoried with --deep
+	DataFiles []FileReport `json:"dataFiles,omitempty" xml:"dataFile,omitempty"`
+	// Reserved are the title's other reserved folders, such as $t
+	Reserved []ReservedFolderReport `json:"reserved,omitempty" xml:"reserved,omitempty"`
+	// UpdateVersion is set for titles the database knows updates for
+	UpdateVersion *UpdateVersionReport `json:"updateVersion,omitempty" xml:"updateVersion,omitempty"`
+	Unknown       *UnknownTitleReport  `json:"unknown,omitempty" xml:"unknown,omitempty"`
+}
+
+// ContentReport describes a DLC item found in a $c directory.
+type ContentReport struct {
+	ContentID string `json:"contentID" xml:"contentID,attr"`
+	Name      string `json:"name,omitempty" xml:"name,omitempty"`
+	Path      string `json:"path" xml:"path"`
+	Known     bool   `json:"known" xml:"known,attr"`
+	Archived  bool   `json:"archived" xml:"archived,attr"`
+	Class     string `json:"class,omitempty" xml:"class,attr,omitempty"`
+	// Insignia is set for content downloaded from the Insignia Xbox Live revival rather than the original Xbox Live
+	Insignia bool `json:"insignia,omitempty" xml:"insignia,attr,omitempty"`
+	// Verified is set when every file matches the hashes the database holds for the archived copy
+	Verified   bool         `json:"verified,omitempty" xml:"verified,attr,omitempty"`
+	Files      []FileReport `json:"files,omitempty" xml:"file,omitempty"`
+	Mismatches []string     `json:"mismatches,omitempty" xml:"mismatch,omitempty"`
+
+	Thumbnail string       `json:"thumbnail,omitempty" xml:"thumbnail,omitempty"`
+	Meta      *ContentMeta `json:"meta,omitempty" xml:"meta,omitempty"`
+	Warnings  []string     `json:"warnings,omitempty" xml:"warning,omitempty"`
+}
+
+// FileReport is a single hashed file, such as a file on a disc image or inside a DLC folder.
+type FileReport struct {
+	Path  string `json:"path" xml:"path"`
+	Size  int64  `json:"size" xml:"size"`
+	SHA1  string `json:"sha1,omitempty" xml:"sha1,omitempty"`
+	MD5   string `json:"md5,omitempty" xml:"md5,omitempty"`
+	CRC32 string `json:"crc32,omitempty" xml:"crc32,omitempty"`
+	XXH64 string `json:"xxh64,omitempty" xml:"xxh64,omitempty"`
+}
+
+func newFileReport(path string, size int64, hashes FileHashes) FileReport {
+	return FileReport{Path: path, Size: size, SHA1: hashes.SHA1, MD5: hashes.MD5, CRC32: hashes.CRC32, XXH64: hashes.XXH64}
+}
+
+// UpdateReport describes a title update XBE found in a $u directory.
+type UpdateReport struct {
+	Name     string     `json:"name,omitempty" xml:"name,omitempty"`
+	Path     string     `json:"path" xml:"path"`
+	SHA1     string     `json:"sha1,omitempty" xml:"sha1,omitempty"`
+	MD5      string     `json:"md5,omitempty" xml:"md5,omitempty"`
+	CRC32    string     `json:"crc32,omitempty" xml:"crc32,omitempty"`
+	XXH64    string     `json:"xxh64,omitempty" xml:"xxh64,omitempty"`
+	Size     int64      `json:"size" xml:"size"`
+	Known    bool       `json:"known" xml:"known,attr"`
+	Archived bool       `json:"archived" xml:"archived,attr"`
+	XBE      *XBEReport `json:"xbe,omitempty" xml:"xbe,omitempty"`
+	// Integrity is "truncated" or "corrupted" when the XBE doesn't match its own headers
+	Integrity string `json:"integrity,omitempty" xml:"integrity,attr,omitempty"`
+	// KnownBad is the database's reason for listing the update as a bad dump
+	KnownBad string   `json:"knownBad,omitempty" xml:"knownBad,omitempty"`
+	Warnings []string `json:"warnings,omitempty" xml:"warning,omitempty"`
+	Error    string   `json:"error,omitempty" xml:"error,omitempty"`
+}
+
+// XBEReport holds the certificate details of a scanned XBE.
+type XBEReport struct {
+	TitleID   string   `json:"titleID" xml:"titleID,attr"`
+	TitleName string   `json:"titleName" xml:"titleName"`
+	Version   uint32   `json:"version" xml:"version"`
+	Regions   []string `json:"regions,omitempty" xml:"region,omitempty"`
+	Media     []string `json:"media,omitempty" xml:"media,omitempty"`
+}
+
+func newXBEReport(xbe *XBEInfo) *XBEReport {
+	return &XBEReport{
+		TitleID:   xbe.TitleID,
+		TitleName: xbe.TitleName,
+		Version:   xbe.Version,
+		Regions:   xbe.Regions(),
+		Media:     xbe.Media(),
+	}
+}
+
+// ReportItem is a single discovered item, flattened for tabular exports.
+type ReportItem struct {
+	TitleID   string
+	TitleName string
+	Source    string
+	ContentID string
+	Name      string
+	Path      string
+	SHA1      string
+	MD5       string
+	CRC32     string
+	Known     bool
+	Archived  bool
+	Integrity string
+	KnownBad  bool
+	// Insignia marks content downloaded from the Insignia revival, counted apart from original Xbox Live content
+	Insignia bool
+	Type     string
+}
+
+const (
+	itemTypeTitle   = "unknown title"
+	itemTypeContent = "content"
+	itemTypeUpdate  = "update"
+
+	statusArchived   = "archived"
+	statusUnarchived = "unarchived"
+	statusUnknown    = "unknown"
+	statusKnownBad   = "known bad"
+)
+
+// Flattens the report into one item per unknown title, content and update.
+func reportItems(report *ScanReport) []ReportItem {
+	var items []ReportItem
+	for _, title := range report.Titles {
+		// Paths are qualified with the source when a scan covers more than one, such as HDD image partitions
+		itemPath := func(p string) string {
+			if title.Source == "" {
+				return p
+			}
+			return title.Source + "/" + p
+		}
+		if !title.Known {
+			item := ReportItem{TitleID: title.TitleID, Source: title.Source, Path: itemPath(title.Path), Type: itemTypeTitle}
+			if title.Unknown != nil && title.Unknown.ProbableName != "" {
+				item.TitleName = "probably " + title.Unknown.ProbableName
+			}
+			items = append(items, item)
+		}
+		for _, content := range title.Content {
+			name := content.Name
+			if name == "" && content.Meta != nil {
+				name = content.Meta.DisplayName
+			}
+			items = append(items, ReportItem{
+				TitleID:   title.TitleID,
+				TitleName: title.TitleName,
+				Source:    title.Source,
+				ContentID: content.ContentID,
+				Name:      name,
+				Path:      itemPath(content.Path),
+				Known:     content.Known,
+				Archived:  content.Archived,
+				Insignia:  content.Insignia,
+				Type:      itemTypeContent,
+			})
+		}
+		for _, update := range title.Updates {
+			items = append(items, ReportItem{
+				TitleID:   title.TitleID,
+				TitleName: title.TitleName,
+				Source:    title.Source,
+				Name:      update.Name,
+				Path:      itemPath(update.Path),
+				SHA1:      update.SHA1,
+				MD5:       update.MD5,
+				CRC32:     update.CRC32,
+				Known:     update.Known,
+				Archived:  update.Archived,
+				Integrity: update.Integrity,
+				KnownBad:  update.KnownBad != "",
+				Type:      itemTypeUpdate,
+			})
+		}
+	}
+	return items
+}
+
+// Status returns a short human readable state for the item.
+func (item ReportItem) Status() string {
+	switch {
+	case item.Archived:
+		return statusArchived
+	case item.Known:
+		return statusUnarchived
+	case item.KnownBad:
+		return statusKnownBad
+	case item.Integrity != "":
+		// Damaged files are reported apart from merely unknown ones
+		return item.Integrity
+	default:
+		return statusUnknown
+	}
+}
+
+// reportPath returns path relative to the scanned directory, using forward slashes.
+func reportPath(directory string, path string) string {
+	rel, err := filepath.Rel(directory, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Sorts titles by titleID, content by contentID and updates by path so consecutive scans produce identical output.
+func sortReport(report *ScanReport) {
+	sort.SliceStable(report.Titles, func(i, j int) bool {
+		if report.Titles[i].TitleID != report.Titles[j].TitleID {
+			return report.Titles[i].TitleID < report.Titles[j].TitleID
+		}
+		if report.Titles[i].Source != report.Titles[j].Source {
+			return report.Titles[i].Source < report.Titles[j].Source
+		}
+		return report.Titles[i].Path < report.Titles[j].Path
+	})
+	for i := range report.Titles {
+		content := report.Titles[i].Content
+		sort.SliceStable(content, func(a, b int) bool {
+			if content[a].ContentID != content[b].ContentID {
+				return content[a].ContentID < content[b].ContentID
+			}
+			return content[a].Path < content[b].Path
+		})
+		updates := report.Titles[i].Updates
+		sort.SliceStable(updates, func(a, b int) bool {
+			return updates[a].Path < updates[b].Path
+		})
+	}
+}
+
+func writeJSONReport(outputPath string, report *ScanReport) error {
+	if err := checkWritable(outputPath); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(report, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outputPath, append(data, '\n'), 0o644)
+}
+
+func writeXMLReport(outputPath string, report *ScanReport) error {
+	if err := checkWritable(outputPath); err != nil {
+		return err
+	}
+	data, err := xml.MarshalIndent(report, "", "    ")
+	if err != nil {
+		return err
+	}
+	output := xml.Header + string(data) + "\n"
+	return os.WriteFile(outputPath, []byte(output), 0o644)
+}
+
+type reportWriter func(outputPath string, report *ScanReport) error
+
+// reportFormats are the formats that can be selected for --output with --format.
+var reportFormats = map[string]reportWriter{
+	"json": writeJSONReport,
+	"xml":  writeXMLReport,
+	"csv":  writeCSVReport,
+	"html": writeHTMLReport,
+	"md":   writeMarkdownReport,
+	"dat":  writeDATReport,
+	"pdf":  writePDFReport,
+}
+
+// Writes the results of the last scan to any output files and history database requested by flags.
+func exportReports() error {
+	sortReport(&scanResults)
+
+	if thumbnailsFlag != "" {
+		err := exportThumbnails(thumbnailsFlag, &scanResults)
+		if err != nil {
+			return fmt.Errorf("error exporting images: %v", err)
+		}
+	}
+	if soundtracksFlag != "" {
+		err := exportSoundtracks(soundtracksFlag, &scanResults)
+		if err != nil {
+			return fmt.Errorf("error exporting soundtracks: %v", err)
+		}
+	}
+	if bundleFlag != "" {
+		err := writeBundle(bundleFlag, &scanResults)
+		if err != nil {
+			return fmt.Errorf("error writing submission bundle: %v", err)
+		}
+	} else if submitFlag == "" && pullFlag == "" && issueFlag == "" && !openIssueFlag && backupDestination == nil {
+		offerBundle(&scanResults)
+	}
+	if submitFlag != "" {
+		err := submitFindings(submitFlag, &scanResults)
+		if err != nil {
+			return fmt.Errorf("error submitting findings: %v", err)
+		}
+	}
+	if pullFlag != "" {
+		err := pullFindings(pullFlag, &scanResults)
+		if err != nil {
+			return fmt.Errorf("error pulling content: %v", err)
+		}
+	}
+	if backupDestination != nil {
+		err := backupFindings(backupDestination, &scanResults)
+		if err != nil {
+			return fmt.Errorf("error backing up content: %v", err)
+		}
+	}
+	if layoutExport != nil {
+		err := layoutExport.export(&scanResults)
+		if err != nil {
+			return fmt.Errorf("error exporting content: %v", err)
+		}
+	}
+	if issueFlag != "" || openIssueFlag {
+		err := draftIssue(issueFlag, openIssueFlag, &scanResults)
+		if err != nil {
+			return fmt.Errorf("error drafting issue: %v", err)
+		}
+	}
+
+	outputs := []struct {
+		path  string
+		write reportWriter
+	}{
+		{outputFlag, reportFormats[formatFlag]},
+		{csvFlag, writeCSVReport},
+		{htmlFlag, writeHTMLReport},
+		{exportMDFlag, writeMarkdownReport},
+		{datFlag, writeDATReport},
+		{pdfFlag, writePDFReport},
+	}
+
+	for _, output := range outputs {
+		if output.path == "" {
+			continue
+		}
+		err := output.write(output.path, &scanResults)
+		if err != nil {
+			return fmt.Errorf("error writing report: %v", err)
+		}
+		if guiEnabled {
+			addText(theme.ForegroundColor(), "Report saved to: %s", output.path)
+		}
+		printLine("Report saved to: %s", output.path)
+	}
+
+	if historyFlag != "" {
+		return recordHistory(historyFlag, &scanResults)
+	}
+	return nil
+}
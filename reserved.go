@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+// ReservedFolderReport is a reserved folder of a title other than $c and $u, such as $t, where some titles keep
+// Live-related data.
+type ReservedFolderReport struct {
+	Name  string       `json:"name" xml:"name,attr"`
+	Path  string       `json:"path" xml:"path"`
+	Files []FileReport `json:"files,omitempty" xml:"file,omitempty"`
+}
+
+// Hashes the ordinary files a title keeps outside its reserved folders, such as settings, caches and downloaded
+// rosters.
+func inventoryTitleFiles(ctx context.Context, source *scanSource, titleDir string, titleReport *TitleReport) error {
+	err := fs.WalkDir(source.FS, titleDir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if filePath != titleDir && (path.Dir(filePath) == titleDir && strings.HasPrefix(d.Name(), "$") || isIgnored(filePath)) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if isIgnored(filePath) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		hash := getFileHashes
+		if fastHashFlag {
+			hash = getQuickHash
+		}
+		hashes, err := hash(ctx, source.FS, filePath)
+		if err != nil {
+			return err
+		}
+		titleReport.DataFiles = append(titleReport.DataFiles, newFileReport(reportPath(titleDir, filePath), info.Size(), hashes))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error inventorying %s: %v", source.displayPath(titleDir), err)
+	}
+
+	var size int64
+	for _, file := range titleReport.DataFiles {
+		size += file.Size
+	}
+	if guiEnabled {
+		addText(theme.ForegroundColor(), "%d title data files (%.1f MB)", len(titleReport.DataFiles), float64(size)/1e6)
+	}
+	printInfo(fatihColor.FgWhite, "%d title data files (%.1f MB)\n", len(titleReport.DataFiles), float64(size)/1e6)
+	return nil
+}
+
+// Hashes the files of every reserved folder of a title other than $c and $u into the report.
+func processReservedFolders(ctx context.Context, source *scanSource, titleDir string, directory string, titleReport *TitleReport) error {
+	entries, err := fs.ReadDir(source.FS, titleDir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || !strings.HasPrefix(name, "$") || strings.EqualFold(name, "$c") || strings.EqualFold(name, "$u") {
+			continue
+		}
+		folderPath := path.Join(titleDir, name)
+		files, err := hashContentFiles(ctx, source, folderPath, fastHashFlag)
+		if err != nil {
+			return fmt.Errorf("error hashing %s: %v", source.displayPath(folderPath), err)
+		}
+		var size int64
+		for _, file := range files {
+			size += file.Size
+		}
+		if guiEnabled {
+			addText(theme.ForegroundColor(), "%s folder found with %d files (%.1f MB): %s", name, len(files), float64(size)/1e6, source.displayPath(folderPath))
+		}
+		printInfo(fatihColor.FgWhite, "%s folder found with %d files (%.1f MB): %s\n", name, len(files), float64(size)/1e6, source.displayPath(folderPath))
+		titleReport.Reserved = append(titleReport.Reserved, ReservedFolderReport{
+			Name:  name,
+			Path:  reportPath(directory, folderPath),
+			Files: files,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+// SaveReport lists the saves a title keeps in UDATA.
+type SaveReport struct {
+	TitleID   string   `json:"titleID" xml:"titleID,attr"`
+	TitleName string   `json:"titleName,omitempty" xml:"titleName,omitempty"`
+	Known     bool     `json:"known" xml:"known,attr"`
+	Path      string   `json:"path" xml:"path"`
+	Source    string   `json:"source,omitempty" xml:"source,attr,omitempty"`
+	Saves     []string `json:"saves,omitempty" xml:"save,omitempty"`
+}
+
+// Returns the value of a key in a UTF-16 TitleMeta.xbx or SaveMeta.xbx file.
+func readMetaValue(fsys fs.FS, name string, key string) string {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(decodeUTF16Text(data), "\n") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), key+"="); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// Lists the saves in UDATA, such as those on a memory unit, reporting whether each title is in the database.
+func checkForSaves(ctx context.Context, source *scanSource) error {
+	fsys := source.FS
+	udata := findFileFold(fsys, ".", "UDATA")
+	if udata == "" {
+		return nil
+	}
+	entries, err := fs.ReadDir(fsys, udata)
+	if err != nil {
+		return fmt.Errorf("error reading saves: %v", err)
+	}
+
+	if guiEnabled {
+		addHeader("Saves")
+	}
+	printHeader("Saves")
+	for _, entry := range entries {
+		if !entry.IsDir() || !isHexString(entry.Name(), 8) || !titleSelected(entry.Name()) || isIgnored(path.Join(udata, entry.Name())) {
+			continue
+		}
+		titleDir := path.Join(udata, entry.Name())
+		titleID := strings.ToLower(entry.Name())
+		titleData, _, known := lookupTitle(titleID)
+		save := SaveReport{
+			TitleID:   titleID,
+			TitleName: titleData.TitleName,
+			Known:     known,
+			Path:      path.Join("UDATA", entry.Name()),
+			Source:    source.reportName(),
+		}
+		if save.TitleName == "" {
+			save.TitleName = readMetaValue(fsys, findFileFold(fsys, titleDir, "TitleMeta.xbx"), "TitleName")
+		}
+
+		saveDirs, err := fs.ReadDir(fsys, titleDir)
+		if err != nil {
+			return fmt.Errorf("error reading saves: %v", err)
+		}
+		for _, saveDir := range saveDirs {
+			if !saveDir.IsDir() {
+				continue
+			}
+			name := readMetaValue(fsys, findFileFold(fsys, path.Join(titleDir, saveDir.Name()), "SaveMeta.xbx"), "Name")
+			if name == "" {
+				name = saveDir.Name()
+			}
+			save.Saves = append(save.Saves, name)
+		}
+
+		label := titleID
+		if save.TitleName != "" {
+			label = fmt.Sprintf("%s (%s)", save.TitleName, titleID)
+		}
+		if known {
+			if guiEnabled {
+				addText(theme.PrimaryColorNamed(theme.ColorGreen), "%s: %d saves", label, len(save.Saves))
+			}
+			printInfo(fatihColor.FgGreen, "%s: %d saves\n", label, len(save.Saves))
+		} else {
+			if guiEnabled {
+				addText(theme.PrimaryColorNamed(theme.ColorYellow), "Saves for unknown title %s: %d saves", label, len(save.Saves))
+			}
+			printInfo(fatihColor.FgYellow, "Saves for unknown title %s: %d saves\n", label, len(save.Saves))
+		}
+		scanResults.Saves = append(scanResults.Saves, save)
+	}
+	return nil
+}
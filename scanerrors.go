@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+// ErrorReport is a file or folder the scan couldn't read, such as one it has no permission to, and carried on past.
+type ErrorReport struct {
+	Path    string `json:"path" xml:"path"`
+	Source  string `json:"source,omitempty" xml:"source,attr,omitempty"`
+	Scanner string `json:"scanner,omitempty" xml:"scanner,attr,omitempty"`
+	Error   string `json:"error" xml:"error"`
+}
+
+// The name of the scanner running, which errors are recorded under.
+var runningScanner string
+
+// Records err, which the scan hit reading name in source, and returns nil so the scan carries on past it, rather
+// than one unreadable folder ending the scan of a whole drive. A cancelled scan's error is returned, and nil is
+// returned for a nil err.
+func skipScanError(ctx context.Context, source *scanSource, name string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	scanResults.Errors = append(scanResults.Errors, ErrorReport{
+		Path:    name,
+		Source:  source.reportName(),
+		Scanner: runningScanner,
+		Error:   err.Error(),
+	})
+	emitEvent(ScanEvent{Event: eventError, Path: name, Error: err.Error()})
+	if guiEnabled {
+		addText(theme.ErrorColor(), "Error reading %s, skipped: %v", source.displayPath(name), err)
+	}
+	defer itemOutput(onlyErrors)()
+	printInfo(fatihColor.FgRed, "Error reading %s, skipped: %v\n", source.displayPath(name), err)
+	return nil
+}
+
+// The folders the search for dumps under a --location couldn't read, which become errors of the scan once it starts.
+var searchErrors []ErrorReport
+
+// Records err, which the search for dumps under location hit reading the folder name, so a folder such as System
+// Volume Information doesn't stop the search.
+func skipSearchError(location, name string, err error) {
+	searchErrors = append(searchErrors, ErrorReport{Path: name, Source: location, Error: err.Error()})
+	emitEvent(ScanEvent{Event: eventError, Path: name, Error: err.Error()})
+	displayPath := filepath.Join(location, filepath.FromSlash(name))
+	if guiEnabled {
+		addText(theme.ErrorColor(), "Error reading %s, skipped: %v", displayPath, err)
+	}
+	defer itemOutput(onlyErrors)()
+	printInfo(fatihColor.FgRed, "Error reading %s, skipped: %v\n", displayPath, err)
+}
+
+// Prints the errors the scan carried on past, so they aren't lost among its other output.
+func printScanErrors() {
+	if len(scanResults.Errors) == 0 {
+		return
+	}
+	if guiEnabled {
+		addHeader("Errors")
+		addText(theme.ErrorColor(), "Skipped %d files or folders that couldn't be read:", len(scanResults.Errors))
+	}
+	printHeader("Errors")
+	printInfo(fatihColor.FgRed, "Skipped %d files or folders that couldn't be read:\n", len(scanResults.Errors))
+	for _, scanErr := range scanResults.Errors {
+		name := scanErr.Path
+		if scanErr.Source != "" {
+			name = scanErr.Source + ": " + name
+		}
+		if guiEnabled {
+			addText(theme.ErrorColor(), "%s: %s", name, scanErr.Error)
+		}
+		printInfo(fatihColor.FgRed, "    %s: %s\n", name, scanErr.Error)
+	}
+}
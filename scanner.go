@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// Scanner checks one kind of content on a scan source. Scanners are run in registry order on every source they
+// match, so a new kind of content only needs a Scanner added to the registry.
+type Scanner interface {
+	// Name identifies the scanner in --disable-scanners
+	Name() string
+	// Match reports whether the scanner applies to a source
+	Match(source *scanSource) bool
+	// Scan stops early with ctx's error once ctx is cancelled
+	Scan(ctx context.Context, source *scanSource) error
+}
+
+// scannerFunc is a Scanner made from a pair of functions.
+type scannerFunc struct {
+	name  string
+	match func(source *scanSource) bool
+	scan  func(ctx context.Context, source *scanSource) error
+}
+
+func (s scannerFunc) Name() string                  { return s.name }
+func (s scannerFunc) Match(source *scanSource) bool { return s.match(source) }
+func (s scannerFunc) Scan(ctx context.Context, source *scanSource) error {
+	return s.scan(ctx, source)
+}
+
+// Cache partitions only hold leftovers, so every other scanner skips them.
+func notCache(source *scanSource) bool {
+	return !source.Cache
+}
+
+// The scanners run on every source, in order.
+var scanners = []Scanner{
+	scannerFunc{
+		name:  "cache",
+		match: func(source *scanSource) bool { return source.Cache },
+		scan:  scanCachePartition,
+	},
+	scannerFunc{
+		name: "content",
+		match: func(source *scanSource) bool {
+			_, err := fs.Stat(source.FS, tdataFolder)
+			return notCache(source) && err == nil
+		},
+		scan: checkForContent,
+	},
+	scannerFunc{name: "softmods", match: notCache, scan: checkForSoftmods},
+	scannerFunc{name: "soundtracks", match: notCache, scan: checkForSoundtracks},
+	scannerFunc{
+		name:  "saves",
+		match: func(source *scanSource) bool { return notCache(source) && source.MemoryUnit },
+		scan:  checkForSaves,
+	},
+	scannerFunc{
+		name: "software",
+		match: func(source *scanSource) bool {
+			return notCache(source) && softwareFlag && (source.Label == "" || contains(softwarePartitions, source.Label))
+		},
+		scan: scanSoftware,
+	},
+}
+
+// Returns the names of the registered scanners.
+func scannerNames() []string {
+	return scannerNamesOf(scanners)
+}
+
+func scannerNamesOf(list []Scanner) []string {
+	var names []string
+	for _, scanner := range list {
+		names = append(names, scanner.Name())
+	}
+	return names
+}
+
+// Returns the scanners left enabled by --disable-scanners.
+func enabledScanners() ([]Scanner, error) {
+	disabled := make(map[string]bool)
+	for _, name := range strings.Split(disableScannersFlag, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !contains(scannerNames(), name) {
+			return nil, fmt.Errorf("unknown scanner %q, available scanners are: %s", name, strings.Join(scannerNames(), ", "))
+		}
+		disabled[name] = true
+	}
+
+	var enabled []Scanner
+	for _, scanner := range scanners {
+		if !disabled[scanner.Name()] {
+			enabled = append(enabled, scanner)
+		}
+	}
+	return enabled, nil
+}
@@ -0,0 +1,505 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	fatihColor "github.com/fatih/color"
+)
+
+// Most titles a name search over the API returns.
+const serveMaxMatches = 50
+
+// Content types the report endpoint sends each format with.
+var reportContentTypes = map[string]string{
+	"json": "application/json",
+	"xml":  "application/xml",
+	"csv":  "text/csv; charset=utf-8",
+	"html": "text/html; charset=utf-8",
+	"md":   "text/markdown; charset=utf-8",
+	"dat":  "application/xml",
+	"pdf":  "application/pdf",
+}
+
+// ServeScan is the state of the last scan started over the API.
+type ServeScan struct {
+	ID        int      `json:"id"`
+	Locations []string `json:"locations"`
+	Running   bool     `json:"running"`
+	Started   string   `json:"started"`
+	Finished  string   `json:"finished,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// ServeStatus is what the status endpoint reports.
+type ServeStatus struct {
+	Version   string           `json:"version"`
+	Databases []DatabaseReport `json:"databases"`
+	Scan      *ServeScan       `json:"scan,omitempty"`
+}
+
+// HashMatch is something the database knows a SHA1 as.
+type HashMatch struct {
+	Type      string `json:"type"`
+	TitleID   string `json:"titleID,omitempty"`
+	TitleName string `json:"titleName,omitempty"`
+	ContentID string `json:"contentID,omitempty"`
+	Name      string `json:"name"`
+	Version   string `json:"version,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// TitleMatch is a title found in the database, with its data when looked up by ID.
+type TitleMatch struct {
+	TitleID   string     `json:"titleID"`
+	TitleName string     `json:"titleName"`
+	Platform  string     `json:"platform,omitempty"`
+	Data      *TitleData `json:"data,omitempty"`
+}
+
+// scanServer runs scans and answers queries for pinecone serve. One scan runs at a time, and its results stay
+// available until the next one.
+type scanServer struct {
+	token        string
+	jsonFilePath string
+	// loopback is set when the server only listens on this machine
+	loopback bool
+
+	// database guards titles, databaseIndex and loadedDatabases, which an update replaces while other requests read
+	// them
+	database sync.RWMutex
+
+	mu   sync.Mutex
+	scan *ServeScan
+	// report is the report of the last scan that finished, kept apart from scanResults, which the next scan fills
+	report *ScanReport
+	// updating is set while the databases are downloaded again, which scans wait for
+	updating bool
+}
+
+func writeJSONResponse(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+func writeErrorResponse(w http.ResponseWriter, status int, format string, args ...any) {
+	writeJSONResponse(w, status, map[string]string{"error": fmt.Sprintf(format, args...)})
+}
+
+// Returns the state of the last scan, copied so it can be read while the scan runs.
+func (server *scanServer) currentScan() *ServeScan {
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.scan == nil {
+		return nil
+	}
+	scan := *server.scan
+	return &scan
+}
+
+// Requires the -token as a bearer token. Web pages can't send one to another site without its consent, so any page
+// the user visits can't start scans through the server either. Requests a page could still make, a POST with a form
+// or plain text body, or one from another origin, are turned away before the token is even checked.
+func (server *scanServer) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if origin := r.Header.Get("Origin"); origin != "" && !sameOrigin(origin, r.Host) {
+			writeErrorResponse(w, http.StatusForbidden, "requests from %s aren't allowed", origin)
+			return
+		}
+		if server.loopback && !isLoopbackHost(r.Host) {
+			writeErrorResponse(w, http.StatusForbidden, "unexpected host %s", r.Host)
+			return
+		}
+		if r.Method == http.MethodPost && r.ContentLength != 0 {
+			if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+				writeErrorResponse(w, http.StatusUnsupportedMediaType, "request bodies must be application/json")
+				return
+			}
+		}
+		given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(server.token)) != 1 {
+			writeErrorResponse(w, http.StatusUnauthorized, "missing or wrong token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Reports whether an Origin header names the host the request was sent to.
+func sameOrigin(origin, host string) bool {
+	_, originHost, ok := strings.Cut(origin, "://")
+	return ok && strings.EqualFold(originHost, host)
+}
+
+// Reports whether a Host header names this machine, rather than a name a page pointed at it to reach the server.
+func isLoopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Returns a random token for serve to require when none is given.
+func newServeToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+func (server *scanServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	server.database.RLock()
+	defer server.database.RUnlock()
+	writeJSONResponse(w, http.StatusOK, ServeStatus{Version: version, Databases: loadedDatabases, Scan: server.currentScan()})
+}
+
+// Starts a scan of the locations in the request body, or of the -l given to serve when there are none.
+func (server *scanServer) startScan(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Locations []string `json:"locations"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "invalid scan request: %v", err)
+			return
+		}
+	}
+	locations := request.Locations
+	if len(locations) == 0 {
+		locations = scanLocations()
+	}
+	for _, location := range locations {
+		if _, err := os.Stat(location); err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "%s: %v", location, err)
+			return
+		}
+	}
+
+	server.mu.Lock()
+	if server.scan != nil && server.scan.Running {
+		id := server.scan.ID
+		server.mu.Unlock()
+		writeErrorResponse(w, http.StatusConflict, "scan %d is still running", id)
+		return
+	}
+	if server.updating {
+		server.mu.Unlock()
+		writeErrorResponse(w, http.StatusConflict, "the databases are being updated")
+		return
+	}
+	id := 1
+	if server.scan != nil {
+		id = server.scan.ID + 1
+	}
+	server.scan = &ServeScan{ID: id, Locations: locations, Running: true, Started: time.Now().UTC().Format(time.RFC3339)}
+	scan := *server.scan
+	server.mu.Unlock()
+
+	go server.runScan(locations)
+	writeJSONResponse(w, http.StatusAccepted, scan)
+}
+
+func (server *scanServer) runScan(locations []string) {
+	server.database.RLock()
+	defer server.database.RUnlock()
+	ctx, release := newScanContext()
+	defer release()
+	emitEvent(ScanEvent{Event: eventStarted, Path: scanLocation(locations)})
+	err := checkParsingSettings(ctx, locations)
+	report := scanResults
+
+	server.mu.Lock()
+	if err == nil {
+		server.report = &report
+	}
+	server.scan.Running = false
+	server.scan.Finished = time.Now().UTC().Format(time.RFC3339)
+	if err != nil {
+		server.scan.Error = err.Error()
+		printInfo(fatihColor.FgRed, "Scan %d failed: %v\n", server.scan.ID, err)
+	}
+	server.mu.Unlock()
+	event := ScanEvent{Event: eventFinished}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	emitEvent(event)
+}
+
+func (server *scanServer) handleScans(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		scan := server.currentScan()
+		if scan == nil {
+			writeErrorResponse(w, http.StatusNotFound, "no scan has been started")
+			return
+		}
+		writeJSONResponse(w, http.StatusOK, scan)
+	case http.MethodPost:
+		server.startScan(w, r)
+	case http.MethodDelete:
+		if !scanRunning() {
+			writeErrorResponse(w, http.StatusConflict, "no scan is running")
+			return
+		}
+		stopScan()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "%s not allowed", r.Method)
+	}
+}
+
+// Streams scan events as server-sent events until the client goes away.
+func (server *scanServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeErrorResponse(w, http.StatusInternalServerError, "streaming isn't supported")
+		return
+	}
+	events, unsubscribe := subscribeEvents()
+	defer unsubscribe()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-events:
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Event, data)
+			flusher.Flush()
+		}
+	}
+}
+
+// Sends the report of the last scan in the format asked for, JSON by default.
+func (server *scanServer) handleReport(w http.ResponseWriter, r *http.Request) {
+	server.mu.Lock()
+	var scan ServeScan
+	if server.scan != nil {
+		scan = *server.scan
+	}
+	report := server.report
+	server.mu.Unlock()
+	switch {
+	case scan.ID == 0:
+		writeErrorResponse(w, http.StatusNotFound, "no scan has finished")
+		return
+	case scan.Running:
+		writeErrorResponse(w, http.StatusConflict, "scan %d is still running", scan.ID)
+		return
+	case scan.Error != "":
+		writeErrorResponse(w, http.StatusInternalServerError, "scan %d failed: %s", scan.ID, scan.Error)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	write, ok := reportFormats[format]
+	if !ok {
+		writeErrorResponse(w, http.StatusBadRequest, "unknown report format %q", format)
+		return
+	}
+	// The report writers write files, so the report is written to a temporary one and sent from there
+	dir, err := os.MkdirTemp("", "pinecone-report")
+	if err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "%v", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	reportPath := filepath.Join(dir, "report."+format)
+	if err := write(reportPath, report); err != nil {
+		writeErrorResponse(w, http.StatusInternalServerError, "error writing report: %v", err)
+		return
+	}
+	w.Header().Set("Content-Type", reportContentTypes[format])
+	http.ServeFile(w, r, reportPath)
+}
+
+// Looks a title up by ID under /api/titles/, or searches the titles by name with ?q=.
+func (server *scanServer) handleTitles(w http.ResponseWriter, r *http.Request) {
+	server.database.RLock()
+	defer server.database.RUnlock()
+	if titleID := strings.ToLower(strings.TrimPrefix(r.URL.Path, "/api/titles/")); titleID != "" && r.URL.Path != "/api/titles" {
+		data, platform, ok := lookupTitle(titleID)
+		if !ok {
+			writeErrorResponse(w, http.StatusNotFound, "title %s isn't in the database", titleID)
+			return
+		}
+		writeJSONResponse(w, http.StatusOK, TitleMatch{TitleID: titleID, TitleName: data.TitleName, Platform: platform, Data: &data})
+		return
+	}
+	query := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+	if query == "" {
+		writeErrorResponse(w, http.StatusBadRequest, "search with ?q= or look a title up under /api/titles/<title ID>")
+		return
+	}
+	matches := []TitleMatch{}
+	for _, section := range []struct {
+		titles   map[string]TitleData
+		platform string
+	}{{titles.Titles, ""}, {titles.Chihiro, platformChihiro}, {titles.Debug, platformDebug}} {
+		for titleID, data := range section.titles {
+			if strings.Contains(strings.ToLower(data.TitleName), query) {
+				matches = append(matches, TitleMatch{TitleID: titleID, TitleName: data.TitleName, Platform: section.platform})
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].TitleName < matches[j].TitleName })
+	writeJSONResponse(w, http.StatusOK, matches[:min(len(matches), serveMaxMatches)])
+}
+
+// Returns what the database knows a SHA1 as, as lookupHash prints it.
+func hashMatches(hash string) []HashMatch {
+	matches := []HashMatch{}
+	for _, ref := range databaseIndex.hashes[hash] {
+		match := HashMatch{Type: "update", TitleID: ref.titleID, TitleName: ref.titleName, ContentID: ref.contentID, Name: ref.name}
+		if ref.contentID != "" {
+			match.Type = "content file"
+		}
+		matches = append(matches, match)
+	}
+	if software, ok := titles.Software[hash]; ok {
+		matches = append(matches, HashMatch{Type: "software", Name: software.Name, Version: software.Version})
+	}
+	if xbe, ok := databaseIndex.homebrewXBEs[hash]; ok {
+		matches = append(matches, HashMatch{Type: "homebrew", TitleID: xbe.titleID, Name: titles.Homebrew[xbe.titleID].TitleName, Version: xbe.id})
+	}
+	if bad, ok := titles.KnownBad[hash]; ok {
+		matches = append(matches, HashMatch{Type: "known bad", TitleID: bad.TitleID, Name: bad.Name, Reason: bad.Reason})
+	}
+	return matches
+}
+
+func (server *scanServer) handleHashes(w http.ResponseWriter, r *http.Request) {
+	hash := strings.ToLower(strings.TrimPrefix(r.URL.Path, "/api/hashes/"))
+	if !isLowerHex(hash, 40) {
+		writeErrorResponse(w, http.StatusBadRequest, "look a SHA1 up under /api/hashes/<sha1>")
+		return
+	}
+	server.database.RLock()
+	matches := hashMatches(hash)
+	server.database.RUnlock()
+	if len(matches) == 0 {
+		writeErrorResponse(w, http.StatusNotFound, "SHA1 %s is not in the database", hash)
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, matches)
+}
+
+// Downloads the databases again, between scans.
+func (server *scanServer) handleDatabaseUpdate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeErrorResponse(w, http.StatusMethodNotAllowed, "%s not allowed", r.Method)
+		return
+	}
+	server.mu.Lock()
+	if (server.scan != nil && server.scan.Running) || server.updating {
+		server.mu.Unlock()
+		writeErrorResponse(w, http.StatusConflict, "a scan or update is still running")
+		return
+	}
+	server.updating = true
+	server.mu.Unlock()
+	defer func() {
+		server.mu.Lock()
+		server.updating = false
+		server.mu.Unlock()
+	}()
+
+	server.database.Lock()
+	defer server.database.Unlock()
+	if err := loadDatabases(server.jsonFilePath, true); err != nil {
+		writeErrorResponse(w, http.StatusBadGateway, "error updating data: %v", err)
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, ServeStatus{Version: version, Databases: loadedDatabases})
+}
+
+// Runs Pinecone as an HTTP server, so web front-ends and other tools can start scans, follow their progress, fetch
+// their reports and query the database. Scans use the flags given before the command, such as -o or --fast-hash.
+func runServe(args []string) error {
+	flags := newCommandFlags("serve")
+	addr := flags.String("addr", "127.0.0.1:8080", "Address to listen on")
+	token := flags.String("token", "", "Require this bearer token on every request, instead of a random one")
+	addSourceFlags(flags)
+	addScanFlags(flags)
+	addReportFlags(flags)
+	addDatabaseFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("usage: pinecone serve [-addr 127.0.0.1:8080] [-token secret] [flags]")
+	}
+	applyLocationFlags()
+	if *token == "" {
+		*token = os.Getenv("PINECONE_TOKEN")
+	}
+	generated := *token == ""
+	if generated {
+		var err error
+		if *token, err = newServeToken(); err != nil {
+			return fmt.Errorf("error generating a token: %v", err)
+		}
+	}
+
+	if err := loadCommandDatabase(); err != nil {
+		return err
+	}
+	guiEnabled = false
+	hashProgress = emitHashProgress
+	host, _, err := net.SplitHostPort(*addr)
+	if err != nil {
+		return fmt.Errorf("invalid -addr %q: %v", *addr, err)
+	}
+	server := &scanServer{token: *token, jsonFilePath: filepath.Join(dataPath, "id_database.json"), loopback: isLoopbackHost(host)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/status", server.handleStatus)
+	mux.HandleFunc("/api/scans", server.handleScans)
+	mux.HandleFunc("/api/events", server.handleEvents)
+	mux.HandleFunc("/api/report", server.handleReport)
+	mux.HandleFunc("/api/titles", server.handleTitles)
+	mux.HandleFunc("/api/titles/", server.handleTitles)
+	mux.HandleFunc("/api/hashes/", server.handleHashes)
+	mux.HandleFunc("/api/database/update", server.handleDatabaseUpdate)
+
+	fmt.Printf("Pinecone v%s serving on http://%s/api/status\n", version, *addr)
+	if generated {
+		fmt.Printf("Send Authorization: Bearer %s with every request, or pick a token with -token\n", *token)
+	}
+	err = http.ListenAndServe(*addr, server.authorize(mux))
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
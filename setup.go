@@ -1,19 +1,26 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
-	"runtime"
+	"os/signal"
+	"sync"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
 )
 
 func checkDataFolder(dataFolder string) error {
 	// Ensure data folder exists
 	if _, err := os.Stat(dataFolder); os.IsNotExist(err) {
-		fmt.Println("Data folder not found. Creating...")
+		if err := checkWritable(dataFolder); err != nil {
+			return err
+		}
+		printLine("Data folder not found. Creating...")
 		if mkDirErr := os.Mkdir(dataFolder, 0755); mkDirErr != nil {
 			return fmt.Errorf("Error creating data folder: %v", mkDirErr)
 		}
@@ -23,7 +30,7 @@ func checkDataFolder(dataFolder string) error {
 
 func checkDatabaseFile(jsonFilePath string, jsonURL string, updateFlag bool, window ...fyne.Window) error {
 	// Check if JSON file exists
-	if _, err := os.Stat(jsonFilePath); os.IsNotExist(err) {
+	if !databaseExists(jsonFilePath) {
 		// Prompt for download if JSON file doesn't exist
 		if guiEnabled {
 			if len(window) != 1 {
@@ -36,7 +43,7 @@ func checkDatabaseFile(jsonFilePath string, jsonURL string, updateFlag bool, win
 			guiShowDownloadConfirmation(window[0], jsonFilePath, jsonURL)
 		} else {
 			if cliPromptForDownload(jsonURL) {
-				err := loadJSONData(jsonFilePath, "Xbox-Preservation-Project", "Pinecone", "data/id_database.json", &titles, true)
+				err := loadDatabases(jsonFilePath, true)
 				if err != nil {
 					return fmt.Errorf("error downloading data: %v ", err)
 				}
@@ -46,16 +53,17 @@ func checkDatabaseFile(jsonFilePath string, jsonURL string, updateFlag bool, win
 		}
 	} else if updateFlag {
 		// Handle manual update
-		err := loadJSONData(jsonFilePath, "Xbox-Preservation-Project", "Pinecone", jsonFilePath, &titles, true)
+		err := loadDatabases(jsonFilePath, true)
 		if err != nil {
 			return fmt.Errorf("error updating data: %v", err)
 		}
 	} else {
 		// Load existing JSON data
-		err := loadJSONData(jsonFilePath, "Xbox-Preservation-Project", "Pinecone", jsonFilePath, &titles, false)
+		err := loadDatabases(jsonFilePath, false)
 		if err != nil {
 			return fmt.Errorf("error loading data: %v", err)
 		}
+		notifyUpdates(jsonFilePath, jsonURL)
 		if guiEnabled {
 			guiScanDump()
 		}
@@ -70,7 +78,10 @@ func checkDumpFolder(dumpLocation string) error {
 		}
 	} else {
 		if _, err := os.Stat(dumpLocation); os.IsNotExist(err) {
-			fmt.Println("Default dump folder not found. Creating...")
+			if err := checkReadOnly("create the missing dump folder"); err != nil {
+				return err
+			}
+			printLine("Default dump folder not found. Creating...")
 			if mkDirErr := os.Mkdir(dumpLocation, 0755); mkDirErr != nil {
 				return fmt.Errorf("Error creating dump folder: %v", mkDirErr)
 			}
@@ -80,41 +91,187 @@ func checkDumpFolder(dumpLocation string) error {
 	return nil
 }
 
-func checkParsingSettings() error {
-	if titleIDFlag != "" {
-		// if the titleID flag is set, print stats for that title
-		printStats(titleIDFlag, false)
-	} else if summarizeFlag {
-		// if the summarize flag is set, print stats for all titles
-		printStats("", true)
-	} else if fatxplorer {
-		if runtime.GOOS == "windows" {
-			if _, err := os.Stat(`X:\`); os.IsNotExist(err) {
-				return fmt.Errorf(`FatXplorer's X: drive not found`)
-			} else {
-				fmt.Println("Checking for Content...")
-				fmt.Println("====================================================================================================")
-				err := checkForContent("X:\\TDATA")
-				if err != nil {
-					return err
-				}
+// errScanCancelled is returned by checkParsingSettings when a scan is stopped before it finishes.
+var errScanCancelled = errors.New("scan cancelled")
+
+// The cancel function of the scan in progress, so the GUI can stop it.
+var (
+	scanMu     sync.Mutex
+	cancelScan context.CancelFunc
+)
+
+// Returns a context for a scan, cancelled on SIGINT or by stopScan. The returned function releases it once the scan
+// is over.
+func newScanContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	ctx, cancel := context.WithCancel(ctx)
+	scanMu.Lock()
+	cancelScan = cancel
+	scanMu.Unlock()
+	return ctx, func() {
+		scanMu.Lock()
+		cancelScan = nil
+		scanMu.Unlock()
+		cancel()
+		stop()
+	}
+}
+
+// Reports whether a scan is in progress.
+func scanRunning() bool {
+	scanMu.Lock()
+	defer scanMu.Unlock()
+	return cancelScan != nil
+}
+
+// Stops the scan in progress, if there is one.
+func stopScan() {
+	scanMu.Lock()
+	defer scanMu.Unlock()
+	if cancelScan != nil {
+		cancelScan()
+	}
+}
+
+// Reports a cancelled scan as errScanCancelled, whatever error it stopped with.
+func scanError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return errScanCancelled
+	}
+	return err
+}
+
+// Runs the enabled scanners over every source, opened from locations. A scanner that fails is recorded in the scan's
+// errors, and the others still run.
+func runScanners(ctx context.Context, locations []string, sources []*scanSource, enabled []Scanner) error {
+	// Locations usually sit on different drives, so all of them are hashed at once, while their results are still
+	// reported one location at a time
+	if len(locations) > 1 && contains(scannerNamesOf(enabled), "content") {
+		for _, source := range sources {
+			if !source.Serial {
+				defer source.startHashing(ctx, max(jobsFlag, 1))()
 			}
-		} else {
-			return fmt.Errorf("FatXplorer mode is only available on Windows.")
 		}
-	} else {
-		// If no flag is set, proceed normally
-		// Check if TDATA folder exists
-		if _, err := os.Stat(dumpLocation + "/TDATA"); os.IsNotExist(err) {
-			return fmt.Errorf("TDATA folder not found. Please place TDATA folder in the dump folder.")
-		}
-		fmt.Println("Checking for Content...")
-		fmt.Println("====================================================================================================")
-		err := checkForContent(dumpLocation + "/TDATA")
+	}
+	location := ""
+	for _, source := range sources {
+		if source.Location != location {
+			location = source.Location
+			if guiEnabled {
+				addHeader("Location " + location)
+			}
+			printHeader("Location " + location)
+		}
+		if source.Label != "" {
+			if guiEnabled {
+				addHeader("Partition " + source.Label)
+			}
+			printHeader("Partition " + source.Label)
+		}
+		for _, scanner := range enabled {
+			if !scanner.Match(source) {
+				continue
+			}
+			setLogScanner(scanner.Name(), source)
+			runningScanner = scanner.Name()
+			err := scanner.Scan(ctx, source)
+			setLogScanner("", nil)
+			err = skipScanError(ctx, source, ".", err)
+			runningScanner = ""
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func checkParsingSettings(ctx context.Context, locations []string) error {
+	if summarizeFlag {
+		// if the summarize flag is set, print stats for the titles asked for, or all of them
+		printStats(titleIDFlag == "")
+		return nil
+	} else if isoFlag != "" {
+		scanResults = ScanReport{Version: version, Databases: loadedDatabases, Location: isoFlag}
+		err := scanError(ctx, scanDiscImage(ctx, isoFlag))
+		if err != nil {
+			return err
+		}
+		return exportReports()
+	} else if imageFlag == "" && ftpFlag == "" && !fatxplorer && len(locations) == 1 && isXbox360Dump(locations[0]) {
+		scanResults = ScanReport{Version: version, Databases: loadedDatabases, Location: locations[0]}
+		err := scanXbox360(locations[0])
 		if err != nil {
 			return err
 		}
+		return exportReports()
+	}
+
+	sources, closeSources, err := openScanSources(locations)
+	if err != nil {
+		return err
 	}
+	defer closeSources()
 
+	if hashManifestFlag != "" {
+		return scanError(ctx, writeManifest(ctx, sources, hashManifestFlag))
+	} else if verifyManifestFlag != "" {
+		return scanError(ctx, verifyManifest(ctx, sources, verifyManifestFlag))
+	}
+
+	printLine("Checking for Content...")
+	fmt.Println("====================================================================================================")
+	enabled, err := enabledScanners()
+	if err != nil {
+		return err
+	}
+	identicalFiles = newIdenticalFileIndex()
+	if hashCacheFlag != "" {
+		fileHashCache, err = loadHashCache(hashCacheFlag)
+		if err != nil {
+			return err
+		}
+		defer func() { fileHashCache = nil }()
+	}
+	currentCheckpoint, err = startCheckpoint(scanLocation(locations), resumeFlag)
+	if err != nil {
+		return err
+	}
+	defer func() { currentCheckpoint = nil }()
+	scanResults = ScanReport{Version: version, Databases: loadedDatabases, Location: scanLocation(locations), Errors: searchErrors}
+	stopProgress := startProgress(ctx, sources)
+	err = runScanners(ctx, locations, sources, enabled)
+	stopProgress()
+	err = scanError(ctx, err)
+	// Hashes computed before a cancelled scan are still saved, so the next scan doesn't repeat them
+	if fileHashCache != nil {
+		if err := fileHashCache.save(); err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		if currentCheckpoint.save() == nil {
+			if guiEnabled {
+				addText(theme.PrimaryColorNamed(theme.ColorYellow), "Progress saved, scan again with --resume to continue where this scan stopped")
+			}
+			printInfo(fatihColor.FgYellow, "Progress saved, scan again with --resume to continue where this scan stopped\n")
+		}
+		return err
+	}
+	currentCheckpoint.remove()
+	checkDuplicateUpdates()
+	checkMissingContent()
+	summarizeRegions()
+	printLinks(sources)
+	printScanErrors()
+	if err := exportReports(); err != nil {
+		return err
+	}
+	if watchFlag {
+		// Rescans take the hashes of the files they didn't change from the checkpoint's, rather than hash them again
+		watchHashes, currentCheckpoint = currentCheckpoint.hashes, nil
+		defer func() { watchHashes = nil }()
+		return watchSources(ctx, sources, enabled)
+	}
 	return nil
 }
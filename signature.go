@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The minisign public key the published database is signed with, as the base64 line of its .pub file. Release builds
+// set it with -ldflags "-X main.databasePublicKey=..."; builds without one don't check signatures.
+var databasePublicKey = ""
+
+// Extension of the minisign signature published next to each signed file.
+const signatureExt = ".minisig"
+
+// minisignKey is a minisign public key.
+type minisignKey struct {
+	id  [8]byte
+	key ed25519.PublicKey
+}
+
+// Parses the base64 line of a minisign public key.
+func parseMinisignKey(encoded string) (*minisignKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(raw) != 2+8+ed25519.PublicKeySize || string(raw[:2]) != "Ed" {
+		return nil, fmt.Errorf("invalid minisign public key")
+	}
+	key := &minisignKey{key: ed25519.PublicKey(raw[10:])}
+	copy(key.id[:], raw[2:10])
+	return key, nil
+}
+
+// Checks a minisign signature of message, returning its trusted comment. Both the legacy signatures of the message
+// itself and the default ones of its BLAKE2b-512 digest are accepted.
+func (k *minisignKey) verify(message, signature []byte) (string, error) {
+	lines := strings.Split(strings.ReplaceAll(string(signature), "\r\n", "\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[0], "untrusted comment:") || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return "", fmt.Errorf("malformed signature")
+	}
+	sig, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(sig) != 2+8+ed25519.SignatureSize {
+		return "", fmt.Errorf("malformed signature")
+	}
+	if !bytes.Equal(sig[2:10], k.id[:]) {
+		return "", fmt.Errorf("signed with key %X, expected %X", sig[2:10], k.id[:])
+	}
+	switch string(sig[:2]) {
+	case "Ed":
+	case "ED":
+		digest := blake2b512(message)
+		message = digest[:]
+	default:
+		return "", fmt.Errorf("unsupported signature algorithm %q", sig[:2])
+	}
+	if !ed25519.Verify(k.key, message, sig[10:]) {
+		return "", fmt.Errorf("signature doesn't match")
+	}
+
+	// The trusted comment is signed along with the signature, so it can't be swapped either
+	comment := strings.TrimPrefix(lines[2], "trusted comment: ")
+	global, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return "", fmt.Errorf("malformed signature")
+	}
+	signed := append(append([]byte{}, sig[10:]...), comment...)
+	if !ed25519.Verify(k.key, signed, global) {
+		return "", fmt.Errorf("trusted comment signature doesn't match")
+	}
+	return comment, nil
+}
+
+// Downloads the signature published next to url and checks data against it, so a tampered or corrupted download
+// never replaces the local database. Does nothing in builds without a database public key.
+func verifyDownloadSignature(url string, data []byte) error {
+	if databasePublicKey == "" {
+		return nil
+	}
+	key, err := parseMinisignKey(databasePublicKey)
+	if err != nil {
+		return err
+	}
+	signature, _, err := downloadJSONData(siblingURL(url, func(p string) string { return p + signatureExt }), downloadState{})
+	if errors.Is(err, errDownloadNotFound) {
+		return fmt.Errorf("the database at %s isn't signed, keeping the local copy", url)
+	}
+	if err != nil {
+		return err
+	}
+	comment, err := key.verify(data, signature)
+	if err != nil {
+		return fmt.Errorf("error verifying the database signature, keeping the local copy: %v", err)
+	}
+	printDatabaseStatus("Signature verified: %s", comment)
+	return nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+const (
+	softwareTypeSoftmod     = "softmod"
+	softwareTypeExploitSave = "exploit save"
+)
+
+// Games whose saves are used to launch softmod installers.
+var exploitGames = []struct {
+	titleID string
+	name    string
+}{
+	{"4541000d", "007: Agent Under Fire"},
+	{"5553000c", "Splinter Cell"},
+	{"4d530017", "MechAssault"},
+}
+
+// Files softmods leave on the C partition. The retail dashboard never creates them.
+var softmodMarkers = []struct {
+	path string
+	name string
+}{
+	{"msdash.xbe", "original dashboard renamed by a softmod"},
+	{"evoxdash.xbe", "EvoX dashboard installed by a softmod"},
+}
+
+// SoftmodReport describes an installed softmod or an exploit save.
+type SoftmodReport struct {
+	Type     string `json:"type" xml:"type,attr"`
+	Name     string `json:"name" xml:"name"`
+	Version  string `json:"version,omitempty" xml:"version,omitempty"`
+	Path     string `json:"path" xml:"path"`
+	Source   string `json:"source,omitempty" xml:"source,attr,omitempty"`
+	SHA1     string `json:"sha1,omitempty" xml:"sha1,omitempty"`
+	Evidence string `json:"evidence" xml:"evidence"`
+}
+
+// Looks for exploit saves in UDATA and softmod files on the C partition, warning that the dump holds modified system files.
+func checkForSoftmods(ctx context.Context, source *scanSource) error {
+	fsys := source.FS
+	var found []SoftmodReport
+
+	if udata := findFileFold(fsys, ".", "UDATA"); udata != "" {
+		for _, game := range exploitGames {
+			saveDir := findFileFold(fsys, udata, game.titleID)
+			if saveDir == "" {
+				continue
+			}
+			// Regular saves never carry executables, exploit saves ship the installer as an XBE
+			err := fs.WalkDir(fsys, saveDir, func(filePath string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if d.IsDir() || !strings.EqualFold(path.Ext(d.Name()), ".xbe") {
+					return nil
+				}
+				hash, err := getSHA1Hash(ctx, fsys, filePath)
+				if err != nil {
+					return err
+				}
+				report := SoftmodReport{
+					Type:     softwareTypeExploitSave,
+					Name:     game.name + " exploit save",
+					Path:     filePath,
+					Source:   source.reportName(),
+					SHA1:     hash,
+					Evidence: "executable in a " + game.name + " save",
+				}
+				if data, ok := titles.Software[hash]; ok {
+					report.Name = data.Name
+					report.Version = data.Version
+				}
+				found = append(found, report)
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("error checking %s saves: %v", game.name, err)
+			}
+		}
+	}
+
+	if source.Label == "C" {
+		for _, marker := range softmodMarkers {
+			markerPath := findFileFold(fsys, ".", marker.path)
+			if markerPath == "" {
+				continue
+			}
+			report := SoftmodReport{
+				Type:     softwareTypeSoftmod,
+				Name:     "Unknown softmod",
+				Path:     markerPath,
+				Source:   source.reportName(),
+				Evidence: marker.name,
+			}
+			if hash, err := getSHA1Hash(ctx, fsys, markerPath); err == nil {
+				report.SHA1 = hash
+			}
+			found = append(found, report)
+		}
+		// A softmod replaces xboxdash.xbe with its own loader, which the database can name by hash
+		if hash, err := getSHA1Hash(ctx, fsys, "xboxdash.xbe"); err == nil {
+			if data, ok := titles.Software[hash]; ok && data.Type == softwareTypeSoftmod {
+				found = append(found, SoftmodReport{
+					Type:     softwareTypeSoftmod,
+					Name:     data.Name,
+					Version:  data.Version,
+					Path:     "xboxdash.xbe",
+					Source:   source.reportName(),
+					SHA1:     hash,
+					Evidence: "xboxdash.xbe matches a known softmod loader",
+				})
+			}
+		}
+	}
+
+	if len(found) == 0 {
+		return nil
+	}
+	if guiEnabled {
+		addText(theme.PrimaryColorNamed(theme.ColorYellow), "This dump contains a softmod or exploit save, system files may have been modified")
+	}
+	printInfo(fatihColor.FgYellow, "This dump contains a softmod or exploit save, system files may have been modified\n")
+	for _, report := range found {
+		name := report.Name
+		if report.Version != "" {
+			name += " " + report.Version
+		}
+		if guiEnabled {
+			addText(theme.PrimaryColorNamed(theme.ColorYellow), "%s (%s) at %s", name, report.Evidence, source.displayPath(report.Path))
+		}
+		printInfo(fatihColor.FgYellow, "%s (%s) at %s\n", name, report.Evidence, source.displayPath(report.Path))
+	}
+	scanResults.Softmods = append(scanResults.Softmods, found...)
+	return nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+// Partitions that hold the dashboard and installed apps, scanned with -software.
+var softwarePartitions = []string{"C", "E"}
+
+const (
+	softwareTypeDashboard = "dashboard"
+	softwareTypeApp       = "app"
+)
+
+// SoftwareReport describes a dashboard or app XBE found outside TDATA.
+type SoftwareReport struct {
+	Path     string     `json:"path" xml:"path"`
+	Source   string     `json:"source,omitempty" xml:"source,attr,omitempty"`
+	Name     string     `json:"name,omitempty" xml:"name,omitempty"`
+	Version  string     `json:"version,omitempty" xml:"version,omitempty"`
+	Type     string     `json:"type,omitempty" xml:"type,attr,omitempty"`
+	Known    bool       `json:"known" xml:"known,attr"`
+	SHA1     string     `json:"sha1" xml:"sha1"`
+	XBE      *XBEReport `json:"xbe,omitempty" xml:"xbe,omitempty"`
+	Warnings []string   `json:"warnings,omitempty" xml:"warning,omitempty"`
+}
+
+// Software recognized from its XBE certificate when its hash isn't in the database. The first match wins.
+var knownSoftware = []struct {
+	name     string
+	kind     string
+	titleID  string
+	fragment string
+}{
+	{"Microsoft Dashboard", softwareTypeDashboard, "fffe0000", ""},
+	{"EvoX Dashboard", softwareTypeDashboard, "", "evox"},
+	{"UnleashX", softwareTypeDashboard, "", "unleashx"},
+	{"Avalaunch", softwareTypeDashboard, "", "avalaunch"},
+	{"NexgenDash", softwareTypeDashboard, "", "nexgen"},
+	{"XBMC4Gamers", softwareTypeDashboard, "", "xbmc4gamers"},
+	{"XBMC4Xbox", softwareTypeDashboard, "", "xbmc4xbox"},
+	{"XBMC", softwareTypeDashboard, "", "xbmc"},
+	{"Xbox Media Center", softwareTypeDashboard, "", "xbox media center"},
+	{"Xbox Media Player", softwareTypeApp, "", "xbox media player"},
+}
+
+// Identifies an XBE by its hash in the database, falling back to its certificate.
+func identifySoftware(xbe *XBEInfo, hash string, software *SoftwareReport) {
+	if data, ok := titles.Software[hash]; ok {
+		software.Name = data.Name
+		software.Version = data.Version
+		software.Type = data.Type
+		software.Known = true
+		return
+	}
+	if identifyHomebrew(xbe, hash, software) {
+		return
+	}
+	software.Name = xbe.TitleName
+	software.Version = fmt.Sprintf("XBE version %d", xbe.Version)
+	software.Type = softwareTypeApp
+	name := strings.ToLower(xbe.TitleName)
+	for _, known := range knownSoftware {
+		if known.titleID != "" && known.titleID == xbe.TitleID || known.fragment != "" && strings.Contains(name, known.fragment) {
+			software.Name = known.name
+			software.Type = known.kind
+			return
+		}
+	}
+}
+
+// Walks a partition outside TDATA and UDATA, identifying every XBE as a dashboard or app.
+func scanSoftware(ctx context.Context, source *scanSource) error {
+	fsys := source.FS
+	found := 0
+	err := fs.WalkDir(fsys, ".", func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if filePath != "." && path.Dir(filePath) == "." && (strings.EqualFold(d.Name(), tdataFolder) || strings.EqualFold(d.Name(), "UDATA")) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !strings.EqualFold(path.Ext(d.Name()), ".xbe") {
+			return nil
+		}
+
+		hash, err := getSHA1Hash(ctx, fsys, filePath)
+		if err != nil {
+			return err
+		}
+		software := SoftwareReport{Path: filePath, Source: source.reportName(), SHA1: hash}
+		xbe, err := readXBEFile(fsys, filePath)
+		if err != nil {
+			software.Warnings = append(software.Warnings, fmt.Sprintf("unable to parse XBE: %v", err))
+		} else {
+			software.XBE = newXBEReport(xbe)
+			identifySoftware(xbe, hash, &software)
+		}
+		found++
+		printSoftware(source, &software)
+		scanResults.Software = append(scanResults.Software, software)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error scanning for software: %v", err)
+	}
+	if found == 0 {
+		if guiEnabled {
+			addText(theme.ForegroundColor(), "No dashboards or apps found")
+		}
+		printInfo(fatihColor.FgWhite, "No dashboards or apps found\n")
+	}
+	return nil
+}
+
+func printSoftware(source *scanSource, software *SoftwareReport) {
+	switch {
+	case software.XBE == nil:
+		if guiEnabled {
+			addText(theme.ErrorColor(), "Unreadable XBE at %s", source.displayPath(software.Path))
+		}
+		printInfo(fatihColor.FgRed, "Unreadable XBE at %s\n", source.displayPath(software.Path))
+	case software.Known:
+		if guiEnabled {
+			addText(theme.PrimaryColorNamed(theme.ColorGreen), "%s %s (%s) at %s", software.Name, software.Version, software.Type, source.displayPath(software.Path))
+		}
+		printInfo(fatihColor.FgGreen, "%s %s (%s) at %s\n", software.Name, software.Version, software.Type, source.displayPath(software.Path))
+	default:
+		if guiEnabled {
+			addText(theme.PrimaryColorNamed(theme.ColorYellow), "%s, %s (%s, hash not in database) at %s", software.Name, software.Version, software.Type, source.displayPath(software.Path))
+		}
+		printInfo(fatihColor.FgYellow, "%s, %s (%s, hash not in database) at %s\n", software.Name, software.Version, software.Type, source.displayPath(software.Path))
+	}
+	if software.XBE != nil {
+		printXBEFlags(software.XBE)
+	}
+	for _, warning := range software.Warnings {
+		if guiEnabled {
+			addText(theme.PrimaryColorNamed(theme.ColorYellow), "Warning: %s", warning)
+		}
+		printInfo(fatihColor.FgYellow, "Warning: %s\n", warning)
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+// ST.DB, the dashboard's custom soundtrack database, is made of 512 byte blocks: a header, then soundtrack and
+// song group blocks told apart by their magic.
+const (
+	soundtrackBlockSize      = 0x200
+	soundtrackMagic          = 0x00021371
+	soundtrackGroupMagic     = 0x00031073
+	soundtrackDurationOffset = 0x15C
+	soundtrackNameOffset     = 0x160
+	soundtrackNameLength     = 64
+	soundtrackGroupsOffset   = 0x0C
+	soundtrackMaxGroups      = 84
+	soundtrackGroupSongs     = 6
+	soundtrackGroupIDsOffset = 0x10
+	soundtrackGroupTimes     = 0x28
+	soundtrackGroupNames     = 0x40
+	soundtrackSongNameLength = 32
+)
+
+// SoundtrackReport is a custom soundtrack ripped to the hard drive through the dashboard.
+type SoundtrackReport struct {
+	ID       uint32           `json:"id" xml:"id,attr"`
+	Name     string           `json:"name" xml:"name"`
+	Source   string           `json:"source,omitempty" xml:"source,attr,omitempty"`
+	Duration uint32           `json:"durationMs" xml:"durationMs"`
+	Songs    []SoundtrackSong `json:"songs,omitempty" xml:"song,omitempty"`
+	Warnings []string         `json:"warnings,omitempty" xml:"warning,omitempty"`
+}
+
+// SoundtrackSong is a single track of a soundtrack.
+type SoundtrackSong struct {
+	ID       uint32 `json:"id" xml:"id,attr"`
+	Name     string `json:"name" xml:"name"`
+	Duration uint32 `json:"durationMs" xml:"durationMs"`
+	File     string `json:"file,omitempty" xml:"file,omitempty"`
+}
+
+// Decodes a fixed length, NUL padded UTF-16LE string.
+func decodeFixedUTF16(data []byte) string {
+	text := decodeUTF16Text(data)
+	if i := strings.IndexRune(text, 0); i >= 0 {
+		text = text[:i]
+	}
+	return strings.TrimSpace(text)
+}
+
+// Parses ST.DB into soundtracks, in the order they are stored.
+func parseSoundtrackDB(data []byte) []SoundtrackReport {
+	var soundtracks []SoundtrackReport
+	var soundtrackBlocks [][]byte
+	groups := make(map[uint64][]byte)
+	for offset := soundtrackBlockSize; offset+soundtrackBlockSize <= len(data); offset += soundtrackBlockSize {
+		block := data[offset : offset+soundtrackBlockSize]
+		switch binary.LittleEndian.Uint32(block) {
+		case soundtrackMagic:
+			soundtracks = append(soundtracks, SoundtrackReport{
+				ID:       binary.LittleEndian.Uint32(block[4:]),
+				Duration: binary.LittleEndian.Uint32(block[soundtrackDurationOffset:]),
+				Name:     decodeFixedUTF16(block[soundtrackNameOffset : soundtrackNameOffset+soundtrackNameLength*2]),
+			})
+			soundtrackBlocks = append(soundtrackBlocks, block)
+		case soundtrackGroupMagic:
+			soundtrackID := binary.LittleEndian.Uint32(block[4:])
+			groupID := binary.LittleEndian.Uint32(block[8:])
+			groups[uint64(soundtrackID)<<32|uint64(groupID)] = block
+		}
+	}
+
+	// Soundtracks list their song groups in play order, each group holding up to six songs
+	for i, block := range soundtrackBlocks {
+		soundtrack := &soundtracks[i]
+		numSongs := int(binary.LittleEndian.Uint32(block[8:]))
+		for g := 0; g < soundtrackMaxGroups && len(soundtrack.Songs) < numSongs; g++ {
+			groupID := binary.LittleEndian.Uint32(block[soundtrackGroupsOffset+g*4:])
+			group, ok := groups[uint64(soundtrack.ID)<<32|uint64(groupID)]
+			if !ok {
+				break
+			}
+			for s := 0; s < soundtrackGroupSongs && len(soundtrack.Songs) < numSongs; s++ {
+				nameOffset := soundtrackGroupNames + s*soundtrackSongNameLength*2
+				soundtrack.Songs = append(soundtrack.Songs, SoundtrackSong{
+					ID:       binary.LittleEndian.Uint32(group[soundtrackGroupIDsOffset+s*4:]),
+					Duration: binary.LittleEndian.Uint32(group[soundtrackGroupTimes+s*4:]),
+					Name:     decodeFixedUTF16(group[nameOffset : nameOffset+soundtrackSongNameLength*2]),
+				})
+			}
+		}
+		if len(soundtrack.Songs) < numSongs {
+			soundtrack.Warnings = append(soundtrack.Warnings, fmt.Sprintf("database lists %d songs, found %d", numSongs, len(soundtrack.Songs)))
+		}
+	}
+	return soundtracks
+}
+
+// Finds the WMA file of a song. Song files are named by their ID, which may or may not include the soundtrack ID.
+func findSongFile(fsys fs.FS, musicDir string, soundtrackID uint32, songID uint32) string {
+	soundtrackDir := findFileFold(fsys, musicDir, fmt.Sprintf("%04x", soundtrackID))
+	for _, name := range []string{fmt.Sprintf("%08x.wma", songID), fmt.Sprintf("%04x%04x.wma", soundtrackID, songID)} {
+		if songPath := findFileFold(fsys, soundtrackDir, name); songPath != "" {
+			return songPath
+		}
+	}
+	return ""
+}
+
+// Lists the custom soundtracks stored by the dashboard in TDATA/fffe0000/music, adding them to scanResults.
+func checkForSoundtracks(ctx context.Context, source *scanSource) error {
+	fsys := source.FS
+	musicDir := findFileFold(fsys, findFileFold(fsys, tdataFolder, dashboardTitleID), "music")
+	dbPath := findFileFold(fsys, musicDir, "ST.DB")
+	if dbPath == "" {
+		return nil
+	}
+	data, err := fs.ReadFile(fsys, dbPath)
+	if err != nil {
+		return fmt.Errorf("error reading soundtrack database: %v", err)
+	}
+
+	soundtracks := parseSoundtrackDB(data)
+	if guiEnabled {
+		addHeader("Soundtracks")
+	}
+	printHeader("Soundtracks")
+	for i := range soundtracks {
+		soundtrack := &soundtracks[i]
+		soundtrack.Source = source.reportName()
+		missing := 0
+		for j := range soundtrack.Songs {
+			song := &soundtrack.Songs[j]
+			song.File = findSongFile(fsys, musicDir, soundtrack.ID, song.ID)
+			if song.File == "" {
+				missing++
+			}
+		}
+		if missing > 0 {
+			soundtrack.Warnings = append(soundtrack.Warnings, fmt.Sprintf("%d songs have no WMA file", missing))
+		}
+
+		minutes := soundtrack.Duration / 60000
+		if guiEnabled {
+			addText(theme.ForegroundColor(), "%s: %d tracks, %d:%02d", soundtrack.Name, len(soundtrack.Songs), minutes, soundtrack.Duration/1000%60)
+		}
+		printInfo(fatihColor.FgWhite, "%s: %d tracks, %d:%02d\n", soundtrack.Name, len(soundtrack.Songs), minutes, soundtrack.Duration/1000%60)
+		for _, warning := range soundtrack.Warnings {
+			if guiEnabled {
+				addText(theme.PrimaryColorNamed(theme.ColorYellow), "Warning: %s", warning)
+			}
+			printInfo(fatihColor.FgYellow, "Warning: %s\n", warning)
+		}
+	}
+	if len(soundtracks) == 0 {
+		if guiEnabled {
+			addText(theme.ForegroundColor(), "Soundtrack database is empty")
+		}
+		printInfo(fatihColor.FgWhite, "Soundtrack database is empty\n")
+	}
+	scanResults.Soundtracks = append(scanResults.Soundtracks, soundtracks...)
+	return nil
+}
+
+// Replaces characters that can't be used in file names.
+func sanitizeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`<>:"/\|?*`, r) || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.TrimRight(name, ". ")
+	if name == "" {
+		return "_"
+	}
+	return name
+}
+
+// Copies every soundtrack's WMA files into dir, one folder per soundtrack with tracks named in play order.
+func exportSoundtracks(dir string, report *ScanReport) error {
+	if err := checkWritable(dir); err != nil {
+		return err
+	}
+	exported := 0
+	for _, soundtrack := range report.Soundtracks {
+		source := report.sourceByLabel(soundtrack.Source)
+		if source == nil {
+			continue
+		}
+		soundtrackDir := filepath.Join(dir, sanitizeFileName(fmt.Sprintf("%04x %s", soundtrack.ID, soundtrack.Name)))
+		if err := os.MkdirAll(soundtrackDir, 0o755); err != nil {
+			return err
+		}
+		for i, song := range soundtrack.Songs {
+			if song.File == "" {
+				continue
+			}
+			name := sanitizeFileName(fmt.Sprintf("%02d - %s", i+1, song.Name)) + path.Ext(song.File)
+			if err := copyFromFS(source.FS, song.File, filepath.Join(soundtrackDir, name)); err != nil {
+				return err
+			}
+			exported++
+		}
+	}
+	if guiEnabled {
+		addText(theme.ForegroundColor(), "%d soundtrack songs saved to: %s", exported, dir)
+	}
+	printLine("%d soundtrack songs saved to: %s", exported, dir)
+	return nil
+}
+
+func copyFromFS(fsys fs.FS, name string, outputPath string) error {
+	if err := checkWritable(outputPath); err != nil {
+		return err
+	}
+	in, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const tdataFolder = "TDATA"
+
+// scanSource is a filesystem holding TDATA/UDATA folders, such as a dump folder or a partition of an HDD image.
+type scanSource struct {
+	// Label tells results from different sources apart, such as the partition letter of an HDD image
+	Label string
+	// Location is the --location the source was opened from, set when several locations are scanned at once
+	Location string
+	// Display is prefixed to paths printed to the console
+	Display string
+	FS      fs.FS
+	// Cache marks X/Y/Z cache partitions, which hold scratch data rather than TDATA/UDATA
+	Cache bool
+	// MemoryUnit marks memory unit images, whose saves are listed
+	MemoryUnit bool
+	// Serial marks sources that are slow to read concurrently, such as solid 7z archives
+	Serial bool
+	// Dir is the local folder the source was opened from, which --watch can watch, if it's one
+	Dir string
+
+	// hashes is set while checkForContent hashes the source's files in parallel
+	hashes *hashPool
+	// links has the symbolic links found in the folder, if the source is one
+	links *linkFS
+}
+
+// Returns name, a path inside the source, as it should be printed to the console.
+func (source *scanSource) displayPath(name string) string {
+	return filepath.Join(source.Display, filepath.FromSlash(name))
+}
+
+// Returns the name results from the source are recorded under in reports: its label, prefixed with its location
+// when several locations are scanned.
+func (source *scanSource) reportName() string {
+	if source.Location == "" {
+		return source.Label
+	}
+	if source.Label == "" {
+		return source.Location
+	}
+	return source.Location + "/" + source.Label
+}
+
+// locationList collects every --location given, each of which may also be a comma-separated list.
+type locationList []string
+
+func (locations *locationList) String() string {
+	return strings.Join(*locations, ",")
+}
+
+func (locations *locationList) Set(value string) error {
+	for _, location := range strings.Split(value, ",") {
+		if location = strings.TrimSpace(location); location != "" {
+			*locations = append(*locations, location)
+		}
+	}
+	return nil
+}
+
+// The locations to scan, defaulting to dumpLocation.
+func scanLocations() []string {
+	if len(locationsFlag) > 1 {
+		return locationsFlag
+	}
+	return []string{dumpLocation}
+}
+
+// Returns the location recorded in reports for the current settings, with locations the folders or archives scanned.
+func scanLocation(locations []string) string {
+	if imageFlag != "" {
+		return imageFlag
+	}
+	if ftpFlag != "" {
+		if u, err := url.Parse(ftpFlag); err == nil {
+			return ftpDisplayURL(u)
+		}
+	}
+	if fatxplorer {
+		drives, err := fatXplorerDrives()
+		if err != nil {
+			return "FatXplorer"
+		}
+		var roots []string
+		for _, drive := range drives {
+			roots = append(roots, drive.Root)
+		}
+		return strings.Join(roots, ", ")
+	}
+	var recorded []string
+	for _, location := range locations {
+		// Folders holding nested dumps are recorded as they are
+		if info, err := os.Stat(location); err == nil && info.IsDir() {
+			if _, err := os.Stat(location + "/TDATA"); err != nil {
+				recorded = append(recorded, location)
+				continue
+			}
+		}
+		recorded = append(recorded, location+"/TDATA")
+	}
+	return strings.Join(recorded, ", ")
+}
+
+// Opens the sources selected by the current settings, with locations the folders or archives to scan, usually
+// scanLocations(). The returned function releases them once scanning is done.
+func openScanSources(locations []string) ([]*scanSource, func(), error) {
+	searchErrors = nil
+	if imageFlag != "" {
+		return openImageSources(imageFlag)
+	}
+	if ftpFlag != "" {
+		source, closeSource, err := openFTPSource(ftpFlag)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []*scanSource{source}, closeSource, nil
+	}
+	if fatxplorer {
+		return openFatXplorerSources()
+	}
+	var sources []*scanSource
+	var closers []func()
+	closeSources := func() {
+		for _, closeSource := range closers {
+			closeSource()
+		}
+	}
+	for _, location := range locations {
+		found, closeSource, err := openLocationSources(location)
+		if err != nil {
+			closeSources()
+			if len(locations) == 1 {
+				return nil, nil, err
+			}
+			return nil, nil, fmt.Errorf("%s: %v", location, err)
+		}
+		if len(locations) > 1 {
+			for _, source := range found {
+				source.Location = path.Join(filepath.ToSlash(location), source.Location)
+			}
+		}
+		sources = append(sources, found...)
+		closers = append(closers, closeSource)
+	}
+	return sources, closeSources, nil
+}
+
+// Opens a dump folder or archive given by --location. A folder without TDATA/UDATA of its own is searched for
+// dumps nested at any depth, such as dumps/console1/E/TDATA, and each one found becomes a source labelled with its
+// path inside the folder.
+func openLocationSources(location string) ([]*scanSource, func(), error) {
+	if isArchivePath(location) {
+		source, closeArchive, err := openArchiveSource(location)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []*scanSource{source}, closeArchive, nil
+	}
+	if findFileFold(dirFS(location), ".", tdataFolder) != "" {
+		source, closeSource := openDirSource(location)
+		return []*scanSource{source}, closeSource, nil
+	}
+
+	roots, err := findDumpRoots(location)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(roots) == 0 {
+		return nil, nil, fmt.Errorf("TDATA folder not found. Please place TDATA folder in the dump folder.")
+	}
+	var sources []*scanSource
+	var closers []func()
+	for _, root := range roots {
+		source, closeSource := openDirSource(filepath.Join(location, filepath.FromSlash(root)))
+		source.Location = root
+		if root == "." {
+			source.Location = "" // UDATA on its own, such as a copied memory unit
+		}
+		sources = append(sources, source)
+		closers = append(closers, closeSource)
+	}
+	return sources, func() {
+		for _, closeSource := range closers {
+			closeSource()
+		}
+	}, nil
+}
+
+// Opens a folder as a scan source. Its directories are listed ahead of the walk unless -j=1 asks for one read at a
+// time, and the links in them are resolved by a linkFS. Paths are matched ignoring case, as on the console.
+func openDirSource(dir string) (*scanSource, func()) {
+	links := newLinkFS(newFoldFS(dirFS(dir)), dir)
+	if jobsFlag <= 1 {
+		return &scanSource{Display: dir, FS: links, Dir: dir, links: links}, func() {}
+	}
+	walker := newWalkFS(links)
+	return &scanSource{Display: dir, FS: walker, Dir: dir, links: links}, walker.close
+}
+
+// Returns the folders under location holding a TDATA or UDATA folder, relative to it. Folders inside a dump aren't
+// searched any further.
+func findDumpRoots(location string) ([]string, error) {
+	var roots []string
+	fsys := newFoldFS(dirFS(location))
+	err := fs.WalkDir(fsys, ".", func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if filePath == "." {
+				return err
+			}
+			// Folders such as $RECYCLE.BIN can't be read on a drive's root, and hold no dumps anyway
+			skipSearchError(location, filePath, err)
+			return fs.SkipDir
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		for _, folder := range []string{tdataFolder, "UDATA"} {
+			if info, err := fs.Stat(fsys, path.Join(filePath, folder)); err == nil && info.IsDir() {
+				roots = append(roots, filePath)
+				return fs.SkipDir
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error searching %s for dumps: %v", location, err)
+	}
+	return roots, nil
+}
+
+// Finds the source a title was scanned from, or nil if the report wasn't produced by this run.
+func (report *ScanReport) titleSource(title *TitleReport) *scanSource {
+	return report.sourceByLabel(title.Source)
+}
+
+// Finds the source with the given label, or nil if the report wasn't produced by this run.
+func (report *ScanReport) sourceByLabel(label string) *scanSource {
+	for _, source := range report.sources {
+		if source.reportName() == label {
+			return source
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+// Sends a submission to endpoint, returning an error unless the server accepts it.
+func postSubmission(endpoint, contentType string, body []byte) error {
+	client, err := httpClient()
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "Pinecone/"+version)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		if text := strings.TrimSpace(string(message)); text != "" {
+			return fmt.Errorf("%s refused the submission: %s: %s", endpoint, resp.Status, text)
+		}
+		return fmt.Errorf("%s refused the submission: %s", endpoint, resp.Status)
+	}
+	return nil
+}
+
+// Asks on the console whether to upload the files of the --bundle written, which is never done without asking.
+func cliConfirmBundleUpload(bundlePath, endpoint string) bool {
+	info, err := os.Stat(bundlePath)
+	if err != nil {
+		return false
+	}
+	var response string
+	fmt.Printf("Upload %s (%.1f MB), which holds the files themselves, to %s? (yes/no): ", bundlePath, float64(info.Size())/(1<<20), endpoint)
+	fmt.Scanln(&response)
+	return strings.ToLower(response) == "yes"
+}
+
+// Sends the hashes and metadata of the unarchived and unknown content of a scan to a community endpoint, so the
+// database maintainers learn about new finds. Only the manifest a --bundle would hold is sent; the files themselves
+// are only uploaded from a --bundle, after asking.
+func submitFindings(endpoint string, report *ScanReport) error {
+	if parsed, err := url.Parse(endpoint); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return fmt.Errorf("invalid submission URL %q", endpoint)
+	}
+	items := bundleItems(report)
+	if len(items) == 0 {
+		if guiEnabled {
+			addText(theme.ForegroundColor(), "Nothing unarchived to submit")
+		}
+		printInfo(fatihColor.FgWhite, "Nothing unarchived to submit\n")
+		return nil
+	}
+	manifest := BundleManifest{Scanner: "Pinecone v" + version, Created: time.Now().UTC().Format(time.RFC3339), Databases: report.Databases, Items: items}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	if err := postSubmission(endpoint, "application/json", data); err != nil {
+		return err
+	}
+	if guiEnabled {
+		addText(theme.PrimaryColorNamed(theme.ColorGreen), "Hashes and details of %d items submitted to %s", len(items), endpoint)
+	}
+	printInfo(fatihColor.FgGreen, "Hashes and details of %d items submitted to %s\n", len(items), endpoint)
+
+	// The GUI, porcelain output and daemon have no console to ask on, so they never upload files
+	if bundleFlag == "" || guiEnabled || porcelainFlag || everyFlag > 0 {
+		return nil
+	}
+	if _, err := os.Stat(bundleFlag); err != nil || !cliConfirmBundleUpload(bundleFlag, endpoint) {
+		return nil
+	}
+	bundle, err := os.ReadFile(bundleFlag)
+	if err != nil {
+		return err
+	}
+	if err := postSubmission(endpoint, "application/zip", bundle); err != nil {
+		return err
+	}
+	printInfo(fatihColor.FgGreen, "%s uploaded to %s\n", bundleFlag, endpoint)
+	return nil
+}
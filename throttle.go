@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// ioThrottle spaces out reads so that, across every hashing worker, they average no more than a set number of
+// bytes a second.
+type ioThrottle struct {
+	mu   sync.Mutex
+	rate float64
+	next time.Time
+}
+
+// The throttle set by --io-limit, or nil.
+var readThrottle *ioThrottle
+
+// Returns a throttle limiting reads to mbPerSecond megabytes a second.
+func newIOThrottle(mbPerSecond float64) *ioThrottle {
+	return &ioThrottle{rate: mbPerSecond * 1e6}
+}
+
+// Accounts for n bytes just read, sleeping until the average rate is back under the limit.
+func (t *ioThrottle) wait(ctx context.Context, n int) error {
+	t.mu.Lock()
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	t.next = t.next.Add(time.Duration(float64(n) / t.rate * float64(time.Second)))
+	delay := t.next.Sub(now)
+	t.mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+// Returns the path of name inside dir, matching case-insensitively, or "" if there is none.
+func findFileFold(fsys fs.FS, dir string, name string) string {
+	if dir == "" {
+		return ""
+	}
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		if strings.EqualFold(entry.Name(), name) {
+			return path.Join(dir, entry.Name())
+		}
+	}
+	return ""
+}
+
+func writePNG(outputPath string, img image.Image) error {
+	if err := checkWritable(outputPath); err != nil {
+		return err
+	}
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := png.Encode(file, img); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// Decodes the XPR image in sourcePath and saves it as a PNG, returning false if there was no usable image.
+func exportThumbnail(fsys fs.FS, sourcePath string, outputPath string, embedded bool) bool {
+	if sourcePath == "" {
+		return false
+	}
+	data, err := fs.ReadFile(fsys, sourcePath)
+	if err != nil {
+		return false
+	}
+
+	var img image.Image
+	if embedded {
+		img, err = decodeEmbeddedXPR(data)
+	} else {
+		img, err = decodeXPR(data)
+	}
+	if err == nil {
+		err = writePNG(outputPath, img)
+	}
+	if err != nil {
+		if !embedded {
+			if guiEnabled {
+				addText(theme.PrimaryColorNamed(theme.ColorYellow), "Unable to export image %s: %v", sourcePath, err)
+			}
+			printInfo(fatihColor.FgYellow, "Unable to export image %s: %v\n", sourcePath, err)
+		}
+		return false
+	}
+	return true
+}
+
+// Exports title and content images found in the dump as PNGs into dir, recording them in the report.
+func exportThumbnails(dir string, report *ScanReport) error {
+	if err := checkWritable(dir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	exported := 0
+	for i := range report.Titles {
+		title := &report.Titles[i]
+		source := report.titleSource(title)
+		if source == nil {
+			continue
+		}
+		fsys := source.FS
+		titleDirs := []string{path.Join(tdataFolder, title.Path)}
+		if udata := findFileFold(fsys, ".", "UDATA"); udata != "" {
+			titleDirs = append(titleDirs, findFileFold(fsys, udata, title.TitleID))
+		}
+		for _, titleDir := range titleDirs {
+			name := title.TitleID + ".png"
+			if exportThumbnail(fsys, findFileFold(fsys, titleDir, "titleimage.xbx"), filepath.Join(dir, name), false) {
+				title.Thumbnail = name
+				exported++
+				break
+			}
+		}
+
+		for j := range title.Content {
+			content := &title.Content[j]
+			contentDir := path.Join(tdataFolder, content.Path)
+			name := fmt.Sprintf("%s_%s.png", title.TitleID, content.ContentID)
+			if exportThumbnail(fsys, findFileFold(fsys, contentDir, "titleimage.xbx"), filepath.Join(dir, name), false) ||
+				exportThumbnail(fsys, findFileFold(fsys, contentDir, "contentmeta.xbx"), filepath.Join(dir, name), true) {
+				content.Thumbnail = name
+				exported++
+			}
+		}
+	}
+
+	if guiEnabled {
+		addText(theme.ForegroundColor(), "%d images saved to: %s", exported, dir)
+	}
+	printLine("%d images saved to: %s", exported, dir)
+	return nil
+}
@@ -1,13 +1,99 @@
 package main
 
+import "strings"
+
 type TitleData struct {
 	TitleName         string              `json:"Title Name,"`
 	ContentIDs        []string            `json:"Content IDs"`
 	TitleUpdates      []string            `json:"Title Updates"`
 	TitleUpdatesKnown []map[string]string `json:"Title Updates Known"`
 	Archived          []map[string]string `json:"Archived"`
+	ContentTypes      map[string]string   `json:"Content Types,omitempty"`
+	// ContentFiles holds the SHA1 of every file of archived content, by content ID and path inside the content folder
+	ContentFiles map[string]map[string]string `json:"Content Files,omitempty"`
+	// Insignia describes the content distributed by the Insignia Xbox Live revival, by content ID. Original Xbox Live
+	// content it re-issued is also listed in ContentIDs; new downloads, such as roster updates, are only listed here
+	Insignia map[string]InsigniaData `json:"Insignia,omitempty"`
+}
+
+// InsigniaData describes a content download of the Insignia revival.
+type InsigniaData struct {
+	Name string `json:"Name"`
+	// Reissue marks content first distributed by the original Xbox Live
+	Reissue bool `json:"Reissue,omitempty"`
+	// Files holds the SHA1 of every file of Insignia's copy by path, telling a re-issue apart from an original download
+	Files map[string]string `json:"Files,omitempty"`
+}
+
+// Reports whether a content ID is a new download of the Insignia revival, rather than original Xbox Live content.
+func (data *TitleData) insigniaOnly(contentID string) bool {
+	insignia, ok := data.Insignia[contentID]
+	return ok && !insignia.Reissue
+}
+
+// SoftwareData identifies a dashboard or app XBE by its SHA1.
+type SoftwareData struct {
+	Name    string `json:"Name"`
+	Version string `json:"Version"`
+	Type    string `json:"Type"`
+}
+
+// HomebrewData describes a homebrew title, with the hashes of its known XBEs mapped to their versions.
+type HomebrewData struct {
+	TitleName string              `json:"Title Name"`
+	XBEs      []map[string]string `json:"XBEs"`
 }
 
 type TitleList struct {
-	Titles map[string]TitleData `json:"Titles"`
+	// Version is an optional label for the revision of the database, recorded in reports
+	Version  string                  `json:"Version,omitempty"`
+	Titles   map[string]TitleData    `json:"Titles"`
+	Software map[string]SoftwareData `json:"Software,omitempty"`
+	Homebrew map[string]HomebrewData `json:"Homebrew,omitempty"`
+	Chihiro  map[string]TitleData    `json:"Chihiro,omitempty"`
+	Debug    map[string]TitleData    `json:"Debug,omitempty"`
+	KnownBad map[string]KnownBadData `json:"Known Bad,omitempty"`
+}
+
+// KnownBadData describes a corrupted or tampered title update that circulates in the wild, by its SHA1.
+type KnownBadData struct {
+	TitleID string `json:"Title ID"`
+	Name    string `json:"Name"`
+	Reason  string `json:"Reason"`
+}
+
+// Platforms of titles found outside the retail section of the database.
+const (
+	platformChihiro = "chihiro"
+	platformDebug   = "debug"
+)
+
+var platformNames = map[string]string{
+	platformChihiro: "Chihiro arcade title",
+	platformDebug:   "XDK/debug title",
+}
+
+// Title ID the dashboard stores its data under.
+const dashboardTitleID = "fffe0000"
+
+// Publisher prefix of the title IDs the XDK gives its samples.
+const xdkSamplePrefix = "ffff"
+
+// Looks a title up in the retail database, then in the Chihiro and debug sections. Titles using the XDK sample
+// prefix are reported as debug titles even when the database doesn't list them. IDs are matched ignoring case.
+func lookupTitle(titleID string) (TitleData, string, bool) {
+	titleID = strings.ToLower(titleID)
+	if data, ok := titles.Titles[titleID]; ok {
+		return data, "", true
+	}
+	if data, ok := titles.Chihiro[titleID]; ok {
+		return data, platformChihiro, true
+	}
+	if data, ok := titles.Debug[titleID]; ok {
+		return data, platformDebug, true
+	}
+	if strings.HasPrefix(titleID, xdkSamplePrefix) {
+		return TitleData{TitleName: "XDK sample " + titleID}, platformDebug, true
+	}
+	return TitleData{}, "", false
 }
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Returns the title IDs and wildcards given with --titleid, such as 4d530004,4d53*, in lower case.
+func titleIDPatterns() []string {
+	var patterns []string
+	for _, pattern := range strings.Split(titleIDFlag, ",") {
+		if pattern = strings.ToLower(strings.TrimSpace(pattern)); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// Checks that --titleid only holds title IDs, or wildcards where * stands for any hex digits and ? for one. A title
+// ID cut short matches nothing, so the wildcard it was probably meant as is suggested.
+func validateTitleFilter() error {
+	for _, pattern := range titleIDPatterns() {
+		wildcard := strings.Contains(pattern, "*")
+		valid := len(pattern) <= 8 || wildcard
+		for _, c := range pattern {
+			if (c < '0' || c > '9') && (c < 'a' || c > 'f') && c != '*' && c != '?' {
+				valid = false
+			}
+		}
+		if valid && !wildcard && len(pattern) < 8 {
+			return fmt.Errorf("-titleid %q is shorter than a title ID, did you mean %s*?", pattern, pattern)
+		}
+		if !valid {
+			return fmt.Errorf("-titleid %q isn't a title ID or a wildcard such as 4d53*", pattern)
+		}
+	}
+	return nil
+}
+
+// Reports whether the title is one --titleid asks for, which every title is when it isn't given.
+func titleSelected(titleID string) bool {
+	patterns := titleIDPatterns()
+	if len(patterns) == 0 {
+		return true
+	}
+	titleID = strings.ToLower(titleID)
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, titleID); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the IDs of the titles in the databases that --titleid matches, in order.
+func selectedTitleIDs() []string {
+	var ids []string
+	for _, list := range []map[string]TitleData{titles.Titles, titles.Chihiro, titles.Debug} {
+		for titleID := range list {
+			if titleSelected(titleID) {
+				ids = append(ids, titleID)
+			}
+		}
+	}
+	// XDK samples aren't listed, but are known by their prefix
+	for _, pattern := range titleIDPatterns() {
+		if _, _, ok := lookupTitle(pattern); ok && !strings.ContainsAny(pattern, "*?") && !contains(ids, pattern) {
+			ids = append(ids, pattern)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,137 @@
+package main
+
+import "sort"
+
+// titleKey is an ID or hash listed under a title.
+type titleKey struct {
+	titleID string
+	id      string
+}
+
+// hashRef is an entry of the database a SHA1 was found under: a known title update, when contentID is empty, or a
+// file of archived content.
+type hashRef struct {
+	titleID   string
+	titleName string
+	contentID string
+	name      string
+}
+
+// titleIndex maps content IDs and hashes back to the titles listing them, so scans and lookups don't walk every title
+// of the database for each file they check.
+type titleIndex struct {
+	// The retail title listing each content ID and known update hash
+	contentTitles map[string]string
+	updateTitles  map[string]string
+	// The names of the known updates and archived content of the title each ID resolves to
+	knownUpdates map[titleKey]string
+	archived     map[titleKey]string
+	// Every title update and content file with each hash, in every section
+	hashes map[string][]hashRef
+	// The homebrew title and version of each XBE hash
+	homebrewXBEs map[string]titleKey
+}
+
+// The index of the loaded database, rebuilt each time it's loaded.
+var databaseIndex = &titleIndex{}
+
+// Builds the index of list. Per-title entries follow lookupTitle, so a title ID found in several sections resolves to
+// the entry scans use.
+func buildTitleIndex(list *TitleList) *titleIndex {
+	idx := &titleIndex{
+		contentTitles: make(map[string]string),
+		updateTitles:  make(map[string]string),
+		knownUpdates:  make(map[titleKey]string),
+		archived:      make(map[titleKey]string),
+		hashes:        make(map[string][]hashRef),
+		homebrewXBEs:  make(map[string]titleKey),
+	}
+	for titleID, data := range list.Titles {
+		for _, contentID := range data.ContentIDs {
+			if _, ok := idx.contentTitles[contentID]; !ok {
+				idx.contentTitles[contentID] = titleID
+			}
+		}
+		for contentID := range data.Insignia {
+			if _, ok := idx.contentTitles[contentID]; !ok {
+				idx.contentTitles[contentID] = titleID
+			}
+		}
+		for _, known := range data.TitleUpdatesKnown {
+			for hash := range known {
+				if _, ok := idx.updateTitles[hash]; !ok {
+					idx.updateTitles[hash] = titleID
+				}
+			}
+		}
+	}
+
+	indexed := make(map[string]bool)
+	for _, section := range []map[string]TitleData{list.Titles, list.Chihiro, list.Debug} {
+		for titleID, data := range section {
+			for _, known := range data.TitleUpdatesKnown {
+				for hash, name := range known {
+					idx.hashes[hash] = append(idx.hashes[hash], hashRef{titleID: titleID, titleName: data.TitleName, name: name})
+				}
+			}
+			for contentID, files := range data.ContentFiles {
+				for name, hash := range files {
+					idx.hashes[hash] = append(idx.hashes[hash], hashRef{titleID: titleID, titleName: data.TitleName, contentID: contentID, name: name})
+				}
+			}
+
+			if indexed[titleID] {
+				continue
+			}
+			indexed[titleID] = true
+			// The first list naming an ID wins, as it did when the lists were walked in order
+			for _, known := range data.TitleUpdatesKnown {
+				for hash, name := range known {
+					if _, ok := idx.knownUpdates[titleKey{titleID, hash}]; !ok {
+						idx.knownUpdates[titleKey{titleID, hash}] = name
+					}
+				}
+			}
+			for _, archived := range data.Archived {
+				for id, name := range archived {
+					if _, ok := idx.archived[titleKey{titleID, id}]; !ok && name != "" {
+						idx.archived[titleKey{titleID, id}] = name
+					}
+				}
+			}
+		}
+	}
+	for _, refs := range idx.hashes {
+		sort.Slice(refs, func(i, j int) bool {
+			if refs[i].titleID != refs[j].titleID {
+				return refs[i].titleID < refs[j].titleID
+			}
+			if refs[i].contentID != refs[j].contentID {
+				return refs[i].contentID < refs[j].contentID
+			}
+			return refs[i].name < refs[j].name
+		})
+	}
+
+	for titleID, homebrew := range list.Homebrew {
+		for _, xbes := range homebrew.XBEs {
+			for hash, version := range xbes {
+				if _, ok := idx.homebrewXBEs[hash]; !ok {
+					idx.homebrewXBEs[hash] = titleKey{titleID, version}
+				}
+			}
+		}
+	}
+	return idx
+}
+
+// Returns the name of the known update of a title with the given hash, if any.
+func (idx *titleIndex) knownUpdate(titleID, hash string) (string, bool) {
+	name, ok := idx.knownUpdates[titleKey{titleID, hash}]
+	return name, ok
+}
+
+// Returns the name a title's content is archived under, or "" if it isn't.
+func (idx *titleIndex) archivedName(titleID, contentID string) string {
+	return idx.archived[titleKey{titleID, contentID}]
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	fatihColor "github.com/fatih/color"
+)
+
+// Smallest and largest piece sizes a torrent is made with, and the number of pieces aimed for in between.
+const (
+	minTorrentPieceSize = 256 << 10
+	maxTorrentPieceSize = 16 << 20
+	torrentPieces       = 1500
+)
+
+// Appends the bencoding of v, which is a string, integer, list or dictionary of those, to buf. Dictionary keys are
+// written in sorted order, as the format requires.
+func bencode(buf *bytes.Buffer, v any) {
+	switch v := v.(type) {
+	case string:
+		fmt.Fprintf(buf, "%d:%s", len(v), v)
+	case []byte:
+		fmt.Fprintf(buf, "%d:", len(v))
+		buf.Write(v)
+	case int:
+		fmt.Fprintf(buf, "i%de", v)
+	case int64:
+		fmt.Fprintf(buf, "i%de", v)
+	case []any:
+		buf.WriteByte('l')
+		for _, item := range v {
+			bencode(buf, item)
+		}
+		buf.WriteByte('e')
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('d')
+		for _, key := range keys {
+			bencode(buf, key)
+			bencode(buf, v[key])
+		}
+		buf.WriteByte('e')
+	default:
+		panic(fmt.Sprintf("can't bencode %T", v))
+	}
+}
+
+// Returns the piece size for a torrent of totalSize bytes: a power of two giving about torrentPieces pieces.
+func torrentPieceSize(totalSize int64) int64 {
+	size := int64(minTorrentPieceSize)
+	for size < maxTorrentPieceSize && totalSize/size > torrentPieces {
+		size *= 2
+	}
+	return size
+}
+
+// torrentFile is a file of the torrent, at a slash separated path inside its folder.
+type torrentFile struct {
+	path string
+	size int64
+}
+
+// Returns the files of a folder to share, in the order they're hashed.
+func torrentFiles(dir string) ([]torrentFile, error) {
+	var files []torrentFile
+	err := fs.WalkDir(os.DirFS(dir), ".", func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, torrentFile{path: filePath, size: info.Size()})
+		return nil
+	})
+	sort.Slice(files, func(i, j int) bool { return files[i].path < files[j].path })
+	return files, err
+}
+
+// Returns the SHA1s of the pieces of the files, read one after another as if they were one.
+func hashTorrentPieces(open func(file torrentFile) (io.ReadCloser, error), files []torrentFile, pieceSize int64) ([]byte, error) {
+	var pieces []byte
+	piece := sha1.New()
+	filled := int64(0)
+	for _, file := range files {
+		in, err := open(file)
+		if err != nil {
+			return nil, err
+		}
+		for {
+			n, err := io.CopyN(piece, in, pieceSize-filled)
+			filled += n
+			if filled == pieceSize {
+				pieces = piece.Sum(pieces)
+				piece.Reset()
+				filled = 0
+			}
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				in.Close()
+				return nil, err
+			}
+		}
+		in.Close()
+	}
+	if filled > 0 {
+		pieces = piece.Sum(pieces)
+	}
+	return pieces, nil
+}
+
+// Writes a .torrent of target, a --bundle zip or a folder such as one written by --pull or export, so it can be
+// shared between the preservation group's members without a central server. Web seeds let clients download from
+// HTTP servers holding a copy too.
+func writeTorrent(torrentPath, target string, trackers, webSeeds []string, private bool) error {
+	stat, err := os.Stat(target)
+	if err != nil {
+		return err
+	}
+	isDir := stat.IsDir()
+	files := []torrentFile{{path: filepath.Base(target), size: stat.Size()}}
+	if isDir {
+		if files, err = torrentFiles(target); err != nil {
+			return err
+		}
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("%s holds no files to share", target)
+	}
+	var totalSize int64
+	for _, file := range files {
+		totalSize += file.size
+	}
+	pieceSize := torrentPieceSize(totalSize)
+
+	pieces, err := hashTorrentPieces(func(file torrentFile) (io.ReadCloser, error) {
+		if !isDir {
+			return os.Open(target)
+		}
+		return os.Open(filepath.Join(target, filepath.FromSlash(file.path)))
+	}, files, pieceSize)
+	if err != nil {
+		return err
+	}
+
+	info := map[string]any{
+		"name":         filepath.Base(filepath.Clean(target)),
+		"piece length": pieceSize,
+		"pieces":       pieces,
+	}
+	if isDir {
+		list := make([]any, 0, len(files))
+		for _, file := range files {
+			var pathList []any
+			for _, part := range strings.Split(file.path, "/") {
+				pathList = append(pathList, part)
+			}
+			list = append(list, map[string]any{"length": file.size, "path": pathList})
+		}
+		info["files"] = list
+	} else {
+		info["length"] = files[0].size
+	}
+	if private {
+		info["private"] = 1
+	}
+
+	torrent := map[string]any{
+		"created by":    "Pinecone v" + version,
+		"creation date": time.Now().Unix(),
+		"info":          info,
+	}
+	if len(trackers) > 0 {
+		torrent["announce"] = trackers[0]
+		// Each tracker gets a tier of its own, so clients try them in the order given
+		tiers := make([]any, 0, len(trackers))
+		for _, tracker := range trackers {
+			tiers = append(tiers, []any{tracker})
+		}
+		torrent["announce-list"] = tiers
+	}
+	if len(webSeeds) > 0 {
+		seeds := make([]any, 0, len(webSeeds))
+		for _, seed := range webSeeds {
+			seeds = append(seeds, seed)
+		}
+		torrent["url-list"] = seeds
+	}
+
+	var buf bytes.Buffer
+	bencode(&buf, torrent)
+	if err := checkWritable(torrentPath); err != nil {
+		return err
+	}
+	if err := os.WriteFile(torrentPath, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	var infoBuf bytes.Buffer
+	bencode(&infoBuf, info)
+	printInfo(fatihColor.FgGreen, "Torrent of %d files (%.1f MB) saved to: %s\n", len(files), float64(totalSize)/(1<<20), torrentPath)
+	printInfo(fatihColor.FgWhite, "Info hash: %x\n", sha1.Sum(infoBuf.Bytes()))
+	if len(trackers) == 0 && len(webSeeds) == 0 {
+		printInfo(fatihColor.FgYellow, "No -tracker or -webseed given, so peers can only find it through DHT\n")
+	}
+	return nil
+}
+
+// Makes a .torrent of a submission bundle or exported folder, with the trackers and web seeds given, or else those
+// of the settings.
+func runTorrent(args []string) error {
+	flags := newCommandFlags("torrent")
+	var trackers, webSeeds urlList
+	flags.Var(&trackers, "tracker", "Announce URL of a tracker, repeat to add several")
+	flags.Var(&webSeeds, "webseed", "URL of an HTTP server holding a copy, repeat to add several")
+	private := flags.Bool("private", false, "Mark the torrent private, so clients only find peers through its trackers")
+	output := flags.String("o", "", "Where to write the .torrent, defaulting to the bundle or folder's name with .torrent added")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: pinecone torrent [-tracker URL]... [-webseed URL]... [-private] [-o file.torrent] bundle.zip|folder")
+	}
+	target := filepath.Clean(flags.Arg(0))
+	if len(trackers) == 0 && len(webSeeds) == 0 {
+		if settings, err := loadSettings(); err == nil {
+			trackers, webSeeds = settings.TorrentTrackers, settings.TorrentWebSeeds
+		}
+	}
+	torrentPath := *output
+	if torrentPath == "" {
+		torrentPath = target + ".torrent"
+	}
+	// Hashing the pieces can take a while, so a refused write is reported before it starts
+	if err := checkWritable(torrentPath); err != nil {
+		return err
+	}
+	return writeTorrent(torrentPath, target, trackers, webSeeds, *private)
+}
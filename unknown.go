@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+// UnknownTitleReport describes what a titleID directory missing from the database holds.
+type UnknownTitleReport struct {
+	// ProbableName is a guess at the title, from the XBEs inside or the title's saves
+	ProbableName string       `json:"probableName,omitempty" xml:"probableName,omitempty"`
+	Size         int64        `json:"size" xml:"size"`
+	Files        int          `json:"files" xml:"files"`
+	XBEs         []UnknownXBE `json:"xbes,omitempty" xml:"xbe,omitempty"`
+	ContentMeta  []string     `json:"contentMeta,omitempty" xml:"contentMeta,omitempty"`
+}
+
+// UnknownXBE is an XBE found in an unrecognized directory.
+type UnknownXBE struct {
+	Path string     `json:"path" xml:"path"`
+	SHA1 string     `json:"sha1" xml:"sha1"`
+	XBE  *XBEReport `json:"xbe,omitempty" xml:"xbe,omitempty"`
+}
+
+// Walks an unrecognized titleID directory, totalling its files and reading any XBEs and contentmeta.xbx inside. Files
+// that can't be read are recorded as scan errors and left out, so the rest of the directory is still inventoried.
+func inspectUnknownTitle(ctx context.Context, source *scanSource, directory string, titleDir string) (*UnknownTitleReport, error) {
+	fsys := source.FS
+	unknown := &UnknownTitleReport{}
+	err := fs.WalkDir(fsys, titleDir, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if err := skipScanError(ctx, source, filePath, err); err != nil {
+				return err
+			}
+			if d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if isIgnored(filePath) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return skipScanError(ctx, source, filePath, err)
+		}
+		unknown.Files++
+		unknown.Size += info.Size()
+
+		switch {
+		case strings.EqualFold(path.Ext(d.Name()), ".xbe"):
+			hash, err := getSHA1Hash(ctx, fsys, filePath)
+			if err != nil {
+				return skipScanError(ctx, source, filePath, err)
+			}
+			found := UnknownXBE{Path: reportPath(directory, filePath), SHA1: hash}
+			if xbe, err := readXBEFile(fsys, filePath); err == nil {
+				found.XBE = newXBEReport(xbe)
+			}
+			unknown.XBEs = append(unknown.XBEs, found)
+		case strings.EqualFold(d.Name(), "contentmeta.xbx"):
+			unknown.ContentMeta = append(unknown.ContentMeta, reportPath(directory, filePath))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error inspecting %s: %v", source.displayPath(titleDir), err)
+	}
+
+	unknown.ProbableName = probableTitleName(fsys, path.Base(titleDir), unknown)
+
+	if guiEnabled {
+		addText(theme.PrimaryColorNamed(theme.ColorYellow), "Unrecognized title directory: %s (%d files, %.1f MB)", source.displayPath(titleDir), unknown.Files, float64(unknown.Size)/1e6)
+	}
+	printInfo(fatihColor.FgYellow, "Unrecognized title directory: %s (%d files, %.1f MB)\n", source.displayPath(titleDir), unknown.Files, float64(unknown.Size)/1e6)
+	if unknown.ProbableName != "" {
+		if guiEnabled {
+			addText(theme.PrimaryColorNamed(theme.ColorYellow), "Probably %s", unknown.ProbableName)
+		}
+		printInfo(fatihColor.FgYellow, "Probably %s\n", unknown.ProbableName)
+	}
+	for _, found := range unknown.XBEs {
+		if found.XBE == nil {
+			continue
+		}
+		if guiEnabled {
+			addText(theme.ForegroundColor(), "XBE: %s (%s) version %d at %s", found.XBE.TitleName, found.XBE.TitleID, found.XBE.Version, found.Path)
+		}
+		printInfo(fatihColor.FgWhite, "XBE: %s (%s) version %d at %s\n", found.XBE.TitleName, found.XBE.TitleID, found.XBE.Version, found.Path)
+		printXBEFlags(found.XBE)
+	}
+	if len(unknown.ContentMeta) > 0 {
+		if guiEnabled {
+			addText(theme.ForegroundColor(), "%d contentmeta.xbx files inside", len(unknown.ContentMeta))
+		}
+		printInfo(fatihColor.FgWhite, "%d contentmeta.xbx files inside\n", len(unknown.ContentMeta))
+	}
+	return unknown, nil
+}
+
+// Guesses the name of an unrecognized title. An XBE whose certificate matches the directory is the best evidence,
+// then any other XBE, then the TitleMeta.xbx of the title's saves.
+func probableTitleName(fsys fs.FS, titleID string, unknown *UnknownTitleReport) string {
+	for _, found := range unknown.XBEs {
+		if found.XBE != nil && strings.EqualFold(found.XBE.TitleID, titleID) && found.XBE.TitleName != "" {
+			return found.XBE.TitleName
+		}
+	}
+	for _, found := range unknown.XBEs {
+		if found.XBE != nil && found.XBE.TitleName != "" {
+			return found.XBE.TitleName
+		}
+	}
+	titleMeta := findFileFold(fsys, findFileFold(fsys, findFileFold(fsys, ".", "UDATA"), titleID), "TitleMeta.xbx")
+	return readMetaValue(fsys, titleMeta, "TitleName")
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Name of the file in the data folder caching the last update check, and how long it's trusted for.
+const (
+	updateCheckName     = "update_check.json"
+	updateCheckInterval = 24 * time.Hour
+	updateCheckTimeout  = 5 * time.Second
+)
+
+// Where Pinecone's releases are published.
+const (
+	latestReleaseURL = "https://api.github.com/repos/Xbox-Preservation-Project/Pinecone/releases/latest"
+	releasesURL      = "https://github.com/Xbox-Preservation-Project/Pinecone/releases"
+)
+
+// updateCheck is the result of the last check for a newer database and release.
+type updateCheck struct {
+	Checked string `json:"checked"`
+	// Revision is the local database revision checked, so an update made since is noticed
+	Revision         string `json:"revision"`
+	DatabaseOutdated bool   `json:"databaseOutdated"`
+	LatestRelease    string `json:"latestRelease,omitempty"`
+}
+
+// Returns a client for update checks, which give up quickly rather than hold up the scan.
+func updateCheckClient() (*http.Client, error) {
+	client, err := httpClient()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: client.Transport, Timeout: updateCheckTimeout}, nil
+}
+
+// Reports whether the server has a newer copy of the database at u than the local one at jsonFilePath, asking only
+// for the headers of a conditional request. Copies without a download state, such as edited ones, are never outdated.
+func databaseOutdated(client *http.Client, u, jsonFilePath string) (bool, error) {
+	state := readDownloadState(jsonFilePath)
+	if state.ETag == "" && state.LastModified == "" {
+		return false, nil
+	}
+	req, err := http.NewRequest("HEAD", u, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/vnd.github.v3.raw")
+	authorizeGitHub(req)
+	if state.ETag != "" {
+		req.Header.Set("If-None-Match", state.ETag)
+	}
+	if state.LastModified != "" {
+		req.Header.Set("If-Modified-Since", state.LastModified)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return false, nil
+	case http.StatusOK:
+		return true, nil
+	}
+	return false, fmt.Errorf("checking %s: %s", u, resp.Status)
+}
+
+// Returns the version of the latest release, without its "v".
+func latestRelease(client *http.Client) (string, error) {
+	req, err := http.NewRequest("GET", latestReleaseURL, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	authorizeGitHub(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("checking %s: %s", latestReleaseURL, resp.Status)
+	}
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", err
+	}
+	return strings.TrimPrefix(release.TagName, "v"), nil
+}
+
+// Reports whether version a is newer than b, comparing their dot-separated numbers.
+func newerVersion(a, b string) bool {
+	aParts, bParts := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(aParts), len(bParts)); i++ {
+		var x, y int
+		if i < len(aParts) {
+			x, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			y, _ = strconv.Atoi(bParts[i])
+		}
+		if x != y {
+			return x > y
+		}
+	}
+	return false
+}
+
+// Returns the result of the last update check, checking again if it's a day old or the database changed since.
+// Checks are best effort: one that fails, such as when offline, finds nothing and isn't tried again for a day, so
+// starting Pinecone doesn't wait on the network every time.
+func runUpdateCheck(jsonFilePath, jsonURL string) (updateCheck, bool) {
+	checkPath := filepath.Join(dataPath, updateCheckName)
+	revision := databaseRevision(jsonFilePath)
+	var cached updateCheck
+	if data, err := os.ReadFile(checkPath); err == nil && json.Unmarshal(data, &cached) == nil && cached.Revision == revision {
+		if checked, err := time.Parse(time.RFC3339, cached.Checked); err == nil && time.Since(checked) < updateCheckInterval {
+			return cached, true
+		}
+	}
+
+	client, err := updateCheckClient()
+	if err != nil {
+		return updateCheck{}, false
+	}
+	check := updateCheck{Checked: time.Now().UTC().Format(time.RFC3339), Revision: revision}
+	// A pinned revision is meant to be old
+	if dbVersionFlag == "" {
+		check.DatabaseOutdated, _ = databaseOutdated(client, jsonURL, jsonFilePath)
+	}
+	check.LatestRelease, _ = latestRelease(client)
+	if checkWritable(checkPath) == nil {
+		if data, err := json.Marshal(check); err == nil {
+			os.WriteFile(checkPath, data, 0o644)
+		}
+	}
+	return check, true
+}
+
+// Prints a one-line notice when a newer database or Pinecone release is available, instead of silently scanning
+// with stale data.
+func notifyUpdates(jsonFilePath, jsonURL string) {
+	if noUpdateCheckFlag || porcelainFlag {
+		return
+	}
+	check, ok := runUpdateCheck(jsonFilePath, jsonURL)
+	if !ok {
+		return
+	}
+	if check.DatabaseOutdated {
+		notice := "A newer database revision is available"
+		if info, err := os.Stat(jsonFilePath); err == nil {
+			notice = fmt.Sprintf("The database is %d days old and a newer revision is available", int(time.Since(info.ModTime()).Hours()/24))
+		}
+		printDatabaseStatus("%s, run -u to update", notice)
+	}
+	if newerVersion(check.LatestRelease, version) {
+		printDatabaseStatus("Pinecone v%s is available, you have v%s: %s", check.LatestRelease, version, releasesURL)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+// UpdateVersionReport compares the newest update on the console with the newest one the database knows about.
+type UpdateVersionReport struct {
+	Installed uint32 `json:"installed" xml:"installed"`
+	Latest    uint32 `json:"latest" xml:"latest"`
+	UpToDate  bool   `json:"upToDate" xml:"upToDate,attr"`
+}
+
+// Returns the XBE version an update ID is for. Update IDs start with the version as a 32-bit hex number, as in
+// 0000000300000103 for version 3.
+func updateIDVersion(updateID string) (uint32, bool) {
+	if !isHexString(updateID, 16) {
+		return 0, false
+	}
+	version, err := strconv.ParseUint(updateID[:8], 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint32(version), true
+}
+
+// Returns the newest update version the database lists for a title, or 0 if it lists none.
+func latestUpdateVersion(titleData TitleData) uint32 {
+	var latest uint32
+	check := func(updateID string) {
+		if version, ok := updateIDVersion(updateID); ok && version > latest {
+			latest = version
+		}
+	}
+	for _, updateID := range titleData.TitleUpdates {
+		check(updateID)
+	}
+	// Known update names start with their update ID, as in "0000000300000103:RF English Update 1"
+	for _, knownUpdate := range titleData.TitleUpdatesKnown {
+		for _, name := range knownUpdate {
+			updateID, _, _ := strings.Cut(name, ":")
+			check(updateID)
+		}
+	}
+	return latest
+}
+
+// Reports which update version a title has installed and whether it's the newest the database knows about. Only
+// intact XBEs count towards the installed version.
+func reportUpdateVersions(titleData TitleData, titleReport *TitleReport) {
+	latest := latestUpdateVersion(titleData)
+	if latest == 0 {
+		return
+	}
+	status := &UpdateVersionReport{Latest: latest}
+	for _, update := range titleReport.Updates {
+		if update.XBE != nil && update.Integrity == "" && update.KnownBad == "" && update.XBE.Version > status.Installed {
+			status.Installed = update.XBE.Version
+		}
+	}
+	status.UpToDate = status.Installed >= latest
+	titleReport.UpdateVersion = status
+
+	switch {
+	case status.UpToDate:
+		if guiEnabled {
+			addText(theme.PrimaryColorNamed(theme.ColorGreen), "Newest known update installed (version %d)", status.Installed)
+		}
+		printInfo(fatihColor.FgGreen, "Newest known update installed (version %d)\n", status.Installed)
+	case status.Installed == 0:
+		if guiEnabled {
+			addText(theme.PrimaryColorNamed(theme.ColorYellow), "No update installed, version %d is the newest known", latest)
+		}
+		printInfo(fatihColor.FgYellow, "No update installed, version %d is the newest known\n", latest)
+	default:
+		if guiEnabled {
+			addText(theme.PrimaryColorNamed(theme.ColorYellow), "Update version %d installed, version %d is the newest known", status.Installed, latest)
+		}
+		printInfo(fatihColor.FgYellow, "Update version %d installed, version %d is the newest known\n", status.Installed, latest)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Number of directories listed at once ahead of a walk. Listing a directory over SMB or NFS is mostly waiting on
+// the network, so this is well above what hashing uses, and higher still with --network.
+const (
+	walkWorkers        = 16
+	networkWalkWorkers = 64
+)
+
+// walkFS lists the directories of a dump folder on several workers ahead of the walks that need them, and reads
+// the file info of every entry at the same time. Walks still visit directories in the usual order, so results don't
+// change, but on network shares they no longer wait on one listing and one stat at a time.
+type walkFS struct {
+	fs.FS
+
+	mu      sync.Mutex
+	dirs    map[string]*pendingDir
+	queue   []string
+	workers int
+}
+
+type pendingDir struct {
+	done    chan struct{}
+	entries []fs.DirEntry
+	err     error
+}
+
+func newWalkFS(fsys fs.FS) *walkFS {
+	return &walkFS{FS: fsys, dirs: make(map[string]*pendingDir)}
+}
+
+// Stat returns the file info of name. With --network it's taken from the listing of its directory, which has the
+// info of every entry and is usually listed already, rather than asking the share for each file.
+func (w *walkFS) Stat(name string) (fs.FileInfo, error) {
+	if networkFlag && name != "." {
+		if entries, err := w.ReadDir(path.Dir(name)); err == nil {
+			base := path.Base(name)
+			i := sort.Search(len(entries), func(i int) bool { return entries[i].Name() >= base })
+			// Listings describe symlinks themselves rather than what they point to
+			if i < len(entries) && entries[i].Name() == base && entries[i].Type()&fs.ModeSymlink == 0 {
+				return entries[i].Info()
+			}
+		}
+	}
+	return fs.Stat(w.FS, name)
+}
+
+// ReadDir returns the listing of a directory, waiting for its worker if it's already being listed and listing it on
+// the spot otherwise. Listings are kept, since several scanners walk the same folders.
+func (w *walkFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	pending, first := w.claim(name)
+	if first {
+		w.list(name, pending)
+	}
+	<-pending.done
+	return pending.entries, pending.err
+}
+
+// Returns the pending listing of a directory, and whether the caller is the first to ask for it and should list it.
+func (w *walkFS) claim(name string) (*pendingDir, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if pending, ok := w.dirs[name]; ok {
+		return pending, false
+	}
+	pending := &pendingDir{done: make(chan struct{})}
+	w.dirs[name] = pending
+	return pending, true
+}
+
+// Lists a directory with the info of its entries, then queues its subdirectories.
+func (w *walkFS) list(name string, pending *pendingDir) {
+	defer close(pending.done)
+	entries, err := fs.ReadDir(w.FS, name)
+	pending.err = err
+	var subdirs []string
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil {
+			entry = fs.FileInfoToDirEntry(info)
+		}
+		pending.entries = append(pending.entries, entry)
+		if entry.IsDir() {
+			subdirs = append(subdirs, path.Join(name, entry.Name()))
+		}
+	}
+	w.push(subdirs)
+}
+
+// Queues directories to be listed, starting workers as needed. The queue is a stack, so listing runs depth first
+// like the walks it's ahead of.
+func (w *walkFS) push(dirs []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i := len(dirs) - 1; i >= 0; i-- {
+		w.queue = append(w.queue, dirs[i])
+	}
+	limit := walkWorkers
+	if networkFlag {
+		limit = networkWalkWorkers
+	}
+	for ; w.workers < limit && w.workers < len(w.queue); w.workers++ {
+		go w.work()
+	}
+}
+
+// Lists queued directories until the queue is empty.
+func (w *walkFS) work() {
+	for {
+		w.mu.Lock()
+		if len(w.queue) == 0 {
+			w.workers--
+			w.mu.Unlock()
+			return
+		}
+		name := w.queue[len(w.queue)-1]
+		w.queue = w.queue[:len(w.queue)-1]
+		w.mu.Unlock()
+
+		if pending, first := w.claim(name); first {
+			w.list(name, pending)
+		}
+	}
+}
+
+// Drops the listings kept of name, the directories below it and those above it, whose listings change with it, so
+// they're listed again the next time they're walked.
+func (w *walkFS) forget(name string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for dir := range w.dirs {
+		if dir == name || strings.HasPrefix(dir, name+"/") {
+			delete(w.dirs, dir)
+		}
+	}
+	for dir := path.Dir(name); ; dir = path.Dir(dir) {
+		delete(w.dirs, dir)
+		if dir == "." {
+			break
+		}
+	}
+}
+
+// Stops listing the directories still queued.
+func (w *walkFS) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.queue = nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// wantedTitle is a title with content or updates the database knows of but nobody has archived.
+type wantedTitle struct {
+	titleID   string
+	titleName string
+	platform  string
+	content   []string
+	updates   []string
+}
+
+// Returns the publisher code a title ID starts with, the two characters its first four hex digits spell, as in EA
+// for 4541xxxx.
+func titlePublisher(titleID string) string {
+	if !isHexString(titleID, 8) {
+		return ""
+	}
+	code, _ := hex.DecodeString(titleID[:4])
+	for _, c := range code {
+		if c < 0x20 || c > 0x7e {
+			return ""
+		}
+	}
+	return string(code)
+}
+
+// Returns the titles with unarchived content IDs or updates, filtered by title ID or name and by publisher, in title
+// ID order.
+func wantedTitles(title, publisher string) []wantedTitle {
+	title = strings.ToLower(strings.TrimSpace(title))
+	publisher = strings.TrimSpace(publisher)
+	var wanted []wantedTitle
+	for _, section := range []struct {
+		titles   map[string]TitleData
+		platform string
+	}{{titles.Titles, ""}, {titles.Chihiro, platformChihiro}, {titles.Debug, platformDebug}} {
+		for titleID, data := range section.titles {
+			if title != "" && titleID != title && !strings.Contains(strings.ToLower(data.TitleName), title) {
+				continue
+			}
+			if publisher != "" && !strings.EqualFold(titlePublisher(titleID), publisher) && (len(publisher) != 4 || !strings.HasPrefix(titleID, strings.ToLower(publisher))) {
+				continue
+			}
+			entry := wantedTitle{titleID: titleID, titleName: data.TitleName, platform: section.platform}
+			for _, contentID := range data.ContentIDs {
+				if databaseIndex.archivedName(titleID, contentID) == "" {
+					entry.content = append(entry.content, contentID)
+				}
+			}
+			for updateID, archived := range titleUpdateIDs(data) {
+				if !archived {
+					entry.updates = append(entry.updates, updateID)
+				}
+			}
+			if len(entry.content) == 0 && len(entry.updates) == 0 {
+				continue
+			}
+			sort.Strings(entry.content)
+			sort.Strings(entry.updates)
+			wanted = append(wanted, entry)
+		}
+	}
+	sort.Slice(wanted, func(i, j int) bool {
+		if wanted[i].titleID != wanted[j].titleID {
+			return wanted[i].titleID < wanted[j].titleID
+		}
+		return wanted[i].platform < wanted[j].platform
+	})
+	return wanted
+}
+
+// Prints the content and updates the database knows of but that aren't archived, for collectors to look for on their
+// drives.
+func runWanted(args []string) error {
+	flags := newCommandFlags("wanted")
+	title := flags.String("title", "", "Only list the title with this ID, or titles whose name contains this")
+	publisher := flags.String("publisher", "", "Only list titles of this publisher code, as in EA or 4541")
+	format := flags.String("format", "text", "List format: text, md or csv")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("usage: pinecone wanted [-title ID or name] [-publisher code] [-format text|md|csv]")
+	}
+	if *format != "text" && *format != "md" && *format != "csv" {
+		return fmt.Errorf("unknown wanted list format %q, expected text, md or csv", *format)
+	}
+	if err := loadCommandDatabase(); err != nil {
+		return err
+	}
+	return writeWanted(os.Stdout, wantedTitles(*title, *publisher), *format)
+}
+
+func writeWanted(w io.Writer, wanted []wantedTitle, format string) error {
+	if format == "csv" {
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"title_id", "title_name", "platform", "kind", "id"})
+		for _, entry := range wanted {
+			for _, contentID := range entry.content {
+				cw.Write([]string{entry.titleID, entry.titleName, entry.platform, "content", contentID})
+			}
+			for _, updateID := range entry.updates {
+				cw.Write([]string{entry.titleID, entry.titleName, entry.platform, "update", updateID})
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	items := 0
+	for _, entry := range wanted {
+		items += len(entry.content) + len(entry.updates)
+	}
+	revision := "unknown"
+	if len(loadedDatabases) > 0 {
+		revision = loadedDatabases[0].String()
+	}
+	if format == "md" {
+		fmt.Fprintf(w, "# Wanted: %d items from %d titles\n\nDatabase %s\n", items, len(wanted), revision)
+	} else {
+		fmt.Fprintf(w, "Wanted: %d items from %d titles (database %s)\n", items, len(wanted), revision)
+	}
+	for _, entry := range wanted {
+		name := fmt.Sprintf("%s (%s)", entry.titleName, entry.titleID)
+		if entry.platform != "" {
+			name += ", " + platformNames[entry.platform]
+		}
+		if format == "md" {
+			fmt.Fprintf(w, "\n## %s\n\n", name)
+			for _, contentID := range entry.content {
+				fmt.Fprintf(w, "- Content `%s`\n", contentID)
+			}
+			for _, updateID := range entry.updates {
+				fmt.Fprintf(w, "- Title update `%s`\n", updateID)
+			}
+			continue
+		}
+		fmt.Fprintf(w, "\n%s\n", name)
+		for _, contentID := range entry.content {
+			fmt.Fprintf(w, "    Content %s\n", contentID)
+		}
+		for _, updateID := range entry.updates {
+			fmt.Fprintf(w, "    Title update %s\n", updateID)
+		}
+	}
+	return nil
+}
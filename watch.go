@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+	"github.com/fsnotify/fsnotify"
+)
+
+// How long a watched dump has to stay quiet before what changed in it is scanned, so content still being copied,
+// such as over FTP from a console, isn't scanned half written.
+const watchSettleDelay = 3 * time.Second
+
+// watchedTitle is a title folder of a watched source changed since it was last scanned.
+type watchedTitle struct {
+	source *scanSource
+	// path is the folder inside the source, such as TDATA/4d530064
+	path string
+}
+
+// Returns the source a changed file belongs to and the TDATA or UDATA title folder it's in, if it's in one.
+func changedTitle(sources []*scanSource, name string) (watchedTitle, bool) {
+	for _, source := range sources {
+		rel, err := filepath.Rel(source.Dir, name)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if len(parts) < 2 || len(parts[1]) != 8 || (!strings.EqualFold(parts[0], tdataFolder) && !strings.EqualFold(parts[0], "UDATA")) || !titleSelected(parts[1]) {
+			return watchedTitle{}, false
+		}
+		if strings.EqualFold(parts[0], tdataFolder) {
+			parts[0] = tdataFolder
+		}
+		return watchedTitle{source: source, path: parts[0] + "/" + parts[1]}, true
+	}
+	return watchedTitle{}, false
+}
+
+// Watches dir and every folder below it, calling found for each of them.
+func addWatchTree(watcher *fsnotify.Watcher, dir string, found func(name string)) error {
+	return filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Removed again before it could be watched
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		found(name)
+		return watcher.Add(name)
+	})
+}
+
+// Drops what the last scan of a title folder added to scanResults, so scanning it again doesn't list it twice.
+func dropTitleResults(source *scanSource, titlePath string) {
+	reported := reportPath(tdataFolder, titlePath)
+	reportName := source.reportName()
+	titleResults := scanResults.Titles[:0]
+	for _, title := range scanResults.Titles {
+		if title.Source != reportName || title.Path != reported {
+			titleResults = append(titleResults, title)
+		}
+	}
+	scanResults.Titles = titleResults
+	homebrew := scanResults.Homebrew[:0]
+	for _, found := range scanResults.Homebrew {
+		if found.Source != reportName || found.Path != reported {
+			homebrew = append(homebrew, found)
+		}
+	}
+	scanResults.Homebrew = homebrew
+	artifacts := scanResults.Artifacts[:0]
+	for _, artifact := range scanResults.Artifacts {
+		if artifact.Source != reportName || !strings.HasPrefix(artifact.Path, reported+"/") {
+			artifacts = append(artifacts, artifact)
+		}
+	}
+	scanResults.Artifacts = artifacts
+}
+
+// Drops the saves and softmods found in the UDATA folder of source, so they can be checked again.
+func dropSaveResults(source *scanSource) {
+	reportName := source.reportName()
+	saves := scanResults.Saves[:0]
+	for _, save := range scanResults.Saves {
+		if save.Source != reportName {
+			saves = append(saves, save)
+		}
+	}
+	scanResults.Saves = saves
+	softmods := scanResults.Softmods[:0]
+	for _, softmod := range scanResults.Softmods {
+		if softmod.Source != reportName {
+			softmods = append(softmods, softmod)
+		}
+	}
+	scanResults.Softmods = softmods
+}
+
+// Scans the title folders changed since the last scan, then brings the summary and reports up to date.
+func rescanChanged(ctx context.Context, changed map[watchedTitle]bool, enabled []Scanner) error {
+	var titles []watchedTitle
+	for title := range changed {
+		titles = append(titles, title)
+	}
+	sort.Slice(titles, func(i, j int) bool {
+		if titles[i].source.Dir != titles[j].source.Dir {
+			return titles[i].source.Dir < titles[j].source.Dir
+		}
+		return titles[i].path < titles[j].path
+	})
+
+	if guiEnabled {
+		addHeader("Changes")
+	}
+	printHeader("Changes")
+	udataChanged := make(map[*scanSource]bool)
+	for _, title := range titles {
+		source := title.source
+		if walker, ok := source.FS.(*walkFS); ok {
+			walker.forget(title.path)
+		}
+		if folder, _, _ := strings.Cut(title.path, "/"); folder != tdataFolder {
+			udataChanged[source] = true
+			continue
+		}
+		if !contains(scannerNamesOf(enabled), "content") {
+			continue
+		}
+		dropTitleResults(source, title.path)
+		if _, err := fs.Stat(source.FS, title.path); err != nil {
+			if guiEnabled {
+				addText(theme.ForegroundColor(), "Removed: %s", source.displayPath(title.path))
+			}
+			printInfo(fatihColor.FgWhite, "Removed: %s\n", source.displayPath(title.path))
+			continue
+		}
+		if !containsSource(scanResults.sources, source) {
+			scanResults.sources = append(scanResults.sources, source)
+		}
+		if err := walkContent(ctx, source, title.path); err != nil {
+			return err
+		}
+	}
+	for _, source := range sortedSources(udataChanged) {
+		dropSaveResults(source)
+		for _, scanner := range enabled {
+			if (scanner.Name() == "saves" || scanner.Name() == "softmods") && scanner.Match(source) {
+				if err := scanner.Scan(ctx, source); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	scanResults.DuplicateUpdates, scanResults.Missing, scanResults.Regions = nil, nil, nil
+	checkDuplicateUpdates()
+	checkMissingContent()
+	summarizeRegions()
+	if fileHashCache != nil {
+		if err := fileHashCache.save(); err != nil {
+			return err
+		}
+	}
+	return exportReports()
+}
+
+// Reports whether sources holds source.
+func containsSource(sources []*scanSource, source *scanSource) bool {
+	for _, other := range sources {
+		if other == source {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the sources in set, sorted by folder.
+func sortedSources(set map[*scanSource]bool) []*scanSource {
+	var sorted []*scanSource
+	for source := range set {
+		sorted = append(sorted, source)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Dir < sorted[j].Dir })
+	return sorted
+}
+
+// Watches the TDATA and UDATA folders of the dump folders scanned for content arriving or changing, and scans each
+// title folder changed once the dump has been quiet for a moment, until ctx is cancelled. Reports are written again
+// after every scan.
+func watchSources(ctx context.Context, sources []*scanSource, enabled []Scanner) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("error watching the dump: %v", err)
+	}
+	defer watcher.Close()
+
+	var watched []*scanSource
+	changed := make(map[watchedTitle]bool)
+	found := func(name string) {
+		if title, ok := changedTitle(watched, name); ok {
+			changed[title] = true
+		}
+	}
+	for _, source := range sources {
+		if source.Dir == "" {
+			continue
+		}
+		// The dump folder itself is watched for TDATA or UDATA appearing in it
+		if err := watcher.Add(source.Dir); err != nil {
+			return fmt.Errorf("error watching %s: %v", source.Dir, err)
+		}
+		for _, folder := range []string{tdataFolder, findFileFold(source.FS, ".", "UDATA")} {
+			if folder == "" {
+				continue
+			}
+			if info, err := os.Stat(filepath.Join(source.Dir, folder)); err != nil || !info.IsDir() {
+				continue
+			}
+			if err := addWatchTree(watcher, filepath.Join(source.Dir, folder), func(string) {}); err != nil {
+				return fmt.Errorf("error watching %s: %v", source.displayPath(folder), err)
+			}
+		}
+		watched = append(watched, source)
+	}
+	if len(watched) == 0 {
+		return fmt.Errorf("-watch only works on dump folders, not archives")
+	}
+
+	if guiEnabled {
+		addText(theme.PrimaryColorNamed(theme.ColorBlue), "Watching for new content, press Ctrl+C to stop")
+	}
+	printInfo(fatihColor.FgBlue, "Watching for new content, press Ctrl+C to stop\n")
+	settle := time.NewTimer(watchSettleDelay)
+	settle.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			if guiEnabled {
+				addText(theme.PrimaryColorNamed(theme.ColorYellow), "Error watching the dump: %v", err)
+			}
+			printInfo(fatihColor.FgYellow, "Error watching the dump: %v\n", err)
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			// Folders created, including TDATA or UDATA themselves, are watched too, along with what's already
+			// in them
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if isWatchedFolder(watched, event.Name) {
+						if err := addWatchTree(watcher, event.Name, found); err != nil {
+							return fmt.Errorf("error watching %s: %v", event.Name, err)
+						}
+					}
+				}
+			}
+			found(event.Name)
+			settle.Reset(watchSettleDelay)
+		case <-settle.C:
+			if len(changed) == 0 {
+				continue
+			}
+			err := rescanChanged(ctx, changed, enabled)
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			changed = make(map[watchedTitle]bool)
+		}
+	}
+}
+
+// Reports whether name is a TDATA or UDATA folder, or a folder inside one, of a watched source.
+func isWatchedFolder(sources []*scanSource, name string) bool {
+	for _, source := range sources {
+		rel, err := filepath.Rel(source.Dir, name)
+		if err != nil {
+			continue
+		}
+		folder, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+		if strings.EqualFold(folder, tdataFolder) || strings.EqualFold(folder, "UDATA") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"unicode/utf16"
+)
+
+// XBE image header offsets.
+const (
+	xbeMagic              = "XBEH"
+	xbeBaseAddressOffset  = 0x104
+	xbeHeaderSizeOffset   = 0x108
+	xbeImageSizeOffset    = 0x10C
+	xbeTimestampOffset    = 0x114
+	xbeCertificateOffset  = 0x118
+	xbeSectionCountOffset = 0x11C
+	xbeSectionsOffset     = 0x120
+	xbeImageHeaderMinSize = 0x178
+)
+
+// XBE section header layout.
+const (
+	xbeSectionHeaderSize   = 0x38
+	xbeSectionRawAddress   = 0x0C
+	xbeSectionRawSize      = 0x10
+	xbeSectionNameAddress  = 0x14
+	xbeSectionDigestOffset = 0x24
+	xbeMaxSections         = 1024
+	xbeMaxSectionName      = 64
+)
+
+// Integrity problems found by checkXBEIntegrity.
+const (
+	xbeTruncated = "truncated"
+	xbeCorrupted = "corrupted"
+)
+
+// XBE certificate offsets, relative to the start of the certificate.
+const (
+	xbeCertTitleIDOffset   = 0x08
+	xbeCertTitleNameOffset = 0x0C
+	xbeCertTitleNameLength = 40
+	xbeCertMediaOffset     = 0x9C
+	xbeCertRegionOffset    = 0xA0
+	xbeCertDiscOffset      = 0xA8
+	xbeCertVersionOffset   = 0xAC
+	xbeCertMinSize         = 0xB0
+)
+
+var errNotXBE = errors.New("not an XBE file")
+
+// XBEInfo holds the fields Pinecone reads from an XBE header and certificate.
+type XBEInfo struct {
+	BaseAddress uint32
+	HeaderSize  uint32
+	ImageSize   uint32
+	Timestamp   uint32
+	TitleID     string
+	TitleName   string
+	Version     uint32
+	RegionFlags uint32
+	MediaFlags  uint32
+	DiscNumber  uint32
+}
+
+var xbeRegionNames = []struct {
+	flag uint32
+	name string
+}{
+	{0x00000001, "NTSC-U"},
+	{0x00000002, "NTSC-J"},
+	{0x00000004, "PAL"},
+	{0x80000000, "Debug"},
+}
+
+var xbeMediaNames = []struct {
+	flag uint32
+	name string
+}{
+	{0x00000001, "Hard Disk"},
+	{0x00000002, "DVD X2"},
+	{0x00000004, "DVD/CD"},
+	{0x00000008, "CD"},
+	{0x00000010, "DVD-5 RO"},
+	{0x00000020, "DVD-9 RO"},
+	{0x00000040, "DVD-5 RW"},
+	{0x00000080, "DVD-9 RW"},
+	{0x00000100, "Dongle"},
+	{0x00000200, "Media Board"},
+	{0x40000000, "Nonsecure Hard Disk"},
+	{0x80000000, "Nonsecure Mode"},
+}
+
+// Regions returns the names of the game region flags set in the certificate.
+func (xbe *XBEInfo) Regions() []string {
+	var names []string
+	for _, region := range xbeRegionNames {
+		if xbe.RegionFlags&region.flag != 0 {
+			names = append(names, region.name)
+		}
+	}
+	return names
+}
+
+// Media returns the names of the allowed media flags set in the certificate.
+func (xbe *XBEInfo) Media() []string {
+	var names []string
+	for _, media := range xbeMediaNames {
+		if xbe.MediaFlags&media.flag != 0 {
+			names = append(names, media.name)
+		}
+	}
+	return names
+}
+
+func readUint32(data []byte, offset int) uint32 {
+	return binary.LittleEndian.Uint32(data[offset : offset+4])
+}
+
+// Decodes a null terminated UTF-16LE string.
+func decodeUTF16(data []byte) string {
+	chars := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		c := binary.LittleEndian.Uint16(data[i:])
+		if c == 0 {
+			break
+		}
+		chars = append(chars, c)
+	}
+	return strings.TrimSpace(string(utf16.Decode(chars)))
+}
+
+// Parses the image header and certificate of an XBE.
+func parseXBE(r io.ReaderAt) (*XBEInfo, error) {
+	header := make([]byte, xbeImageHeaderMinSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, errNotXBE
+		}
+		return nil, err
+	}
+	if string(header[:4]) != xbeMagic {
+		return nil, errNotXBE
+	}
+
+	xbe := &XBEInfo{
+		BaseAddress: readUint32(header, xbeBaseAddressOffset),
+		HeaderSize:  readUint32(header, xbeHeaderSizeOffset),
+		ImageSize:   readUint32(header, xbeImageSizeOffset),
+		Timestamp:   readUint32(header, xbeTimestampOffset),
+	}
+
+	certAddress := readUint32(header, xbeCertificateOffset)
+	if certAddress < xbe.BaseAddress || certAddress-xbe.BaseAddress >= xbe.HeaderSize {
+		return nil, fmt.Errorf("XBE certificate address 0x%08x is outside of the headers", certAddress)
+	}
+	cert := make([]byte, xbeCertMinSize)
+	if _, err := r.ReadAt(cert, int64(certAddress-xbe.BaseAddress)); err != nil {
+		return nil, fmt.Errorf("error reading XBE certificate: %v", err)
+	}
+
+	xbe.TitleID = fmt.Sprintf("%08x", readUint32(cert, xbeCertTitleIDOffset))
+	xbe.TitleName = decodeUTF16(cert[xbeCertTitleNameOffset : xbeCertTitleNameOffset+xbeCertTitleNameLength*2])
+	xbe.MediaFlags = readUint32(cert, xbeCertMediaOffset)
+	xbe.RegionFlags = readUint32(cert, xbeCertRegionOffset)
+	xbe.DiscNumber = readUint32(cert, xbeCertDiscOffset)
+	xbe.Version = readUint32(cert, xbeCertVersionOffset)
+	return xbe, nil
+}
+
+// Checks an XBE against the sizes its headers declare and the SHA1 digest of each section, returning
+// xbeTruncated or xbeCorrupted along with what was wrong, or an empty string for an intact file.
+func checkXBEIntegrity(r io.ReaderAt, fileSize int64, xbe *XBEInfo) (string, []string) {
+	if int64(xbe.HeaderSize) > fileSize {
+		return xbeTruncated, []string{fmt.Sprintf("headers are 0x%x bytes but the file is 0x%x bytes", xbe.HeaderSize, fileSize)}
+	}
+	header := make([]byte, xbe.HeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return xbeTruncated, []string{fmt.Sprintf("error reading headers: %v", err)}
+	}
+	count := readUint32(header, xbeSectionCountOffset)
+	sectionsAddress := readUint32(header, xbeSectionsOffset)
+	start := int64(sectionsAddress) - int64(xbe.BaseAddress)
+	if count > xbeMaxSections || start < 0 || start+int64(count)*xbeSectionHeaderSize > int64(len(header)) {
+		return xbeCorrupted, []string{"section headers are outside of the headers"}
+	}
+
+	result := ""
+	var problems []string
+	for i := int64(0); i < int64(count); i++ {
+		section := header[start+i*xbeSectionHeaderSize : start+(i+1)*xbeSectionHeaderSize]
+		name := fmt.Sprintf("#%d", i)
+		if nameOffset := int64(readUint32(section, xbeSectionNameAddress)) - int64(xbe.BaseAddress); nameOffset >= 0 && nameOffset < int64(len(header)) {
+			raw := header[nameOffset:min(nameOffset+xbeMaxSectionName, int64(len(header)))]
+			if end := bytes.IndexByte(raw, 0); end > 0 {
+				name = string(raw[:end])
+			}
+		}
+
+		rawAddress := int64(readUint32(section, xbeSectionRawAddress))
+		rawSize := readUint32(section, xbeSectionRawSize)
+		if rawAddress+int64(rawSize) > fileSize {
+			result = xbeTruncated
+			problems = append(problems, fmt.Sprintf("section %s ends at 0x%x but the file is 0x%x bytes", name, rawAddress+int64(rawSize), fileSize))
+			continue
+		}
+
+		// Section digests cover the raw size followed by the raw data
+		digest := sha1.New()
+		binary.Write(digest, binary.LittleEndian, rawSize)
+		if _, err := io.Copy(digest, io.NewSectionReader(r, rawAddress, int64(rawSize))); err != nil {
+			return xbeTruncated, append(problems, fmt.Sprintf("error reading section %s: %v", name, err))
+		}
+		if !bytes.Equal(digest.Sum(nil), section[xbeSectionDigestOffset:xbeSectionDigestOffset+sha1.Size]) {
+			if result == "" {
+				result = xbeCorrupted
+			}
+			problems = append(problems, fmt.Sprintf("section %s doesn't match its digest", name))
+		}
+	}
+	return result, problems
+}
+
+func readXBEFile(fsys fs.FS, filePath string) (*XBEInfo, error) {
+	file, err := fsys.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if r, ok := file.(io.ReaderAt); ok {
+		return parseXBE(r)
+	}
+	// Files without random access are read into memory
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	return parseXBE(bytes.NewReader(data))
+}
+
+// Opens an XBE and runs checkXBEIntegrity on it.
+func checkXBEFile(fsys fs.FS, filePath string, xbe *XBEInfo) (string, []string, error) {
+	file, err := fsys.Open(filePath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", nil, err
+	}
+	if r, ok := file.(io.ReaderAt); ok {
+		integrity, problems := checkXBEIntegrity(r, info.Size(), xbe)
+		return integrity, problems, nil
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return "", nil, err
+	}
+	integrity, problems := checkXBEIntegrity(bytes.NewReader(data), int64(len(data)), xbe)
+	return integrity, problems, nil
+}
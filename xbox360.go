@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2/theme"
+	fatihColor "github.com/fatih/color"
+)
+
+// Xbox 360 content type folders, found under Content/<profile>/<titleID>.
+var xbox360ContentTypes = map[uint32]string{
+	0x00000001: "Saved Game",
+	0x00000002: "Marketplace Content",
+	0x00000003: "Publisher",
+	0x00001000: "Xbox 360 Title",
+	0x00004000: "Installed Game",
+	0x00005000: "Xbox Original Game",
+	0x00007000: "Game on Demand",
+	0x00009000: "Avatar Item",
+	0x00010000: "Profile",
+	0x00020000: "Gamer Picture",
+	0x00030000: "Theme",
+	0x00040000: "Cache File",
+	0x00050000: "Storage Download",
+	0x00060000: "Xbox Saved Game",
+	0x00070000: "Xbox Download",
+	0x00080000: "Game Demo",
+	0x00090000: "Video",
+	0x000A0000: "Game Title",
+	0x000B0000: "Title Update",
+	0x000C0000: "Game Trailer",
+	0x000D0000: "Arcade Title",
+	0x000E0000: "XNA",
+	0x000F0000: "License Store",
+	0x00100000: "Movie",
+	0x00200000: "TV",
+	0x00300000: "Music Video",
+	0x00400000: "Game Video",
+	0x00500000: "Podcast Video",
+	0x00600000: "Viral Video",
+}
+
+// Content types holding original Xbox content played through backwards compatibility.
+var xbox360OriginalXboxTypes = []uint32{0x00005000, 0x00060000, 0x00070000}
+
+// Xbox360Content is a content type folder of an Xbox 360 title.
+type Xbox360Content struct {
+	Profile string `json:"profile" xml:"profile,attr"`
+	TitleID string `json:"titleID" xml:"titleID,attr"`
+	Type    string `json:"type" xml:"type"`
+	TypeID  string `json:"typeID" xml:"typeID,attr"`
+	Path    string `json:"path" xml:"path"`
+	Files   int    `json:"files" xml:"files"`
+}
+
+// Reports whether dir looks like an Xbox 360 drive or USB dump, with a Content/<profile> folder and no TDATA.
+func isXbox360Dump(dir string) bool {
+	if _, err := os.Stat(path.Join(dir, tdataFolder)); err == nil {
+		return false
+	}
+	fsys := os.DirFS(dir)
+	entries, err := fs.ReadDir(fsys, findFileFold(fsys, ".", "Content"))
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && isHexString(entry.Name(), 16) {
+			return true
+		}
+	}
+	return false
+}
+
+// Lists the titles and content types of an Xbox 360 dump. Pinecone only tracks original Xbox content, so this
+// is a warning plus an inventory, with original Xbox content played on a 360 called out.
+func scanXbox360(dir string) error {
+	if guiEnabled {
+		addText(theme.PrimaryColorNamed(theme.ColorYellow), "%s looks like an Xbox 360 dump, which Pinecone doesn't check against its database", dir)
+	}
+	printInfo(fatihColor.FgYellow, "%s looks like an Xbox 360 dump, which Pinecone doesn't check against its database\n", dir)
+
+	fsys := os.DirFS(dir)
+	contentDir := findFileFold(fsys, ".", "Content")
+	profiles, err := fs.ReadDir(fsys, contentDir)
+	if err != nil {
+		return fmt.Errorf("error reading Xbox 360 content: %v", err)
+	}
+	for _, profile := range profiles {
+		if !profile.IsDir() || !isHexString(profile.Name(), 16) {
+			continue
+		}
+		profileDir := path.Join(contentDir, profile.Name())
+		titleDirs, err := fs.ReadDir(fsys, profileDir)
+		if err != nil {
+			return fmt.Errorf("error reading Xbox 360 content: %v", err)
+		}
+		for _, titleDir := range titleDirs {
+			if !titleDir.IsDir() || !isHexString(titleDir.Name(), 8) {
+				continue
+			}
+			if err := scanXbox360Title(fsys, profile.Name(), path.Join(profileDir, titleDir.Name())); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(scanResults.Xbox360) == 0 {
+		if guiEnabled {
+			addText(theme.ForegroundColor(), "No Xbox 360 content found")
+		}
+		printInfo(fatihColor.FgWhite, "No Xbox 360 content found\n")
+	}
+	return nil
+}
+
+func scanXbox360Title(fsys fs.FS, profile string, titleDir string) error {
+	titleID := strings.ToLower(path.Base(titleDir))
+	typeDirs, err := fs.ReadDir(fsys, titleDir)
+	if err != nil {
+		return fmt.Errorf("error reading Xbox 360 content: %v", err)
+	}
+	sort.Slice(typeDirs, func(i, j int) bool { return typeDirs[i].Name() < typeDirs[j].Name() })
+	for _, typeDir := range typeDirs {
+		typeID, err := strconv.ParseUint(typeDir.Name(), 16, 32)
+		if !typeDir.IsDir() || len(typeDir.Name()) != 8 || err != nil {
+			continue
+		}
+		typeName, ok := xbox360ContentTypes[uint32(typeID)]
+		if !ok {
+			typeName = "Unknown content type"
+		}
+		files, err := fs.ReadDir(fsys, path.Join(titleDir, typeDir.Name()))
+		if err != nil {
+			return fmt.Errorf("error reading Xbox 360 content: %v", err)
+		}
+		content := Xbox360Content{
+			Profile: profile,
+			TitleID: titleID,
+			Type:    typeName,
+			TypeID:  strings.ToLower(typeDir.Name()),
+			Path:    path.Join(titleDir, typeDir.Name()),
+			Files:   len(files),
+		}
+
+		titleName := titleID
+		if titleData, _, ok := lookupTitle(titleID); ok {
+			titleName = fmt.Sprintf("%s (%s)", titleData.TitleName, titleID)
+		}
+		original := false
+		for _, originalType := range xbox360OriginalXboxTypes {
+			if uint32(typeID) == originalType {
+				original = true
+			}
+		}
+		if original {
+			if guiEnabled {
+				addText(theme.PrimaryColorNamed(theme.ColorYellow), "%s: %s, %d files, original Xbox content worth checking", titleName, typeName, content.Files)
+			}
+			printInfo(fatihColor.FgYellow, "%s: %s, %d files, original Xbox content worth checking\n", titleName, typeName, content.Files)
+		} else {
+			if guiEnabled {
+				addText(theme.ForegroundColor(), "%s: %s, %d files", titleName, typeName, content.Files)
+			}
+			printInfo(fatihColor.FgWhite, "%s: %s, %d files\n", titleName, typeName, content.Files)
+		}
+		scanResults.Xbox360 = append(scanResults.Xbox360, content)
+	}
+	return nil
+}
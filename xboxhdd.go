@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Xbox hard drive layout. Retail drives use fixed offsets; drives partitioned with XBPartitioner carry a table in sector 0.
+const (
+	xboxPartitionTableMagic   = "****PARTINFO****"
+	xboxPartitionTableOffset  = 0x30
+	xboxPartitionEntrySize    = 32
+	xboxPartitionEntries      = 14
+	xboxPartitionInUse        = 0x80000000
+	xboxExtendedOffset        = 0x1DD156000
+	xboxLBA28Limit            = 0x0FFFFFFF * fatxSectorSize
+	xboxMinimumPartitionBytes = 0x100000
+)
+
+// XboxPartition is the location of a single partition on an Xbox hard drive.
+type XboxPartition struct {
+	Letter string
+	Offset int64
+	Size   int64
+}
+
+var xboxRetailPartitions = []XboxPartition{
+	{"E", 0xABE80000, 0x1312D6000},
+	{"C", 0x8CA80000, 0x1F400000},
+	{"X", 0x80000, 0x2EE00000},
+	{"Y", 0x2EE80000, 0x2EE00000},
+	{"Z", 0x5DC80000, 0x2EE00000},
+}
+
+// Partition letters in XBPartitioner table order.
+var xboxPartitionTableLetters = []string{"E", "C", "X", "Y", "Z", "F", "G"}
+
+// Partitions that can hold games and saves, in the order they are scanned.
+var xboxContentPartitions = []string{"C", "E", "F", "G"}
+
+// Partitions titles use as scratch space, scanned with -cache.
+var xboxCachePartitions = []string{"X", "Y", "Z"}
+
+// Returns the partitions of an Xbox hard drive image of the given size.
+func xboxPartitions(r io.ReaderAt, diskSize int64) ([]XboxPartition, error) {
+	sector := make([]byte, fatxSectorSize)
+	if _, err := r.ReadAt(sector, 0); err != nil {
+		return nil, err
+	}
+
+	if string(sector[:len(xboxPartitionTableMagic)]) == xboxPartitionTableMagic {
+		var partitions []XboxPartition
+		for i := 0; i < xboxPartitionEntries && i < len(xboxPartitionTableLetters); i++ {
+			entry := sector[xboxPartitionTableOffset+i*xboxPartitionEntrySize:]
+			flags := binary.LittleEndian.Uint32(entry[16:])
+			start := int64(binary.LittleEndian.Uint32(entry[20:])) * fatxSectorSize
+			size := int64(binary.LittleEndian.Uint32(entry[24:])) * fatxSectorSize
+			if flags&xboxPartitionInUse == 0 || size == 0 {
+				continue
+			}
+			partitions = append(partitions, XboxPartition{xboxPartitionTableLetters[i], start, size})
+		}
+		return partitions, nil
+	}
+
+	// Without a table, F takes the space past the retail partitions up to the LBA28 limit and G the rest
+	partitions := append([]XboxPartition(nil), xboxRetailPartitions...)
+	if diskSize-xboxExtendedOffset >= xboxMinimumPartitionBytes {
+		partitions = append(partitions, XboxPartition{"F", xboxExtendedOffset, min(diskSize, xboxLBA28Limit) - xboxExtendedOffset})
+	}
+	if diskSize-xboxLBA28Limit >= xboxMinimumPartitionBytes {
+		partitions = append(partitions, XboxPartition{"G", xboxLBA28Limit, diskSize - xboxLBA28Limit})
+	}
+	return partitions, nil
+}
+
+// Unlocks an attached drive with the HDD key of the EEPROM given by -eeprom.
+func unlockWithEEPROM(devicePath string) error {
+	info, err := os.Stat(devicePath)
+	if err != nil {
+		return fmt.Errorf("error opening image: %v", err)
+	}
+	if info.Mode()&os.ModeDevice == 0 {
+		// Locking is enforced by the drive itself, so data imaged from a drive is never locked
+		printLine("Image files are never locked, ignoring -eeprom")
+		return nil
+	}
+
+	eeprom, err := loadEEPROM(eepromFlag, eepromKeyFlag)
+	if err != nil {
+		return err
+	}
+	return unlockATADrive(devicePath, eeprom.HDDKey)
+}
+
+// Opens a memory unit image, or the FATX partitions of a raw or qcow2 HDD image, or an attached drive, that hold a TDATA folder,
+// along with the C partition, the cache partitions if -cache is set and the E partition if -software is set.
+func openImageSources(imagePath string) ([]*scanSource, func(), error) {
+	if eepromFlag != "" {
+		if err := unlockWithEEPROM(imagePath); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	file, err := os.Open(imagePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening image: %v", err)
+	}
+	var disk io.ReaderAt = file
+	var diskSize int64
+	if isDevicePath(imagePath) {
+		// Devices report a size of zero, so ask the device instead
+		disk = sectorReader{file}
+		diskSize, err = deviceSize(file)
+	} else {
+		var info os.FileInfo
+		if info, err = file.Stat(); err == nil {
+			diskSize = info.Size()
+		}
+	}
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("error opening image: %v", err)
+	}
+	magic := make([]byte, len(qcow2Magic))
+	if _, err := disk.ReadAt(magic, 0); err == nil && isQcow2(magic) {
+		qcow2, err := openQcow2(file)
+		if err != nil {
+			file.Close()
+			return nil, nil, fmt.Errorf("error opening image: %v", err)
+		}
+		disk, diskSize = qcow2, qcow2.Size()
+		if _, err := disk.ReadAt(magic, 0); err != nil {
+			file.Close()
+			return nil, nil, fmt.Errorf("error opening image: %v", err)
+		}
+	}
+
+	// Memory units hold a single FATX partition starting at the first sector, with no partition layout
+	if string(magic) == fatxMagic {
+		fatx, err := openFATXPartition(disk, 0, diskSize)
+		if err != nil {
+			file.Close()
+			return nil, nil, fmt.Errorf("error opening memory unit: %v", err)
+		}
+		return []*scanSource{{Display: filepath.Base(imagePath), FS: fatx, MemoryUnit: true}}, func() { file.Close() }, nil
+	}
+
+	partitions, err := xboxPartitions(disk, diskSize)
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("error reading partition layout: %v", err)
+	}
+
+	letters := xboxContentPartitions
+	if cacheFlag {
+		letters = append(append([]string(nil), letters...), xboxCachePartitions...)
+	}
+
+	var sources []*scanSource
+	for _, letter := range letters {
+		cache := contains(xboxCachePartitions, letter)
+		for _, partition := range partitions {
+			if partition.Letter != letter || partition.Offset+fatxHeaderSize > diskSize {
+				continue
+			}
+			fatx, err := openFATXPartition(disk, partition.Offset, min(partition.Size, diskSize-partition.Offset))
+			if err != nil {
+				continue
+			}
+			// C is always opened so softmods can be detected on it
+			if _, err := fs.Stat(fatx, tdataFolder); err != nil && !cache && letter != "C" && !(softwareFlag && contains(softwarePartitions, letter)) {
+				continue
+			}
+			sources = append(sources, &scanSource{
+				Label:   partition.Letter,
+				Display: filepath.Base(imagePath) + ":" + partition.Letter,
+				FS:      fatx,
+				Cache:   cache,
+			})
+		}
+	}
+	if len(sources) == 0 {
+		file.Close()
+		return nil, nil, fmt.Errorf("no FATX partition with a TDATA folder found in %s", imagePath)
+	}
+	return sources, func() { file.Close() }, nil
+}
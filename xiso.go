@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+)
+
+// XDVDFS layout constants.
+const (
+	xisoMagic              = "MICROSOFT*XBOX*MEDIA"
+	xisoSectorSize         = 2048
+	xisoVolumeSector       = 32
+	xisoDirentHeaderSize   = 14
+	xisoAttrDirectory      = 0x10
+	xisoEmptyTreeOffset    = 0xFFFF
+	xisoMaxDirectoryLength = 64 << 20
+)
+
+// Offsets of the game partition in full disc images. Extracted XISOs start with it, redump images carry a video partition first.
+var xisoPartitionOffsets = []int64{0, 0x18300000, 0xFD90000, 0x2080000}
+
+var errXISOCorrupt = errors.New("corrupt XDVDFS filesystem")
+
+// XISOImage is a read-only XDVDFS filesystem, implementing fs.FS.
+type XISOImage struct {
+	r          io.ReaderAt
+	offset     int64
+	rootSector uint32
+	rootSize   uint32
+	created    time.Time
+}
+
+// Converts a Windows FILETIME to a time.
+func filetime(value uint64) time.Time {
+	const epochDifference = 116444736000000000
+	if value < epochDifference {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(value-epochDifference)*100).UTC()
+}
+
+// Finds the XDVDFS volume in r, trying the game partition offsets used by full disc images.
+func openXISO(r io.ReaderAt) (*XISOImage, error) {
+	volume := make([]byte, 0x24)
+	for _, offset := range xisoPartitionOffsets {
+		if _, err := r.ReadAt(volume, offset+xisoVolumeSector*xisoSectorSize); err != nil {
+			continue
+		}
+		if string(volume[:len(xisoMagic)]) == xisoMagic {
+			return &XISOImage{
+				r:          r,
+				offset:     offset,
+				rootSector: binary.LittleEndian.Uint32(volume[0x14:]),
+				rootSize:   binary.LittleEndian.Uint32(volume[0x18:]),
+				created:    filetime(binary.LittleEndian.Uint64(volume[0x1C:])),
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("no XDVDFS volume found")
+}
+
+type xisoDirent struct {
+	name       string
+	attributes uint8
+	sector     uint32
+	size       uint32
+}
+
+// Reads a directory, whose entries form a binary tree linked by dword offsets.
+func (x *XISOImage) readDir(sector uint32, size uint32) ([]xisoDirent, error) {
+	if size == 0 {
+		return nil, nil
+	}
+	if size > xisoMaxDirectoryLength {
+		return nil, fmt.Errorf("%w: directory is too large", errXISOCorrupt)
+	}
+	data := make([]byte, size)
+	if _, err := x.r.ReadAt(data, x.offset+int64(sector)*xisoSectorSize); err != nil {
+		return nil, err
+	}
+
+	var entries []xisoDirent
+	visited := make(map[int]bool)
+	var walk func(offset int) error
+	walk = func(offset int) error {
+		if visited[offset] {
+			return fmt.Errorf("%w: directory tree loops", errXISOCorrupt)
+		}
+		visited[offset] = true
+		if offset+xisoDirentHeaderSize > len(data) {
+			return fmt.Errorf("%w: directory entry out of range", errXISOCorrupt)
+		}
+		raw := data[offset:]
+		left := binary.LittleEndian.Uint16(raw[0:])
+		right := binary.LittleEndian.Uint16(raw[2:])
+		if left == xisoEmptyTreeOffset {
+			return nil // Padding or an empty directory
+		}
+		nameLength := int(raw[13])
+		if offset+xisoDirentHeaderSize+nameLength > len(data) {
+			return fmt.Errorf("%w: directory entry out of range", errXISOCorrupt)
+		}
+		entries = append(entries, xisoDirent{
+			name:       string(raw[xisoDirentHeaderSize : xisoDirentHeaderSize+nameLength]),
+			attributes: raw[12],
+			sector:     binary.LittleEndian.Uint32(raw[4:]),
+			size:       binary.LittleEndian.Uint32(raw[8:]),
+		})
+		if left != 0 {
+			if err := walk(int(left) * 4); err != nil {
+				return err
+			}
+		}
+		if right != 0 {
+			if err := walk(int(right) * 4); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(0); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (x *XISOImage) lookup(name string) (xisoDirent, error) {
+	entry := xisoDirent{name: ".", attributes: xisoAttrDirectory, sector: x.rootSector, size: x.rootSize}
+	if name == "." {
+		return entry, nil
+	}
+	for _, element := range strings.Split(name, "/") {
+		if entry.attributes&xisoAttrDirectory == 0 {
+			return xisoDirent{}, fs.ErrNotExist
+		}
+		entries, err := x.readDir(entry.sector, entry.size)
+		if err != nil {
+			return xisoDirent{}, err
+		}
+		found := false
+		for _, child := range entries {
+			if strings.EqualFold(child.name, element) {
+				entry, found = child, true
+				break
+			}
+		}
+		if !found {
+			return xisoDirent{}, fs.ErrNotExist
+		}
+	}
+	return entry, nil
+}
+
+// Open implements fs.FS. Files are stored contiguously, so they support io.ReaderAt and io.Seeker.
+func (x *XISOImage) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	entry, err := x.lookup(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	info := xisoFileInfo{entry: entry, modified: x.created}
+	if entry.attributes&xisoAttrDirectory != 0 {
+		entries, err := x.readDir(entry.sector, entry.size)
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		return &xisoDir{image: x, info: info, entries: entries}, nil
+	}
+	return &xisoFile{
+		info:          info,
+		SectionReader: io.NewSectionReader(x.r, x.offset+int64(entry.sector)*xisoSectorSize, int64(entry.size)),
+	}, nil
+}
+
+// xisoFileInfo implements fs.FileInfo and fs.DirEntry. XDVDFS only records the volume creation time.
+type xisoFileInfo struct {
+	entry    xisoDirent
+	modified time.Time
+}
+
+func (fi xisoFileInfo) Name() string       { return fi.entry.name }
+func (fi xisoFileInfo) Size() int64        { return int64(fi.entry.size) }
+func (fi xisoFileInfo) ModTime() time.Time { return fi.modified }
+func (fi xisoFileInfo) IsDir() bool        { return fi.entry.attributes&xisoAttrDirectory != 0 }
+func (fi xisoFileInfo) Sys() any           { return nil }
+
+func (fi xisoFileInfo) Mode() fs.FileMode {
+	if fi.IsDir() {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+func (fi xisoFileInfo) Type() fs.FileMode          { return fi.Mode().Type() }
+func (fi xisoFileInfo) Info() (fs.FileInfo, error) { return fi, nil }
+
+type xisoFile struct {
+	*io.SectionReader
+	info xisoFileInfo
+}
+
+func (f *xisoFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *xisoFile) Close() error               { return nil }
+
+type xisoDir struct {
+	image   *XISOImage
+	info    xisoFileInfo
+	entries []xisoDirent
+	offset  int
+}
+
+func (d *xisoDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *xisoDir) Close() error               { return nil }
+
+func (d *xisoDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.Name(), Err: errors.New("is a directory")}
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *xisoDir) ReadDir(count int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if count > 0 && len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if count > 0 && count < len(remaining) {
+		remaining = remaining[:count]
+	}
+	d.offset += len(remaining)
+
+	result := make([]fs.DirEntry, len(remaining))
+	for i, entry := range remaining {
+		result[i] = xisoFileInfo{entry: entry, modified: d.image.created}
+	}
+	return result, nil
+}
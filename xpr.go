@@ -0,0 +1,252 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+)
+
+// XPR0 bundle and D3D texture resource layout.
+const (
+	xprMagic            = "XPR0"
+	xprHeaderSize       = 12
+	xprResourceSize     = 20
+	xprFormatShift      = 8
+	xprUSizeShift       = 20
+	xprVSizeShift       = 24
+	xprSizeMask         = 0xF
+	xprFormatMask       = 0xFF
+	xprResourceTypeMask = 0x00070000
+	xprTextureType      = 0x00040000
+)
+
+// D3D texture formats found in dashboard images.
+const (
+	xprFormatA1R5G5B5 = 0x02
+	xprFormatA4R4G4B4 = 0x04
+	xprFormatR5G6B5   = 0x05
+	xprFormatA8R8G8B8 = 0x06
+	xprFormatX8R8G8B8 = 0x07
+	xprFormatDXT1     = 0x0C
+	xprFormatDXT3     = 0x0E
+	xprFormatDXT5     = 0x0F
+	xprFormatLinARGB  = 0x12
+	xprFormatLinRGB   = 0x1E
+)
+
+// Returns the offset of a pixel in a swizzled texture, interleaving the bits of x and y.
+func swizzleOffset(x, y, width, height int) int {
+	offset, bit := 0, 0
+	for w, h := width, height; w > 1 || h > 1; {
+		if w > 1 {
+			offset |= (x & 1) << bit
+			x >>= 1
+			bit++
+			w >>= 1
+		}
+		if h > 1 {
+			offset |= (y & 1) << bit
+			y >>= 1
+			bit++
+			h >>= 1
+		}
+	}
+	return offset
+}
+
+func rgb565(c uint16) color.NRGBA {
+	r := uint8(c >> 11 & 0x1F)
+	g := uint8(c >> 5 & 0x3F)
+	b := uint8(c & 0x1F)
+	return color.NRGBA{r<<3 | r>>2, g<<2 | g>>4, b<<3 | b>>2, 0xFF}
+}
+
+func blendChannel(a, b uint8, wa, wb, total int) uint8 {
+	return uint8((int(a)*wa + int(b)*wb) / total)
+}
+
+// Decodes the colour endpoints and indices of a DXT colour block into image.
+func decodeDXTColorBlock(img *image.NRGBA, block []byte, bx, by int, alwaysFourColors bool) {
+	c0 := binary.LittleEndian.Uint16(block[0:])
+	c1 := binary.LittleEndian.Uint16(block[2:])
+	colors := [4]color.NRGBA{rgb565(c0), rgb565(c1)}
+	if c0 > c1 || alwaysFourColors {
+		colors[2] = color.NRGBA{blendChannel(colors[0].R, colors[1].R, 2, 1, 3), blendChannel(colors[0].G, colors[1].G, 2, 1, 3), blendChannel(colors[0].B, colors[1].B, 2, 1, 3), 0xFF}
+		colors[3] = color.NRGBA{blendChannel(colors[0].R, colors[1].R, 1, 2, 3), blendChannel(colors[0].G, colors[1].G, 1, 2, 3), blendChannel(colors[0].B, colors[1].B, 1, 2, 3), 0xFF}
+	} else {
+		colors[2] = color.NRGBA{blendChannel(colors[0].R, colors[1].R, 1, 1, 2), blendChannel(colors[0].G, colors[1].G, 1, 1, 2), blendChannel(colors[0].B, colors[1].B, 1, 1, 2), 0xFF}
+		colors[3] = color.NRGBA{}
+	}
+
+	indices := binary.LittleEndian.Uint32(block[4:])
+	for py := 0; py < 4; py++ {
+		for px := 0; px < 4; px++ {
+			img.SetNRGBA(bx+px, by+py, colors[indices>>(2*(py*4+px))&3])
+		}
+	}
+}
+
+// Applies the explicit 4 bit alpha of a DXT3 block.
+func decodeDXT3Alpha(img *image.NRGBA, block []byte, bx, by int) {
+	alpha := binary.LittleEndian.Uint64(block)
+	for i := 0; i < 16; i++ {
+		a := uint8(alpha >> (4 * i) & 0xF)
+		offset := img.PixOffset(bx+i%4, by+i/4)
+		img.Pix[offset+3] = a<<4 | a
+	}
+}
+
+// Applies the interpolated alpha of a DXT5 block.
+func decodeDXT5Alpha(img *image.NRGBA, block []byte, bx, by int) {
+	var alphas [8]uint8
+	alphas[0], alphas[1] = block[0], block[1]
+	if alphas[0] > alphas[1] {
+		for i := 1; i < 7; i++ {
+			alphas[i+1] = blendChannel(alphas[0], alphas[1], 7-i, i, 7)
+		}
+	} else {
+		for i := 1; i < 5; i++ {
+			alphas[i+1] = blendChannel(alphas[0], alphas[1], 5-i, i, 5)
+		}
+		alphas[6], alphas[7] = 0, 0xFF
+	}
+
+	var bits uint64
+	for i := 0; i < 6; i++ {
+		bits |= uint64(block[2+i]) << (8 * i)
+	}
+	for i := 0; i < 16; i++ {
+		offset := img.PixOffset(bx+i%4, by+i/4)
+		img.Pix[offset+3] = alphas[bits>>(3*i)&7]
+	}
+}
+
+func decodeDXT(data []byte, width, height int, format uint32) (*image.NRGBA, error) {
+	blockSize := 16
+	if format == xprFormatDXT1 {
+		blockSize = 8
+	}
+	blocksWide, blocksHigh := (width+3)/4, (height+3)/4
+	if len(data) < blocksWide*blocksHigh*blockSize {
+		return nil, fmt.Errorf("texture data is truncated")
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, blocksWide*4, blocksHigh*4))
+	for by := 0; by < blocksHigh; by++ {
+		for bx := 0; bx < blocksWide; bx++ {
+			block := data[(by*blocksWide+bx)*blockSize:]
+			switch format {
+			case xprFormatDXT1:
+				decodeDXTColorBlock(img, block, bx*4, by*4, false)
+			case xprFormatDXT3:
+				decodeDXTColorBlock(img, block[8:], bx*4, by*4, true)
+				decodeDXT3Alpha(img, block, bx*4, by*4)
+			default:
+				decodeDXTColorBlock(img, block[8:], bx*4, by*4, true)
+				decodeDXT5Alpha(img, block, bx*4, by*4)
+			}
+		}
+	}
+	return img.SubImage(image.Rect(0, 0, width, height)).(*image.NRGBA), nil
+}
+
+// Decodes uncompressed 16 and 32 bit textures, which are swizzled unless stored in a linear format.
+func decodePixels(data []byte, width, height int, format uint32) (*image.NRGBA, error) {
+	bytesPerPixel := 4
+	switch format {
+	case xprFormatA1R5G5B5, xprFormatA4R4G4B4, xprFormatR5G6B5:
+		bytesPerPixel = 2
+	}
+	if len(data) < width*height*bytesPerPixel {
+		return nil, fmt.Errorf("texture data is truncated")
+	}
+	linear := format == xprFormatLinARGB || format == xprFormatLinRGB
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			index := y*width + x
+			if !linear {
+				index = swizzleOffset(x, y, width, height)
+			}
+			pixel := data[index*bytesPerPixel:]
+
+			var c color.NRGBA
+			switch format {
+			case xprFormatA8R8G8B8, xprFormatLinARGB:
+				c = color.NRGBA{pixel[2], pixel[1], pixel[0], pixel[3]}
+			case xprFormatX8R8G8B8, xprFormatLinRGB:
+				c = color.NRGBA{pixel[2], pixel[1], pixel[0], 0xFF}
+			case xprFormatR5G6B5:
+				c = rgb565(binary.LittleEndian.Uint16(pixel))
+			case xprFormatA4R4G4B4:
+				v := binary.LittleEndian.Uint16(pixel)
+				c = color.NRGBA{uint8(v>>8&0xF) * 0x11, uint8(v>>4&0xF) * 0x11, uint8(v&0xF) * 0x11, uint8(v>>12) * 0x11}
+			case xprFormatA1R5G5B5:
+				v := binary.LittleEndian.Uint16(pixel)
+				r, g, b := uint8(v>>10&0x1F), uint8(v>>5&0x1F), uint8(v&0x1F)
+				c = color.NRGBA{r<<3 | r>>2, g<<3 | g>>2, b<<3 | b>>2, uint8(v>>15) * 0xFF}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img, nil
+}
+
+// Decodes the first texture in an XPR0 bundle, as used by titleimage.xbx and saveimage.xbx.
+func decodeXPR(data []byte) (image.Image, error) {
+	if len(data) < xprHeaderSize+xprResourceSize || string(data[:4]) != xprMagic {
+		return nil, fmt.Errorf("not an XPR image")
+	}
+	headerSize := int(binary.LittleEndian.Uint32(data[8:]))
+	if headerSize > len(data) {
+		return nil, fmt.Errorf("XPR header size %d is larger than the file", headerSize)
+	}
+
+	for offset := xprHeaderSize; offset+xprResourceSize <= headerSize; offset += xprResourceSize {
+		resource := data[offset:]
+		common := binary.LittleEndian.Uint32(resource[0:])
+		if common == 0xFFFFFFFF {
+			break
+		}
+		if common&xprResourceTypeMask != xprTextureType {
+			continue
+		}
+
+		dataOffset := headerSize + int(binary.LittleEndian.Uint32(resource[4:]))
+		format := binary.LittleEndian.Uint32(resource[12:])
+		pixelFormat := format >> xprFormatShift & xprFormatMask
+		width := 1 << (format >> xprUSizeShift & xprSizeMask)
+		height := 1 << (format >> xprVSizeShift & xprSizeMask)
+		if dataOffset > len(data) {
+			return nil, fmt.Errorf("XPR texture data is outside of the file")
+		}
+
+		var img *image.NRGBA
+		var err error
+		switch pixelFormat {
+		case xprFormatDXT1, xprFormatDXT3, xprFormatDXT5:
+			img, err = decodeDXT(data[dataOffset:], width, height, pixelFormat)
+		case xprFormatA1R5G5B5, xprFormatA4R4G4B4, xprFormatR5G6B5, xprFormatA8R8G8B8, xprFormatX8R8G8B8, xprFormatLinARGB, xprFormatLinRGB:
+			img, err = decodePixels(data[dataOffset:], width, height, pixelFormat)
+		default:
+			return nil, fmt.Errorf("unsupported XPR texture format 0x%02x", pixelFormat)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return img, nil
+	}
+	return nil, fmt.Errorf("no texture found in XPR")
+}
+
+// Finds and decodes an XPR image embedded anywhere in data, such as inside contentmeta.xbx.
+func decodeEmbeddedXPR(data []byte) (image.Image, error) {
+	offset := bytes.Index(data, []byte(xprMagic))
+	if offset < 0 {
+		return nil, fmt.Errorf("no XPR image found")
+	}
+	return decodeXPR(data[offset:])
+}